@@ -0,0 +1,101 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wooyang2018/ppov-blockchain/node"
+)
+
+const (
+	FlagGenesisBlock = "block"
+	FlagGenesisVotes = "votes"
+)
+
+var (
+	genesisChainID   int64
+	genesisBlockFile string
+	genesisVoteFiles []string
+)
+
+var genesisCmd = &cobra.Command{
+	Use:   "genesis",
+	Short: "create the genesis block offline",
+}
+
+var genesisInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "create the unsigned genesis block of the validators in genesis.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b0, err := node.InitGenesis(nodeConfig.DataDir, genesisChainID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created %s, hash %s\n", path.Join(nodeConfig.DataDir, node.GenesisBlockFile),
+			base64.StdEncoding.EncodeToString(b0.Hash()))
+		return nil
+	},
+}
+
+var genesisSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "vote for the genesis block with the node key",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		vote, err := node.SignGenesis(nodeConfig.DataDir, genesisChainID,
+			getGenesisBlockFile(), nodeConfig.KeyConfig)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created %s, voter %s\n", path.Join(nodeConfig.DataDir, node.GenesisVoteFile), vote.Voter())
+		return nil
+	},
+}
+
+var genesisAssembleCmd = &cobra.Command{
+	Use:   "assemble",
+	Short: "add the genesis block and its qc built from the votes to genesis.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b0, err := node.AssembleGenesis(nodeConfig.DataDir, genesisChainID,
+			getGenesisBlockFile(), genesisVoteFiles)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("updated %s, votes %d, hash %s\n", path.Join(nodeConfig.DataDir, node.GenesisFile),
+			len(genesisVoteFiles), base64.StdEncoding.EncodeToString(b0.Hash()))
+		return nil
+	},
+}
+
+func getGenesisBlockFile() string {
+	if genesisBlockFile != "" {
+		return genesisBlockFile
+	}
+	return path.Join(nodeConfig.DataDir, node.GenesisBlockFile)
+}
+
+func init() {
+	genesisCmd.PersistentFlags().Int64Var(&genesisChainID,
+		FlagChainID, nodeConfig.ConsensusConfig.ChainID,
+		"chainid is used to create genesis block")
+
+	for _, cmd := range []*cobra.Command{genesisSignCmd, genesisAssembleCmd} {
+		cmd.Flags().StringVar(&genesisBlockFile,
+			FlagGenesisBlock, "", "unsigned genesis block file (default is in data directory)")
+	}
+
+	genesisAssembleCmd.Flags().StringSliceVar(&genesisVoteFiles,
+		FlagGenesisVotes, nil, "vote files of the validators")
+	genesisAssembleCmd.MarkFlagRequired(FlagGenesisVotes)
+
+	for _, cmd := range []*cobra.Command{genesisInitCmd, genesisSignCmd, genesisAssembleCmd} {
+		cmd.SilenceUsage = true // errors are from the ceremony files, not the arguments
+	}
+	genesisCmd.AddCommand(genesisInitCmd, genesisSignCmd, genesisAssembleCmd)
+	rootCmd.AddCommand(genesisCmd)
+}
@@ -0,0 +1,137 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package main
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wooyang2018/ppov-blockchain/keystore"
+	"github.com/wooyang2018/ppov-blockchain/node"
+)
+
+const (
+	FlagKeyFile           = "file"
+	FlagKeyOut            = "out"
+	FlagNewPassphraseFile = "newPassphraseFile"
+	FlagLightKDF          = "lightKdf"
+)
+
+var (
+	keyFile           string
+	keyOut            string
+	newPassphraseFile string
+	keyLightKDF       bool
+)
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "manage the encrypted nodekey",
+}
+
+var keyGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "generate a new nodekey encrypted with a passphrase",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pass, err := keystore.ReadPassphrase(nodeConfig.KeyConfig.PassphraseFile, "New passphrase: ", true)
+		if err != nil {
+			return err
+		}
+		priv, err := node.GenerateNodeKey(nodeConfig.DataDir, pass, getScryptParams())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created %s, pubkey %s\n", getNodekeyFile(), priv.PublicKey())
+		return nil
+	},
+}
+
+var keyImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "encrypt a plaintext private key as the nodekey",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pass, err := keystore.ReadPassphrase(nodeConfig.KeyConfig.PassphraseFile, "New passphrase: ", true)
+		if err != nil {
+			return err
+		}
+		priv, err := node.ImportNodeKey(nodeConfig.DataDir, keyFile, pass, getScryptParams())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("imported %s, pubkey %s\n", getNodekeyFile(), priv.PublicKey())
+		return nil
+	},
+}
+
+var keyExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "write the plaintext private key of the nodekey",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		priv, err := node.ExportNodeKey(nodeConfig.DataDir, keyOut, nodeConfig.KeyConfig)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("exported %s, pubkey %s\n", keyOut, priv.PublicKey())
+		return nil
+	},
+}
+
+var keyPasswdCmd = &cobra.Command{
+	Use:   "passwd",
+	Short: "change the passphrase of the nodekey",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var newPass []byte
+		var err error
+		if newPassphraseFile != "" {
+			newPass, err = keystore.ReadPassphrase(newPassphraseFile, "", false)
+		} else {
+			newPass, err = keystore.PromptPassphrase("New passphrase: ", true)
+		}
+		if err != nil {
+			return err
+		}
+		priv, err := node.ChangePassphrase(nodeConfig.DataDir, nodeConfig.KeyConfig, newPass, getScryptParams())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("updated %s, pubkey %s\n", getNodekeyFile(), priv.PublicKey())
+		return nil
+	},
+}
+
+func getNodekeyFile() string {
+	return path.Join(nodeConfig.DataDir, node.NodekeyFile)
+}
+
+func getScryptParams() keystore.ScryptParams {
+	if keyLightKDF {
+		return keystore.LightScrypt
+	}
+	return keystore.StandardScrypt
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{keyGenerateCmd, keyImportCmd, keyPasswdCmd} {
+		cmd.Flags().BoolVar(&keyLightKDF,
+			FlagLightKDF, false, "use cheap key derivation, only for test clusters")
+	}
+
+	keyImportCmd.Flags().StringVar(&keyFile,
+		FlagKeyFile, "", "plaintext private key file (default is the nodekey in data directory)")
+
+	keyExportCmd.Flags().StringVar(&keyOut,
+		FlagKeyOut, "", "output file of the plaintext private key")
+	keyExportCmd.MarkFlagRequired(FlagKeyOut)
+
+	keyPasswdCmd.Flags().StringVar(&newPassphraseFile,
+		FlagNewPassphraseFile, "", "file of the new passphrase (default is terminal prompt)")
+
+	for _, cmd := range []*cobra.Command{keyGenerateCmd, keyImportCmd, keyExportCmd, keyPasswdCmd} {
+		cmd.SilenceUsage = true
+	}
+	keyCmd.AddCommand(keyGenerateCmd, keyImportCmd, keyExportCmd, keyPasswdCmd)
+	rootCmd.AddCommand(keyCmd)
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package main
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wooyang2018/ppov-blockchain/node"
+)
+
+const (
+	FlagEndpoint = "endpoint"
+	FlagKeys     = "keys"
+	FlagInterval = "interval"
+)
+
+var (
+	lightEndpoint string
+	lightKeys     []string
+	lightInterval time.Duration
+)
+
+var lightClientCmd = &cobra.Command{
+	Use:   "lightclient",
+	Short: "follow a remote node, verify its blocks and states and log the inconsistencies",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys := make([][]byte, len(lightKeys))
+		for i, k := range lightKeys {
+			b, err := hex.DecodeString(k)
+			if err != nil {
+				return err
+			}
+			keys[i] = b
+		}
+		node.RunLightClient(nodeConfig, lightEndpoint, keys, lightInterval)
+		return nil
+	},
+}
+
+func init() {
+	lightClientCmd.Flags().StringVar(&lightEndpoint,
+		FlagEndpoint, "http://127.0.0.1:9040", "api endpoint of the remote node")
+
+	lightClientCmd.Flags().StringSliceVar(&lightKeys,
+		FlagKeys, nil, "hex state keys to verify")
+
+	lightClientCmd.Flags().DurationVar(&lightInterval,
+		FlagInterval, time.Second, "interval of following the remote node")
+
+	lightClientCmd.Flags().Int64Var(&nodeConfig.ConsensusConfig.ChainID,
+		FlagChainID, nodeConfig.ConsensusConfig.ChainID,
+		"chainid of the followed chain")
+
+	lightClientCmd.Flags().Uint8Var(&nodeConfig.StorageConfig.MerkleBranchFactor,
+		FlagMerkleBranchFactor, nodeConfig.StorageConfig.MerkleBranchFactor,
+		"merkle tree branching factor of the followed chain")
+
+	rootCmd.AddCommand(lightClientCmd)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/wooyang2018/ppov-blockchain/keystore"
 	"github.com/wooyang2018/ppov-blockchain/node"
 )
 
@@ -17,6 +18,16 @@ const (
 	FlagDataDir     = "dataDir"
 	FlagBroadcastTx = "broadcastTx"
 	FlagChainID     = "chainID"
+	FlagSigCache    = "sigCacheSize"
+
+	FlagRemoteSigner  = "remoteSigner"
+	FlagSignerTimeout = "signerTimeout"
+	FlagSignerTLSCert = "signerTLSCert"
+	FlagSignerTLSKey  = "signerTLSKey"
+	FlagSignerTLSCA   = "signerTLSCA"
+
+	FlagPassphraseFile = "passphraseFile"
+	FlagPlainNodekey   = "plainNodekey"
 
 	FlagPointPort = "pointPort"
 	FlagTopicPort = "topicPort"
@@ -33,14 +44,31 @@ const (
 	FlagBatchTxLimit    = "consensus-batchTxLimit"
 	FlagBlockBatchLimit = "consensus-blockBatchLimit"
 	FlagVoteBatchLimit  = "consensus-voteBatchLimit"
+	FlagTxSizeLimit     = "consensus-txSizeLimit"
+	FlagBatchSizeLimit  = "consensus-batchSizeLimit"
+	FlagBlockSizeLimit  = "consensus-blockSizeLimit"
 	FlagTxWaitTime      = "consensus-txWaitTime"
 	FlagBatchWaitTime   = "consensus-batchWaitTime"
+	FlagPartitionTime   = "consensus-partitionTimeout"
 	FlagProposeTimeout  = "consensus-proposeTimeout"
 	FlagBatchTimeout    = "consensus-batchTimeout"
 	FlagBlockDelay      = "consensus-blockDelay"
 	FlagViewWidth       = "consensus-viewWidth"
 	FlagLeaderTimeout   = "consensus-leaderTimeout"
+	FlagNewViewTimeout  = "consensus-newViewTimeout"
 	FlagBenchmarkPath   = "consensus-benchmarkPath"
+	FlagExecuteTx       = "consensus-executeTx"
+	FlagPreserveTx      = "consensus-preserveTx"
+	FlagGenerateTx      = "consensus-generateTx"
+	FlagVoteBatch       = "consensus-voteBatch"
+	FlagVoteForward     = "consensus-voteForward"
+	FlagPartitionTx     = "consensus-partitionTx"
+	FlagCompactQC       = "consensus-compactQC"
+	FlagLeaderElection  = "consensus-leaderElection"
+	FlagReputationWin   = "consensus-reputationWindow"
+	FlagEpochDelay      = "consensus-epochDelay"
+	FlagSyncParallel    = "consensus-syncParallel"
+	FlagCheckpoint      = "consensus-checkpointInterval"
 )
 
 var nodeConfig = node.DefaultConfig
@@ -68,6 +96,12 @@ func init() {
 		FlagDataDir, "d", "", "blockchain data directory")
 	rootCmd.MarkPersistentFlagRequired(FlagDataDir)
 
+	rootCmd.PersistentFlags().StringVar(&nodeConfig.KeyConfig.PassphraseFile,
+		FlagPassphraseFile, "", "file of the nodekey passphrase (default is $"+keystore.PassphraseEnv+" or terminal prompt)")
+
+	rootCmd.PersistentFlags().BoolVar(&nodeConfig.KeyConfig.PlainNodekey,
+		FlagPlainNodekey, false, "allow the plaintext nodekey")
+
 	rootCmd.Flags().IntVar(&nodeConfig.PointPort,
 		FlagPointPort, nodeConfig.PointPort, "node point port")
 
@@ -80,6 +114,24 @@ func init() {
 	rootCmd.Flags().BoolVar(&nodeConfig.BroadcastTx,
 		FlagBroadcastTx, false, "whether to broadcast transaction")
 
+	rootCmd.Flags().IntVar(&nodeConfig.SigCacheSize,
+		FlagSigCache, nodeConfig.SigCacheSize, "number of verified signatures to cache, 0 to disable")
+
+	rootCmd.Flags().StringVar(&nodeConfig.RemoteSigner,
+		FlagRemoteSigner, "", "signer daemon address, unix:///path or tcp://host:port (default is signing with nodekey)")
+
+	rootCmd.Flags().DurationVar(&nodeConfig.SignerTimeout,
+		FlagSignerTimeout, nodeConfig.SignerTimeout, "timeout of a remote sign request")
+
+	rootCmd.Flags().StringVar(&nodeConfig.SignerTLS.Cert,
+		FlagSignerTLSCert, "", "client certificate for a tcp signer address")
+
+	rootCmd.Flags().StringVar(&nodeConfig.SignerTLS.Key,
+		FlagSignerTLSKey, "", "private key of the client certificate")
+
+	rootCmd.Flags().StringVar(&nodeConfig.SignerTLS.CA,
+		FlagSignerTLSCA, "", "ca certificate of the signer daemon")
+
 	rootCmd.Flags().Uint8Var(&nodeConfig.StorageConfig.MerkleBranchFactor,
 		FlagMerkleBranchFactor, nodeConfig.StorageConfig.MerkleBranchFactor,
 		"merkle tree branching factor")
@@ -108,6 +160,18 @@ func init() {
 		FlagVoteBatchLimit, nodeConfig.ConsensusConfig.VoteBatchLimit,
 		"batch count in a batch vote")
 
+	rootCmd.Flags().IntVar(&nodeConfig.ConsensusConfig.TxSizeLimit,
+		FlagTxSizeLimit, nodeConfig.ConsensusConfig.TxSizeLimit,
+		"maximum tx size in bytes")
+
+	rootCmd.Flags().IntVar(&nodeConfig.ConsensusConfig.BatchSizeLimit,
+		FlagBatchSizeLimit, nodeConfig.ConsensusConfig.BatchSizeLimit,
+		"maximum batch size in bytes")
+
+	rootCmd.Flags().IntVar(&nodeConfig.ConsensusConfig.BlockSizeLimit,
+		FlagBlockSizeLimit, nodeConfig.ConsensusConfig.BlockSizeLimit,
+		"maximum block size in bytes")
+
 	rootCmd.Flags().DurationVar(&nodeConfig.ConsensusConfig.TxWaitTime,
 		FlagTxWaitTime, nodeConfig.ConsensusConfig.TxWaitTime,
 		"block creation delay if no transactions in the pool")
@@ -116,6 +180,10 @@ func init() {
 		FlagBatchWaitTime, nodeConfig.ConsensusConfig.BatchWaitTime,
 		"maximum delay the leader waits for voting on a batch")
 
+	rootCmd.Flags().DurationVar(&nodeConfig.ConsensusConfig.PartitionTimeout,
+		FlagPartitionTime, nodeConfig.ConsensusConfig.PartitionTimeout,
+		"delay before the next worker also batches a partitioned tx")
+
 	rootCmd.Flags().DurationVar(&nodeConfig.ConsensusConfig.ProposeTimeout,
 		FlagProposeTimeout, nodeConfig.ConsensusConfig.ProposeTimeout,
 		"duration to wait to propose next block if leader cannot create qc")
@@ -132,7 +200,59 @@ func init() {
 		FlagLeaderTimeout, nodeConfig.ConsensusConfig.LeaderTimeout,
 		"leader must create next qc in this duration")
 
+	rootCmd.Flags().DurationVar(&nodeConfig.ConsensusConfig.NewViewTimeout,
+		FlagNewViewTimeout, nodeConfig.ConsensusConfig.NewViewTimeout,
+		"maximum wait for new view messages before a new leader proposes")
+
 	rootCmd.Flags().StringVar(&nodeConfig.ConsensusConfig.BenchmarkPath,
 		FlagBenchmarkPath, nodeConfig.ConsensusConfig.BenchmarkPath,
 		"path to save the benchmark log of the consensus algorithm")
+
+	rootCmd.Flags().BoolVar(&nodeConfig.ConsensusConfig.ExecuteTxFlag,
+		FlagExecuteTx, nodeConfig.ConsensusConfig.ExecuteTxFlag,
+		"whether to execute transactions of committed blocks")
+
+	rootCmd.Flags().BoolVar(&nodeConfig.ConsensusConfig.PreserveTxFlag,
+		FlagPreserveTx, nodeConfig.ConsensusConfig.PreserveTxFlag,
+		"whether to keep transactions in the pool after batching")
+
+	rootCmd.Flags().BoolVar(&nodeConfig.ConsensusConfig.GenerateTxFlag,
+		FlagGenerateTx, nodeConfig.ConsensusConfig.GenerateTxFlag,
+		"whether to generate mock transactions on startup")
+
+	rootCmd.Flags().BoolVar(&nodeConfig.ConsensusConfig.VoteBatchFlag,
+		FlagVoteBatch, nodeConfig.ConsensusConfig.VoteBatchFlag,
+		"whether voters vote on batches before proposing blocks")
+
+	rootCmd.Flags().BoolVar(&nodeConfig.ConsensusConfig.VoteForwardFlag,
+		FlagVoteForward, nodeConfig.ConsensusConfig.VoteForwardFlag,
+		"whether to send votes to the leader of the next block")
+
+	rootCmd.Flags().BoolVar(&nodeConfig.ConsensusConfig.PartitionTxFlag,
+		FlagPartitionTx, nodeConfig.ConsensusConfig.PartitionTxFlag,
+		"whether each tx is batched only by its responsible worker")
+
+	rootCmd.Flags().BoolVar(&nodeConfig.ConsensusConfig.CompactQCFlag,
+		FlagCompactQC, nodeConfig.ConsensusConfig.CompactQCFlag,
+		"whether to encode quorum certs with signer bitmaps")
+
+	rootCmd.Flags().StringVar(&nodeConfig.ConsensusConfig.LeaderElection,
+		FlagLeaderElection, nodeConfig.ConsensusConfig.LeaderElection,
+		"leader election policy (round-robin, reputation or random)")
+
+	rootCmd.Flags().IntVar(&nodeConfig.ConsensusConfig.ReputationWindow,
+		FlagReputationWin, nodeConfig.ConsensusConfig.ReputationWindow,
+		"committed block count to evaluate worker reputation")
+
+	rootCmd.Flags().IntVar(&nodeConfig.ConsensusConfig.EpochDelay,
+		FlagEpochDelay, nodeConfig.ConsensusConfig.EpochDelay,
+		"block count between validator set approval and activation")
+
+	rootCmd.Flags().IntVar(&nodeConfig.ConsensusConfig.SyncParallel,
+		FlagSyncParallel, nodeConfig.ConsensusConfig.SyncParallel,
+		"maximum peers to download blocks from in parallel")
+
+	rootCmd.Flags().IntVar(&nodeConfig.ConsensusConfig.CheckpointInterval,
+		FlagCheckpoint, nodeConfig.ConsensusConfig.CheckpointInterval,
+		"committed blocks between state checkpoints, 0 to disable")
 }
@@ -0,0 +1,163 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/keystore"
+	"github.com/wooyang2018/ppov-blockchain/logger"
+	"github.com/wooyang2018/ppov-blockchain/remotesigner"
+)
+
+const (
+	FlagKey            = "key"
+	FlagPlainKey       = "plainKey"
+	FlagPassphraseFile = "passphraseFile"
+	FlagState          = "state"
+	FlagListen         = "listen"
+	FlagChainID        = "chainID"
+	FlagTLSCert        = "tlsCert"
+	FlagTLSKey         = "tlsKey"
+	FlagTLSCA          = "tlsCA"
+)
+
+var (
+	keyFile        string
+	plainKey       bool
+	passphraseFile string
+	stateFile      string
+	listenAddr     string
+	chainID        int64
+	tlsFiles       remotesigner.TLSFiles
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "signer",
+	Short: "sign blocks, votes and checkpoints for a validator node without double signing",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inst, err := zap.NewProduction()
+		if err != nil {
+			return err
+		}
+		logger.Set(inst.Sugar())
+
+		priv, err := readKey()
+		if err != nil {
+			return err
+		}
+		srv, err := remotesigner.NewServer(priv, core.NewSignScheme(chainID, 0), stateFile)
+		if err != nil {
+			return err
+		}
+		network, address, err := remotesigner.ParseAddr(listenAddr)
+		if err != nil {
+			return err
+		}
+		ln, err := listen(network, address)
+		if err != nil {
+			return err
+		}
+		state := srv.State()
+		logger.I().Infow("signer started", "pubkey", priv.PublicKey(), "listen", listenAddr,
+			"proposalHeight", state.Proposal.Height, "voteHeight", state.Vote.Height, "checkpointHeight", state.Checkpoint.Height)
+
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-c
+			srv.Close()
+		}()
+		srv.Serve(ln)
+		logger.I().Info("signer stopped")
+		return nil
+	},
+}
+
+// listen accepts only the node user on a Unix socket and only clients of the ca over tcp
+func listen(network, address string) (net.Listener, error) {
+	if network == "tcp" {
+		conf, err := tlsFiles.Config()
+		if err != nil {
+			return nil, err
+		}
+		return tls.Listen(network, address, conf)
+	}
+	os.Remove(address)
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(address, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+func readKey() (*core.PrivateKey, error) {
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s, %w", keyFile, err)
+	}
+	if !keystore.IsKeyFile(b) {
+		if !plainKey {
+			return nil, fmt.Errorf("%s is not encrypted, allow it with --%s", keyFile, FlagPlainKey)
+		}
+		return core.NewPrivateKey(b)
+	}
+	pass, err := keystore.ReadPassphrase(passphraseFile, "Key passphrase: ", false)
+	if err != nil {
+		return nil, err
+	}
+	return keystore.ReadKeyFile(keyFile, pass)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func init() {
+	rootCmd.Flags().StringVar(&keyFile,
+		FlagKey, "", "validator key file, encrypted by `chain key`")
+	rootCmd.MarkFlagRequired(FlagKey)
+
+	rootCmd.Flags().BoolVar(&plainKey,
+		FlagPlainKey, false, "allow the plaintext key file")
+
+	rootCmd.Flags().StringVar(&passphraseFile,
+		FlagPassphraseFile, "", "file of the key passphrase (default is $"+keystore.PassphraseEnv+" or terminal prompt)")
+
+	rootCmd.Flags().StringVar(&stateFile,
+		FlagState, "signer_state.json", "file of the signed heights")
+
+	rootCmd.Flags().StringVar(&listenAddr,
+		FlagListen, "unix:///tmp/ppov-signer.sock", "listen address, unix:///path or tcp://host:port")
+
+	rootCmd.Flags().Int64Var(&chainID,
+		FlagChainID, 0, "chainid of the signed messages")
+
+	rootCmd.Flags().StringVar(&tlsFiles.Cert,
+		FlagTLSCert, "", "server certificate for a tcp listen address")
+
+	rootCmd.Flags().StringVar(&tlsFiles.Key,
+		FlagTLSKey, "", "private key of the server certificate")
+
+	rootCmd.Flags().StringVar(&tlsFiles.CA,
+		FlagTLSCA, "", "ca certificate of the node clients")
+
+	rootCmd.SilenceUsage = true
+}
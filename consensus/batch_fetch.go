@@ -0,0 +1,103 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/logger"
+)
+
+// committed blocks which keep their batch bodies
+const batchKeepBlocks = 20
+
+// fetchMissingBatches requests the batch bodies not stored locally from their workers,
+// or from the peer if the worker fails, and stores the batch txs into txpool
+func fetchMissingBatches(resources *Resources, sizeLimit int, peer *core.PublicKey,
+	headers []*core.BatchHeader,
+) error {
+	for _, header := range headers {
+		if resources.Storage.HasBatch(header.Hash()) {
+			if !hasMissingTxs(resources, header.Transactions()) {
+				continue
+			}
+			// txpool is empty after restart, the stored batch fills it again
+			batch, err := resources.Storage.GetBatch(header.Hash())
+			if err != nil {
+				return err
+			}
+			if err := resources.TxPool.StorePendingTxs(batch.TxList()); err != nil {
+				return err
+			}
+			continue
+		}
+		batch, err := requestBatch(resources, sizeLimit, header.Proposer(), header)
+		if err != nil && peer != nil && !peer.Equal(header.Proposer()) {
+			batch, err = requestBatch(resources, sizeLimit, peer, header)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot fetch batch %s, %w", base64String(header.Hash()), err)
+		}
+		if err := resources.TxPool.StorePendingTxs(batch.TxList()); err != nil {
+			return err
+		}
+		if err := resources.Storage.StoreBatch(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneCommittedBatches deletes the batch bodies of the block committed batchKeepBlocks before blk,
+// later blocks keep them to serve the validators catching up
+func pruneCommittedBatches(resources *Resources, blk *core.Block) {
+	if blk.Height() < batchKeepBlocks {
+		return
+	}
+	old, err := resources.Storage.GetBlockByHeight(blk.Height() - batchKeepBlocks)
+	if err != nil {
+		logger.I().Warnw("get block to prune batches failed", "error", err)
+		return
+	}
+	hashes := make([][]byte, len(old.BatchHeaders()))
+	for i, header := range old.BatchHeaders() {
+		hashes[i] = header.Hash()
+	}
+	if len(hashes) == 0 {
+		return
+	}
+	if err := resources.Storage.DeleteBatches(hashes); err != nil {
+		logger.I().Warnw("prune committed batches failed", "error", err)
+	}
+}
+
+func hasMissingTxs(resources *Resources, hashes [][]byte) bool {
+	for _, hash := range hashes {
+		if resources.TxPool.GetTx(hash) == nil && !resources.Storage.HasTx(hash) {
+			return true
+		}
+	}
+	return false
+}
+
+func requestBatch(resources *Resources, sizeLimit int, peer *core.PublicKey,
+	header *core.BatchHeader,
+) (*core.Batch, error) {
+	batch, err := resources.MsgSvc.RequestBatch(peer, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBatchSize(batch, sizeLimit); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(batch.Header().Hash(), header.Hash()) {
+		return nil, fmt.Errorf("requested batch not matched")
+	}
+	if err := batch.Validate(resources.VldStore); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func TestFetchMissingBatches(t *testing.T) {
+	asrt := assert.New(t)
+
+	worker, peer := core.GenerateKey(nil), core.GenerateKey(nil)
+	keys := []string{worker.PublicKey().String(), peer.PublicKey().String()}
+	newBatch := func(nonce int64) *core.Batch {
+		tx := core.NewTransaction().SetNonce(nonce).Sign(worker)
+		return core.NewBatch().SetTransactions([]*core.Transaction{tx}).Sign(worker)
+	}
+	stored, fetched, failover, lost := newBatch(1), newBatch(2), newBatch(3), newBatch(4)
+	pooled := newBatch(5)
+
+	strg := new(MockStorage)
+	strg.On("HasBatch", stored.Header().Hash()).Return(true)
+	strg.On("HasBatch", pooled.Header().Hash()).Return(true)
+	strg.On("HasTx", mock.Anything).Return(false)
+	strg.On("GetBatch", stored.Header().Hash()).Return(stored, nil)
+	strg.On("HasBatch", mock.Anything).Return(false)
+	strg.On("StoreBatch", mock.Anything).Return(nil)
+	msgSvc := new(MockMsgService)
+	msgSvc.On("RequestBatch", worker.PublicKey(), fetched.Header().Hash()).Return(fetched, nil)
+	msgSvc.On("RequestBatch", worker.PublicKey(), failover.Header().Hash()).Return(nil, errors.New("worker down"))
+	msgSvc.On("RequestBatch", peer.PublicKey(), failover.Header().Hash()).Return(failover, nil)
+	// returns a different batch
+	msgSvc.On("RequestBatch", worker.PublicKey(), lost.Header().Hash()).Return(fetched, nil)
+	msgSvc.On("RequestBatch", peer.PublicKey(), lost.Header().Hash()).Return(nil, errors.New("not found"))
+	txPool := new(MockTxPool)
+	txPool.On("StorePendingTxs", mock.Anything).Return(nil)
+	txPool.On("GetTx", (*pooled.TxList())[0].Hash()).Return((*pooled.TxList())[0])
+	txPool.On("GetTx", mock.Anything).Return(nil) // txpool is empty after restart
+
+	resources := &Resources{
+		VldStore: core.NewValidatorStore(keys, keys),
+		Storage:  strg,
+		MsgSvc:   msgSvc,
+		TxPool:   txPool,
+	}
+
+	headers := []*core.BatchHeader{stored.Header(), pooled.Header(), fetched.Header(), failover.Header()}
+	asrt.NoError(fetchMissingBatches(resources, DefaultConfig.BatchSizeLimit, peer.PublicKey(), headers))
+	strg.AssertCalled(t, "StoreBatch", fetched)
+	strg.AssertCalled(t, "StoreBatch", failover)
+	strg.AssertNotCalled(t, "StoreBatch", stored)
+	txPool.AssertCalled(t, "StorePendingTxs", stored.TxList())
+	strg.AssertNotCalled(t, "GetBatch", pooled.Header().Hash())
+	txPool.AssertCalled(t, "StorePendingTxs", failover.TxList())
+
+	asrt.Error(fetchMissingBatches(resources, DefaultConfig.BatchSizeLimit, peer.PublicKey(), []*core.BatchHeader{lost.Header()}))
+	strg.AssertNotCalled(t, "StoreBatch", lost)
+
+	// oversize batch is rejected on receipt
+	fetched2 := newBatch(6)
+	strg.On("HasBatch", fetched2.Header().Hash()).Return(false)
+	msgSvc.On("RequestBatch", worker.PublicKey(), fetched2.Header().Hash()).Return(fetched2, nil)
+	asrt.Error(fetchMissingBatches(resources, fetched2.Size()-1, nil, []*core.BatchHeader{fetched2.Header()}))
+	strg.AssertNotCalled(t, "StoreBatch", fetched2)
+}
+
+func TestPruneCommittedBatches(t *testing.T) {
+	priv := core.GenerateKey(nil)
+	batch := core.NewBatch().Sign(priv)
+	old := core.NewBlock().SetHeight(5).SetBatchHeaders([]*core.BatchHeader{batch.Header()}, false).Sign(priv)
+
+	strg := new(MockStorage)
+	strg.On("GetBlockByHeight", uint64(5)).Return(old, nil)
+	strg.On("DeleteBatches", [][]byte{batch.Header().Hash()}).Return(nil)
+	resources := &Resources{Storage: strg}
+
+	pruneCommittedBatches(resources, core.NewBlock().SetHeight(batchKeepBlocks-1))
+	strg.AssertNotCalled(t, "GetBlockByHeight", mock.Anything)
+
+	pruneCommittedBatches(resources, core.NewBlock().SetHeight(5+batchKeepBlocks))
+	strg.AssertExpectations(t)
+}
@@ -0,0 +1,207 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/logger"
+)
+
+// blocks downloaded before they are verified and applied in order
+const syncBatchSize = 32
+
+type syncProgress struct {
+	Syncing bool
+	Start   uint64
+	Target  uint64 // exclusive
+	Height  uint64 // last applied height
+}
+
+type fetchedBlock struct {
+	block *core.Block
+	peer  *core.PublicKey
+}
+
+// blockSyncer downloads committed blocks of a height range from several validators in parallel,
+// blocks are verified and applied in height order, a failed height is requested from another peer
+type blockSyncer struct {
+	resources *Resources
+	config    Config
+	state     *state
+
+	// apply updates a verified block connected to its parent to hotstuff
+	apply func(peer *core.PublicKey, blk, parent *core.Block) error
+
+	progress syncProgress
+	mtx      sync.RWMutex
+}
+
+func newBlockSyncer(resources *Resources, config Config, state *state,
+	apply func(peer *core.PublicKey, blk, parent *core.Block) error,
+) *blockSyncer {
+	return &blockSyncer{
+		resources: resources,
+		config:    config,
+		state:     state,
+		apply:     apply,
+	}
+}
+
+func (bs *blockSyncer) getProgress() syncProgress {
+	bs.mtx.RLock()
+	defer bs.mtx.RUnlock()
+	return bs.progress
+}
+
+func (bs *blockSyncer) setProgress(fn func(p *syncProgress)) {
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+	fn(&bs.progress)
+}
+
+// syncForward syncs blocks in [start, end), hint is the peer asked first
+func (bs *blockSyncer) syncForward(hint *core.PublicKey, start, end uint64) error {
+	if start >= end {
+		return nil
+	}
+	peers := bs.syncPeers(hint)
+	if len(peers) == 0 {
+		return errors.New("no peers to sync blocks")
+	}
+	bs.setProgress(func(p *syncProgress) {
+		*p = syncProgress{Syncing: true, Start: start, Target: end, Height: start - 1}
+	})
+	defer bs.setProgress(func(p *syncProgress) { p.Syncing = false })
+	logger.I().Infow("syncing blocks", "start", start, "end", end, "peers", len(peers))
+
+	for from := start; from < end; from += syncBatchSize {
+		to := from + syncBatchSize
+		if to > end {
+			to = end
+		}
+		fetched, err := bs.fetchRange(peers, from, to)
+		if err != nil {
+			return err
+		}
+		for i, fb := range fetched {
+			height := from + uint64(i)
+			if err := bs.verifyAndApply(fb); err != nil {
+				logger.I().Warnw("sync block failed, retry other peers",
+					"height", height, "error", err)
+				if fb, err = bs.fetchBlock(peers, height, fb.peer); err != nil {
+					return err
+				}
+				if err := bs.verifyAndApply(fb); err != nil {
+					return err
+				}
+			}
+			bs.setProgress(func(p *syncProgress) { p.Height = height })
+		}
+	}
+	logger.I().Infow("synced blocks", "start", start, "end", end)
+	return nil
+}
+
+func (bs *blockSyncer) fetchRange(peers []*core.PublicKey, from, to uint64) ([]*fetchedBlock, error) {
+	jobs := make(chan uint64, to-from)
+	for height := from; height < to; height++ {
+		jobs <- height
+	}
+	close(jobs)
+
+	workers := bs.config.SyncParallel
+	if workers <= 0 || workers > len(peers) {
+		workers = len(peers)
+	}
+	fetched := make([]*fetchedBlock, to-from)
+	errs := make([]error, to-from)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		// each worker starts from a different peer to spread the load
+		go func(offset int) {
+			defer wg.Done()
+			ordered := append(append([]*core.PublicKey{}, peers[offset:]...), peers[:offset]...)
+			for height := range jobs {
+				fetched[height-from], errs[height-from] = bs.fetchBlock(ordered, height, nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fetched, nil
+}
+
+// fetchBlock tries the peers in order except the skipped one
+func (bs *blockSyncer) fetchBlock(
+	peers []*core.PublicKey, height uint64, skip *core.PublicKey,
+) (*fetchedBlock, error) {
+	var lastErr error
+	for _, peer := range peers {
+		if skip != nil && peer.Equal(skip) {
+			continue
+		}
+		blk, err := bs.resources.MsgSvc.RequestBlockByHeight(peer, height)
+		if err == nil && blk.Height() != height {
+			err = fmt.Errorf("got block height %d", blk.Height())
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return &fetchedBlock{block: blk, peer: peer}, nil
+	}
+	return nil, fmt.Errorf("cannot get block by height %d, %w", height, lastErr)
+}
+
+// verifyAndApply validates the block after its parent is applied,
+// so that the validator set of the block height is known
+func (bs *blockSyncer) verifyAndApply(fb *fetchedBlock) error {
+	blk := fb.block
+	if err := blk.Validate(bs.resources.VldStore); err != nil {
+		return fmt.Errorf("validate block error %w", err)
+	}
+	parent := bs.state.getBlock(blk.ParentHash())
+	if parent == nil {
+		return fmt.Errorf("cannot connect chain, parent not found")
+	}
+	if err := bs.state.verifyQCHeight(blk.QuorumCert()); err != nil {
+		return fmt.Errorf("qc of block %d, %w", blk.Height(), err)
+	}
+	return bs.apply(fb.peer, blk, parent)
+}
+
+// syncPeers returns the validators other than this node, hint first
+func (bs *blockSyncer) syncPeers(hint *core.PublicKey) []*core.PublicKey {
+	self := bs.resources.Signer.PublicKey()
+	seen := make(map[string]struct{})
+	peers := make([]*core.PublicKey, 0)
+	add := func(pubKey *core.PublicKey) {
+		if pubKey == nil || pubKey.Equal(self) {
+			return
+		}
+		if _, ok := seen[pubKey.String()]; ok {
+			return
+		}
+		seen[pubKey.String()] = struct{}{}
+		peers = append(peers, pubKey)
+	}
+	add(hint)
+	vs := bs.resources.VldStore
+	for i := 0; i < vs.WorkerCount(); i++ {
+		add(vs.GetWorker(i))
+	}
+	for i := 0; i < vs.VoterCount(); i++ {
+		add(vs.GetVoter(i))
+	}
+	return peers
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func newSyncTestChain(privs []*core.PrivateKey, count int) []*core.Block {
+	blocks := []*core.Block{core.NewBlock().SetHeight(0).Sign(privs[0])}
+	for i := 1; i <= count; i++ {
+		parent := blocks[i-1]
+		votes := make([]*core.Vote, len(privs))
+		for j, priv := range privs {
+			votes[j] = parent.Vote(priv)
+		}
+		blk := core.NewBlock().
+			SetHeight(uint64(i)).
+			SetParentHash(parent.Hash()).
+			SetQuorumCert(core.NewQuorumCert().Build(votes)).
+			Sign(privs[i%len(privs)])
+		blocks = append(blocks, blk)
+	}
+	return blocks
+}
+
+func setupBlockSyncer(privs []*core.PrivateKey, b0 *core.Block) (*blockSyncer, *MockMsgService, *[]uint64) {
+	keys := make([]string, len(privs))
+	for i, priv := range privs {
+		keys[i] = priv.PublicKey().String()
+	}
+	msgSvc := new(MockMsgService)
+	resources := &Resources{
+		Signer:   privs[0],
+		VldStore: core.NewValidatorStore(keys, keys),
+		MsgSvc:   msgSvc,
+	}
+	state := newState(resources)
+	state.setBlock(b0)
+	applied := make([]uint64, 0)
+	config := DefaultConfig
+	config.SyncParallel = 2
+	bs := newBlockSyncer(resources, config, state,
+		func(peer *core.PublicKey, blk, parent *core.Block) error {
+			state.setBlock(blk)
+			applied = append(applied, blk.Height())
+			return nil
+		})
+	return bs, msgSvc, &applied
+}
+
+func TestBlockSyncer_syncForward(t *testing.T) {
+	privs := []*core.PrivateKey{core.GenerateKey(nil), core.GenerateKey(nil), core.GenerateKey(nil)}
+	chain := newSyncTestChain(privs, syncBatchSize+5)
+	end := uint64(len(chain))
+	p1, p2 := privs[1].PublicKey(), privs[2].PublicKey()
+	// a block with a valid qc which does not connect to the chain
+	forged := core.NewBlock().SetHeight(10).SetParentHash([]byte("unknown")).
+		SetQuorumCert(chain[10].QuorumCert()).Sign(privs[1])
+
+	tests := []struct {
+		name  string
+		setup func(msgSvc *MockMsgService)
+		valid bool
+	}{
+		{"all peers serve", func(msgSvc *MockMsgService) {
+			for h := uint64(1); h < end; h++ {
+				msgSvc.On("RequestBlockByHeight", p1, h).Return(chain[h], nil)
+				msgSvc.On("RequestBlockByHeight", p2, h).Return(chain[h], nil)
+			}
+		}, true},
+		{"retry failed peer", func(msgSvc *MockMsgService) {
+			for h := uint64(1); h < end; h++ {
+				msgSvc.On("RequestBlockByHeight", p1, h).Return(nil, errors.New("timeout"))
+				msgSvc.On("RequestBlockByHeight", p2, h).Return(chain[h], nil)
+			}
+		}, true},
+		{"retry forged block", func(msgSvc *MockMsgService) {
+			for h := uint64(1); h < end; h++ {
+				if h == 10 {
+					msgSvc.On("RequestBlockByHeight", p1, h).Return(forged, nil)
+				} else {
+					msgSvc.On("RequestBlockByHeight", p1, h).Return(chain[h], nil)
+				}
+				msgSvc.On("RequestBlockByHeight", p2, h).Return(chain[h], nil)
+			}
+		}, true},
+		{"all peers fail", func(msgSvc *MockMsgService) {
+			msgSvc.On("RequestBlockByHeight", mock.Anything, mock.Anything).
+				Return(nil, errors.New("timeout"))
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asrt := assert.New(t)
+			bs, msgSvc, applied := setupBlockSyncer(privs, chain[0])
+			tt.setup(msgSvc)
+
+			err := bs.syncForward(p1, 1, end)
+			progress := bs.getProgress()
+			asrt.False(progress.Syncing)
+			asrt.Equal(end, progress.Target)
+			if !tt.valid {
+				asrt.Error(err)
+				asrt.Empty(*applied)
+				return
+			}
+			asrt.NoError(err)
+			asrt.Equal(end-1, progress.Height)
+			for i, h := range *applied {
+				asrt.EqualValues(i+1, h, "blocks must be applied in order")
+			}
+			asrt.Len(*applied, int(end-1))
+		})
+	}
+}
+
+func TestBlockSyncer_syncPeers(t *testing.T) {
+	asrt := assert.New(t)
+	privs := []*core.PrivateKey{core.GenerateKey(nil), core.GenerateKey(nil), core.GenerateKey(nil)}
+	bs, _, _ := setupBlockSyncer(privs, core.NewBlock().Sign(privs[0]))
+
+	peers := bs.syncPeers(privs[2].PublicKey())
+	asrt.Equal([]*core.PublicKey{privs[2].PublicKey(), privs[1].PublicKey()}, peers,
+		"hint first, without self and duplicates")
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/logger"
+)
+
+// checkpoint intervals ahead of the committed height for which votes are kept
+const checkpointWindow = 10
+
+// checkpointPool signs the state after every CheckpointInterval committed blocks
+// and stores the cert once votes of a quorum for the same checkpoint are received
+type checkpointPool struct {
+	resources *Resources
+	interval  uint64
+
+	votes  map[uint64]map[string][]*core.CheckpointVote // height -> checkpoint sum -> votes
+	voters map[uint64]map[string]struct{}               // height -> voters, one vote for each voter
+	mtx    sync.Mutex
+}
+
+func newCheckpointPool(resources *Resources, interval int) *checkpointPool {
+	if interval <= 0 {
+		return nil
+	}
+	return &checkpointPool{
+		resources: resources,
+		interval:  uint64(interval),
+		votes:     make(map[uint64]map[string][]*core.CheckpointVote),
+		voters:    make(map[uint64]map[string]struct{}),
+	}
+}
+
+func (cp *checkpointPool) isCheckpoint(height uint64) bool {
+	return height > 0 && height%cp.interval == 0
+}
+
+// onCommit votes for the state merkle root after the block is committed
+func (cp *checkpointPool) onCommit(blk *core.Block) {
+	if !cp.isCheckpoint(blk.Height()) {
+		return
+	}
+	signer := cp.resources.Signer
+	vs := cp.resources.VldStore.AtHeight(blk.Height())
+	if !vs.IsVoter(signer.PublicKey()) && !vs.IsWorker(signer.PublicKey()) {
+		return
+	}
+	vote, err := core.NewCheckpointVote().TrySign(blk.Height(), blk.Hash(),
+		cp.resources.Storage.GetMerkleRoot(), signer)
+	if err != nil {
+		logger.I().Errorw("sign checkpoint failed", "height", blk.Height(), "error", err)
+		return
+	}
+	if err := cp.addVote(vote); err != nil {
+		logger.I().Errorw("add checkpoint vote failed", "error", err)
+	}
+	if err := cp.resources.MsgSvc.BroadcastCheckpointVote(vote); err != nil {
+		logger.I().Errorw("broadcast checkpoint vote failed", "error", err)
+	}
+}
+
+func (cp *checkpointPool) onReceiveVote(vote *core.CheckpointVote) error {
+	if !cp.isCheckpoint(vote.Height()) {
+		return fmt.Errorf("not a checkpoint height %d", vote.Height())
+	}
+	if err := vote.Validate(cp.resources.VldStore.AtHeight(vote.Height())); err != nil {
+		return err
+	}
+	return cp.addVote(vote)
+}
+
+func (cp *checkpointPool) addVote(vote *core.CheckpointVote) error {
+	cp.mtx.Lock()
+	defer cp.mtx.Unlock()
+
+	height := vote.Height()
+	if cp.resources.Storage.HasCheckpointCert(height) {
+		return nil
+	}
+	committed := cp.resources.Storage.GetBlockHeight()
+	if height > committed+checkpointWindow*cp.interval {
+		return fmt.Errorf("checkpoint height %d too far from committed %d", height, committed)
+	}
+	cp.pruneVotes(committed)
+
+	if cp.voters[height] == nil {
+		cp.voters[height] = make(map[string]struct{})
+		cp.votes[height] = make(map[string][]*core.CheckpointVote)
+	}
+	if _, voted := cp.voters[height][vote.Voter().String()]; voted {
+		return nil
+	}
+	cp.voters[height][vote.Voter().String()] = struct{}{}
+	sum := string(vote.Sum())
+	cp.votes[height][sum] = append(cp.votes[height][sum], vote)
+
+	votes := cp.votes[height][sum]
+	vs := cp.resources.VldStore.AtHeight(height)
+	var power uint64
+	for _, v := range votes {
+		power += vs.GetPower(v.Voter())
+	}
+	if power < vs.QuorumPower() {
+		return nil
+	}
+	cert, err := core.NewCheckpointCert().Build(votes)
+	if err != nil {
+		return err
+	}
+	delete(cp.votes, height)
+	delete(cp.voters, height)
+	if err := cp.resources.Storage.SetCheckpointCert(cert); err != nil {
+		return err
+	}
+	logger.I().Infow("stored checkpoint", "height", height, "signatures", len(votes))
+	return nil
+}
+
+// pruneVotes drops the votes of old heights which cannot reach a quorum anymore
+func (cp *checkpointPool) pruneVotes(committed uint64) {
+	for height := range cp.votes {
+		if height+checkpointWindow*cp.interval < committed {
+			delete(cp.votes, height)
+			delete(cp.voters, height)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func TestCheckpointPool(t *testing.T) {
+	asrt := assert.New(t)
+
+	privKeys := make([]*core.PrivateKey, 4)
+	keys := make([]string, len(privKeys))
+	for i := range privKeys {
+		privKeys[i] = core.GenerateKey(nil)
+		keys[i] = privKeys[i].PublicKey().String()
+	}
+	var stored *core.CheckpointCert
+	strg := new(MockStorage)
+	strg.On("GetBlockHeight").Return(10)
+	strg.On("GetMerkleRoot").Return([]byte("root"))
+	strg.On("HasCheckpointCert", mock.Anything).Return(false)
+	strg.On("SetCheckpointCert", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*core.CheckpointCert)
+	})
+	msgSvc := new(MockMsgService)
+	msgSvc.On("BroadcastCheckpointVote", mock.Anything).Return(nil)
+	vs := core.NewValidatorStore(keys, keys)
+
+	asrt.Nil(newCheckpointPool(&Resources{}, 0), "disabled")
+	cp := newCheckpointPool(&Resources{
+		Signer:   privKeys[0],
+		VldStore: vs,
+		Storage:  strg,
+		MsgSvc:   msgSvc,
+	}, 5)
+
+	blk := core.NewBlock().SetHeight(10).Sign(privKeys[0])
+	cp.onCommit(core.NewBlock().SetHeight(9).Sign(privKeys[0]))
+	msgSvc.AssertNotCalled(t, "BroadcastCheckpointVote", mock.Anything)
+	cp.onCommit(blk)
+	msgSvc.AssertNumberOfCalls(t, "BroadcastCheckpointVote", 1)
+
+	newVote := func(i int, height uint64, root string) *core.CheckpointVote {
+		return core.NewCheckpointVote().Sign(height, blk.Hash(), []byte(root), privKeys[i])
+	}
+	asrt.Error(cp.onReceiveVote(newVote(1, 9, "root")), "not a checkpoint height")
+	asrt.Error(cp.onReceiveVote(newVote(1, 100, "root")), "too far ahead")
+
+	asrt.NoError(cp.onReceiveVote(newVote(1, 10, "other root")))
+	asrt.NoError(cp.onReceiveVote(newVote(1, 10, "root")), "second vote of voter ignored")
+	asrt.NoError(cp.onReceiveVote(newVote(2, 10, "root")))
+	asrt.Nil(stored, "different roots not counted together")
+
+	asrt.NoError(cp.onReceiveVote(newVote(3, 10, "root")))
+	if asrt.NotNil(stored) {
+		asrt.EqualValues(10, stored.Height())
+		asrt.Equal([]byte("root"), stored.MerkleRoot())
+		asrt.NoError(stored.Validate(vs))
+	}
+	asrt.Empty(cp.votes)
+}
@@ -4,16 +4,23 @@
 
 package consensus
 
-import "time"
+import (
+	"time"
 
-const ExecuteTxFlag = false // set to false when benchmark test
-const PreserveTxFlag = true // set to true when benchmark test
-const GenerateTxFlag = true
-const VoteBatchFlag = false // set to false to prevent voting on batch
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/hotstuff"
+)
 
 type Config struct {
 	ChainID int64
 
+	// hotstuff commit rule of the chain (loaded from genesis file)
+	Variant hotstuff.Variant
+
+	// genesis block and qc signed offline (loaded from genesis file), validators create them online if nil
+	GenesisBlock *core.Block
+	GenesisQC    *core.QuorumCert
+
 	// maximum tx count in a batch
 	BatchTxLimit int
 
@@ -23,12 +30,24 @@ type Config struct {
 	// batch count in a batch vote
 	VoteBatchLimit int
 
+	// maximum encoded size of a tx in bytes, larger txs are rejected by the api and txpool
+	TxSizeLimit int
+
+	// maximum encoded size of a batch in bytes
+	BatchSizeLimit int
+
+	// maximum encoded size of a block in bytes, validators reject larger proposals
+	BlockSizeLimit int
+
 	// block creation delay if no transactions in the pool
 	TxWaitTime time.Duration
 
 	// maximum delay the leader waits for voting on a batch
 	BatchWaitTime time.Duration
 
+	// delay before the next worker also batches a partitioned tx left in the queue
+	PartitionTimeout time.Duration
+
 	// duration to wait to propose next block if leader cannot create qc
 	ProposeTimeout time.Duration
 
@@ -41,19 +60,78 @@ type Config struct {
 	// leader must create next qc within this duration
 	LeaderTimeout time.Duration
 
+	// maximum delay a new leader waits for new view messages of a quorum before proposing
+	NewViewTimeout time.Duration
+
+	// leader election policy (round-robin, reputation or random), must be the same on all nodes
+	LeaderElection string
+
+	// number of latest committed blocks used by the reputation leader election
+	ReputationWindow int
+
+	// blocks between the approval of a validator set and its activation, must be the same on all nodes
+	// and greater than the commit depth of the variant (2 for two-phase, 3 for three-phase)
+	// governance transactions are only applied when ExecuteTxFlag is set
+	EpochDelay int
+
+	// committed blocks between state checkpoints signed by validators, set to 0 to disable
+	CheckpointInterval int
+
+	// maximum number of peers to download blocks from in parallel when the node is behind
+	SyncParallel int
+
 	// path to save the benchmark log of the consensus algorithm (it will not be saved if blank)
 	BenchmarkPath string
+
+	// whether to execute transactions of committed blocks (set to false when benchmark test)
+	ExecuteTxFlag bool
+
+	// whether to keep transactions in the pool after batching (set to true when benchmark test)
+	PreserveTxFlag bool
+
+	// whether to generate mock transactions on startup
+	GenerateTxFlag bool
+
+	// whether voters vote on batches before the leader packs them into a block
+	VoteBatchFlag bool
+
+	// whether votes are sent to the leader of the next block instead of the proposer (chained hotstuff)
+	VoteForwardFlag bool
+
+	// whether each tx is batched only by its responsible worker (set to true when txs are broadcast)
+	PartitionTxFlag bool
+
+	// whether quorum certs are encoded with signer bitmaps instead of public keys
+	CompactQCFlag bool
 }
 
 var DefaultConfig = Config{
-	BatchTxLimit:    200,
-	BlockBatchLimit: -1, // set to -1 to adapt to the number of worker nodes
-	VoteBatchLimit:  -1, // set to -1 to adapt to the number of worker nodes
-	TxWaitTime:      1 * time.Second,
-	BatchWaitTime:   3 * time.Second,
-	ProposeTimeout:  2 * time.Second,
-	BlockDelay:      1 * time.Second,
-	ViewWidth:       60 * time.Second,
-	LeaderTimeout:   20 * time.Second,
-	BenchmarkPath:   "",
+	Variant:            hotstuff.TwoPhase,
+	BatchTxLimit:       200,
+	BlockBatchLimit:    -1, // set to -1 to adapt to the number of worker nodes
+	VoteBatchLimit:     -1, // set to -1 to adapt to the number of worker nodes
+	TxSizeLimit:        16 << 20,
+	BatchSizeLimit:     64 << 20,
+	BlockSizeLimit:     16 << 20,
+	TxWaitTime:         1 * time.Second,
+	BatchWaitTime:      3 * time.Second,
+	PartitionTimeout:   5 * time.Second,
+	ProposeTimeout:     2 * time.Second,
+	BlockDelay:         1 * time.Second,
+	ViewWidth:          60 * time.Second,
+	LeaderTimeout:      20 * time.Second,
+	NewViewTimeout:     2 * time.Second,
+	LeaderElection:     ElectionRoundRobin,
+	ReputationWindow:   10,
+	EpochDelay:         20,
+	CheckpointInterval: 100,
+	SyncParallel:       4,
+	BenchmarkPath:      "",
+	ExecuteTxFlag:      false,
+	PreserveTxFlag:     true,
+	GenerateTxFlag:     true,
+	VoteBatchFlag:      false,
+	VoteForwardFlag:    false,
+	PartitionTxFlag:    false,
+	CompactQCFlag:      false,
 }
@@ -5,6 +5,7 @@
 package consensus
 
 import (
+	"bytes"
 	"math"
 	"math/rand"
 	"os"
@@ -16,6 +17,7 @@ import (
 	"github.com/wooyang2018/ppov-blockchain/execution"
 	"github.com/wooyang2018/ppov-blockchain/hotstuff"
 	"github.com/wooyang2018/ppov-blockchain/logger"
+	"github.com/wooyang2018/ppov-blockchain/storage"
 )
 
 type Consensus struct {
@@ -31,6 +33,9 @@ type Consensus struct {
 	rotator     *rotator
 	voterState  *voterState
 	leaderState *leaderState
+	governance  *governance
+	wal         *consensusWAL
+	checkpoints *checkpointPool
 }
 
 func New(resources *Resources, config Config) *Consensus {
@@ -58,10 +63,12 @@ func (cons *Consensus) GetBlock(hash []byte) *core.Block {
 }
 
 func (cons *Consensus) start() {
+	cons.setupGovernance()
+	cons.setupSignScheme()
 	cons.resources.Host.SetLeader(0)
 	cons.startTime = time.Now().UnixNano()
 	b0, q0 := cons.getInitialBlockAndQC()
-	if hotstuff.TwoPhaseFlag {
+	if cons.config.Variant == hotstuff.TwoPhase {
 		b1, _ := cons.resources.Storage.GetBlock(q0.BlockHash())
 		cons.setupState(b1)
 	} else {
@@ -75,12 +82,16 @@ func (cons *Consensus) start() {
 		}
 	}
 	cons.setupPPovState()
+	cons.wal = newConsensusWAL(cons.resources.Storage)
+	cons.checkpoints = newCheckpointPool(cons.resources, cons.config.CheckpointInterval)
 	cons.setupHsDriver()
 	cons.setupHotstuff(b0, q0)
+	cons.restoreSafetyState()
 	cons.mockTxsForDocker(cons.config.BatchTxLimit)
 	cons.setupValidator()
 	cons.setupPacemaker()
 	cons.setupRotator()
+	cons.validator.replayWAL()
 
 	status := cons.GetStatus()
 	logger.I().Infow("starting consensus", "leader", status.LeaderIndex, "bLeaf", status.BLeaf, "qc", status.QCHigh)
@@ -111,6 +122,7 @@ func (cons *Consensus) setupState(b0 *core.Block) {
 func (cons *Consensus) getInitialBlockAndQC() (*core.Block, *core.QuorumCert) {
 	b0, err := cons.resources.Storage.GetLastBlock()
 	if err == nil {
+		cons.verifyGenesis()
 		q0, err := cons.resources.Storage.GetLastQC()
 		if err != nil {
 			logger.I().Fatalf("cannot get last qc %d", b0.Height())
@@ -121,10 +133,47 @@ func (cons *Consensus) getInitialBlockAndQC() (*core.Block, *core.QuorumCert) {
 	genesis := &genesis{
 		resources: cons.resources,
 		chainID:   cons.config.ChainID,
+		variant:   cons.config.Variant,
+	}
+	if cons.config.GenesisBlock != nil {
+		return genesis.load(cons.config.GenesisBlock, cons.config.GenesisQC)
 	}
 	return genesis.run()
 }
 
+// verifyGenesis refuses to start on a chain created with different chain parameters
+func (cons *Consensus) verifyGenesis() {
+	b0, err := cons.resources.Storage.GetBlockByHeight(0)
+	if err != nil {
+		logger.I().Fatalf("cannot get genesis block %+v", err)
+	}
+	if !bytes.Equal(hashChainID(cons.config.ChainID, cons.config.Variant), b0.ParentHash()) {
+		logger.I().Fatalw("genesis block doesn't match chain id or hotstuff variant",
+			"chainID", cons.config.ChainID, "variant", cons.config.Variant)
+	}
+	if cons.config.GenesisBlock != nil && !bytes.Equal(cons.config.GenesisBlock.Hash(), b0.Hash()) {
+		logger.I().Fatalw("genesis block doesn't match genesis file")
+	}
+}
+
+func (cons *Consensus) setupGovernance() {
+	cons.governance = newGovernance(cons.resources, cons.config)
+	if cons.governance != nil {
+		// a block commits when the variant's chain of descendants is certified, so blocks up to
+		// int(Variant) above the scheduling block are validated before the epoch is known
+		if cons.config.EpochDelay <= int(cons.config.Variant) {
+			logger.I().Fatalw("epoch delay must exceed the commit depth",
+				"delay", cons.config.EpochDelay, "variant", cons.config.Variant)
+		}
+		cons.governance.restore()
+	}
+}
+
+// setupSignScheme sets the committed height which selects the sign version of the messages without a height
+func (cons *Consensus) setupSignScheme() {
+	cons.resources.VldStore.SignScheme().SetHeight(cons.resources.Storage.GetBlockHeight())
+}
+
 func (cons *Consensus) setupHsDriver() {
 	cons.hsDriver = &hsDriver{
 		resources:    cons.resources,
@@ -132,6 +181,9 @@ func (cons *Consensus) setupHsDriver() {
 		state:        cons.state,
 		leaderState:  cons.leaderState,
 		voterState:   cons.voterState,
+		governance:   cons.governance,
+		wal:          cons.wal,
+		checkpoints:  cons.checkpoints,
 		checkTxDelay: 50 * time.Millisecond,
 	}
 }
@@ -142,15 +194,48 @@ func (cons *Consensus) setupHotstuff(b0 *core.Block, q0 *core.QuorumCert) {
 		cons.logfile,
 		newHsBlock(b0, cons.state),
 		newHsQC(q0, cons.state),
+		hotstuff.WithVariant(cons.config.Variant),
 	)
+	cons.hsDriver.hotstuff = cons.hotstuff
+}
+
+// restoreSafetyState loads voting state saved before restart to prevent double voting
+func (cons *Consensus) restoreSafetyState() {
+	if ss, err := cons.resources.Storage.GetSafetyState(); err == nil {
+		cons.state.setBlock(ss.BVote)
+		cons.state.setBlock(ss.BLock)
+		cons.state.setBlock(ss.QCHighBlock)
+		cons.state.setQC(ss.QCHigh)
+		cons.hotstuff.Restore(newHsBlock(ss.BVote, cons.state),
+			newHsBlock(ss.BLock, cons.state), newHsQC(ss.QCHigh, cons.state))
+		logger.I().Infow("restored safety state", "bVote", ss.BVote.Height(),
+			"bLock", ss.BLock.Height(), "qc", ss.QCHighBlock.Height())
+	}
+	if view, err := cons.resources.Storage.GetSafetyView(); err == nil {
+		cons.state.setView(view)
+		logger.I().Infow("restored view", "view", view)
+	}
+	qcHigh := cons.hotstuff.GetQCHigh()
+	cons.hsDriver.safety = &storage.SafetyState{
+		BVote:       cons.hotstuff.GetBVote().(*hsBlock).block,
+		BLock:       cons.hotstuff.GetBLock().(*hsBlock).block,
+		QCHigh:      qcHigh.(*hsQC).qc,
+		QCHighBlock: qcHigh.Block().(*hsBlock).block,
+	}
 }
 
 func (cons *Consensus) setupPPovState() {
+	if err := checkSizeLimits(cons.config); err != nil {
+		logger.I().Fatalw("invalid size limits", "error", err)
+	}
+	sizeLimit := headersSizeLimit(cons.config.BlockSizeLimit, cons.resources.VldStore)
 	cons.voterState = newVoterState()
 	if cons.config.VoteBatchLimit == -1 {
 		cons.config.VoteBatchLimit = cons.resources.VldStore.WorkerCount()
 	}
 	cons.voterState.setVoteBatchLimit(cons.config.VoteBatchLimit)
+	cons.voterState.setSizeLimit(sizeLimit)
+	cons.voterState.setPreserveTx(cons.config.PreserveTxFlag)
 	if cons.config.BenchmarkPath != "" {
 		file, err := os.Create(path.Join(cons.config.BenchmarkPath, "batch.csv"))
 		if err != nil {
@@ -160,16 +245,18 @@ func (cons *Consensus) setupPPovState() {
 	}
 
 	cons.leaderState = newLeaderState()
-	cons.leaderState.setBatchSignLimit(cons.resources.VldStore.MajorityVoterCount())
+	cons.leaderState.setValidatorStore(cons.resources.VldStore)
+	cons.leaderState.setBatchSignLimit(cons.resources.VldStore.QuorumVoterPower())
 	cons.leaderState.setBatchWaitTime(cons.config.BatchWaitTime)
 	if cons.config.BlockBatchLimit == -1 {
 		cons.config.BlockBatchLimit = cons.resources.VldStore.WorkerCount()
 	}
 	cons.leaderState.setBlockBatchLimit(cons.config.BlockBatchLimit)
+	cons.leaderState.setSizeLimit(sizeLimit)
 }
 
 func (cons *Consensus) mockTxsForDocker(num int) {
-	if !GenerateTxFlag {
+	if !cons.config.GenerateTxFlag {
 		return
 	}
 	jobCh := make(chan struct{}, num)
@@ -209,11 +296,20 @@ func makeEmptyTx(signer core.Signer) *core.Transaction {
 func (cons *Consensus) setupValidator() {
 	cons.validator = &validator{
 		resources:   cons.resources,
+		config:      cons.config,
 		state:       cons.state,
 		hotstuff:    cons.hotstuff,
 		leaderState: cons.leaderState,
 		voterState:  cons.voterState,
+		evidence:    newEvidencePool(),
+		earlyVotes:  newVoteBuffer(),
+		wal:         cons.wal,
+		checkpoints: cons.checkpoints,
 	}
+	cons.validator.syncer = newBlockSyncer(cons.resources, cons.config, cons.state,
+		func(peer *core.PublicKey, blk, parent *core.Block) error {
+			return cons.validator.verifyWithParentAndUpdateHotstuff(peer, blk, parent, false)
+		})
 }
 
 func (cons *Consensus) setupPacemaker() {
@@ -228,11 +324,23 @@ func (cons *Consensus) setupPacemaker() {
 }
 
 func (cons *Consensus) setupRotator() {
+	election, err := newLeaderElection(cons.config, cons.resources)
+	if err != nil {
+		logger.I().Fatalw("setup leader election failed", "error", err)
+	}
 	cons.rotator = &rotator{
 		resources: cons.resources,
 		config:    cons.config,
 		state:     cons.state,
 		hotstuff:  cons.hotstuff,
+		election:  election,
+		wal:       cons.wal,
+	}
+	if view := cons.state.getView(); view > 0 {
+		// the restored view keeps its leader until a tc or an approved proposal
+		leaderIdx := election.Leader(view, electionHeight(cons.hotstuff.GetQCHigh()))
+		cons.state.setLeaderIndex(leaderIdx)
+		cons.resources.Host.SetLeader(leaderIdx)
 	}
 }
 
@@ -245,9 +353,19 @@ func (cons *Consensus) getStatus() (status Status) {
 	status.BlockPoolSize = cons.state.getBlockPoolSize()
 	status.QCPoolSize = cons.state.getQCPoolSize()
 	status.LeaderIndex = cons.state.getLeaderIndex()
+	status.View = cons.state.getView()
+	if cons.governance != nil {
+		status.Epoch = cons.governance.epochs.CurrentEpoch()
+	}
 	status.ViewStart = cons.rotator.getViewStart()
 	status.PendingViewChange = cons.rotator.getPendingViewChange()
 
+	progress := cons.validator.syncer.getProgress()
+	status.Syncing = progress.Syncing
+	status.SyncStart = progress.Start
+	status.SyncTarget = progress.Target
+	status.SyncHeight = progress.Height
+
 	status.BVote = cons.hotstuff.GetBVote().Height()
 	status.BLeaf = cons.hotstuff.GetBLeaf().Height()
 	status.BLock = cons.hotstuff.GetBLock().Height()
@@ -0,0 +1,156 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/logger"
+)
+
+// heights kept in the pool to detect equivocation
+const evidenceWindow = 100
+
+type votedBlock struct {
+	vote  *core.Vote
+	block *core.Block
+}
+
+// evidencePool remembers the latest signed messages of each validator
+// and reports an evidence when a conflicting message is received
+type evidencePool struct {
+	proposals  map[string]*core.Block
+	votes      map[string]*votedBlock
+	batchVotes map[string]*core.BatchVote
+	batchTimes map[string]int64 // batch vote key to header timestamp
+	maxHeight  uint64
+	maxTime    int64
+	mtx        sync.Mutex
+}
+
+func newEvidencePool() *evidencePool {
+	return &evidencePool{
+		proposals:  make(map[string]*core.Block),
+		votes:      make(map[string]*votedBlock),
+		batchVotes: make(map[string]*core.BatchVote),
+		batchTimes: make(map[string]int64),
+	}
+}
+
+func heightKey(pubKey *core.PublicKey, height uint64) string {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, height)
+	return pubKey.String() + string(b)
+}
+
+func batchHeaderKey(voter *core.PublicKey, header *core.BatchHeader) string {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(header.Timestamp()))
+	return voter.String() + header.Proposer().String() + string(b)
+}
+
+func (pool *evidencePool) checkProposal(blk *core.Block) *core.Evidence {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+
+	key := heightKey(blk.Proposer(), blk.Height())
+	prev, ok := pool.proposals[key]
+	if !ok {
+		pool.proposals[key] = blk
+		pool.updateHeight(blk.Height())
+		return nil
+	}
+	if bytes.Equal(prev.Hash(), blk.Hash()) {
+		return nil
+	}
+	return buildEvidence(core.NewEvidence().BuildDoubleProposal(prev, blk))
+}
+
+func (pool *evidencePool) checkVote(vote *core.Vote, blk *core.Block) *core.Evidence {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+
+	key := heightKey(vote.Voter(), blk.Height())
+	prev, ok := pool.votes[key]
+	if !ok {
+		pool.votes[key] = &votedBlock{vote: vote, block: blk}
+		pool.updateHeight(blk.Height())
+		return nil
+	}
+	if bytes.Equal(prev.vote.BlockHash(), vote.BlockHash()) {
+		return nil
+	}
+	return buildEvidence(core.NewEvidence().BuildDoubleVote(prev.vote, vote, prev.block, blk))
+}
+
+func (pool *evidencePool) checkBatchVote(vote *core.BatchVote) *core.Evidence {
+	if vote.Voter() == nil {
+		return nil
+	}
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+
+	var ev *core.Evidence
+	for _, header := range vote.BatchHeaders() {
+		key := batchHeaderKey(vote.Voter(), header)
+		prev, ok := pool.batchVotes[key]
+		if !ok {
+			pool.batchVotes[key] = vote
+			pool.batchTimes[key] = header.Timestamp()
+			pool.updateTime(header.Timestamp())
+			continue
+		}
+		if ev == nil {
+			if h1, _ := core.ConflictingBatchHeaders(prev, vote); h1 != nil {
+				ev = buildEvidence(core.NewEvidence().BuildDoubleBatchVote(prev, vote))
+			}
+		}
+	}
+	return ev
+}
+
+func buildEvidence(ev *core.Evidence, err error) *core.Evidence {
+	if err != nil {
+		logger.I().Errorw("build evidence failed", "error", err)
+		return nil
+	}
+	return ev
+}
+
+func (pool *evidencePool) updateHeight(height uint64) {
+	if height <= pool.maxHeight {
+		return
+	}
+	pool.maxHeight = height
+	if height <= evidenceWindow {
+		return
+	}
+	for key, blk := range pool.proposals {
+		if blk.Height() < height-evidenceWindow {
+			delete(pool.proposals, key)
+		}
+	}
+	for key, vb := range pool.votes {
+		if vb.block.Height() < height-evidenceWindow {
+			delete(pool.votes, key)
+		}
+	}
+}
+
+// batch timestamps are in nanoseconds, keep one minute of batch votes
+func (pool *evidencePool) updateTime(ts int64) {
+	if ts <= pool.maxTime {
+		return
+	}
+	pool.maxTime = ts
+	for key, t := range pool.batchTimes {
+		if t < ts-60*1e9 {
+			delete(pool.batchTimes, key)
+			delete(pool.batchVotes, key)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+func newEvidenceTestQC() *core.QuorumCert {
+	parent := core.NewBlock().SetHeight(9).Sign(core.GenerateKey(nil))
+	return core.NewQuorumCert().Build([]*core.Vote{parent.ProposerVote()})
+}
+
+func TestEvidencePool_checkProposal(t *testing.T) {
+	asrt := assert.New(t)
+	pool := newEvidencePool()
+	proposer := core.GenerateKey(nil)
+	qc := newEvidenceTestQC()
+
+	b1 := core.NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(1).Sign(proposer)
+	b2 := core.NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(2).Sign(proposer)
+	b3 := core.NewBlock().SetHeight(11).SetQuorumCert(qc).Sign(proposer)
+	other := core.NewBlock().SetHeight(10).SetQuorumCert(qc).Sign(core.GenerateKey(nil))
+
+	asrt.Nil(pool.checkProposal(b1))
+	asrt.Nil(pool.checkProposal(b1), "same proposal")
+	asrt.Nil(pool.checkProposal(b3), "next height")
+	asrt.Nil(pool.checkProposal(other), "other proposer")
+
+	ev := pool.checkProposal(b2)
+	if asrt.NotNil(ev) {
+		asrt.Equal(pb.Evidence_DoubleProposal, ev.Type())
+		asrt.Equal(proposer.PublicKey(), ev.Offender())
+	}
+}
+
+func TestEvidencePool_checkVote(t *testing.T) {
+	asrt := assert.New(t)
+	pool := newEvidencePool()
+	proposer := core.GenerateKey(nil)
+	voter := core.GenerateKey(nil)
+	qc := newEvidenceTestQC()
+
+	b1 := core.NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(1).Sign(proposer)
+	b2 := core.NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(2).Sign(proposer)
+
+	asrt.Nil(pool.checkVote(b1.Vote(voter), b1))
+	asrt.Nil(pool.checkVote(b1.Vote(voter), b1), "same vote")
+	asrt.Nil(pool.checkVote(b2.ProposerVote(), b2), "other voter")
+
+	ev := pool.checkVote(b2.Vote(voter), b2)
+	if asrt.NotNil(ev) {
+		asrt.Equal(pb.Evidence_DoubleVote, ev.Type())
+		asrt.Equal(voter.PublicKey(), ev.Offender())
+	}
+}
+
+func TestEvidencePool_checkBatchVote(t *testing.T) {
+	asrt := assert.New(t)
+	pool := newEvidencePool()
+	worker := core.GenerateKey(nil)
+	voter := core.GenerateKey(nil)
+
+	h1 := core.NewBatch().Header().SetTimestamp(1).Sign(worker)
+	h2 := core.NewBatch().Header().SetTimestamp(1).SetTransactions([][]byte{[]byte("tx")}).Sign(worker)
+	h3 := core.NewBatch().Header().SetTimestamp(2).Sign(worker)
+
+	asrt.Nil(pool.checkBatchVote(core.NewBatchVote().Build([]*core.BatchHeader{h1}, voter)))
+	asrt.Nil(pool.checkBatchVote(core.NewBatchVote().Build([]*core.BatchHeader{h1, h3}, voter)),
+		"same header and new header")
+
+	ev := pool.checkBatchVote(core.NewBatchVote().Build([]*core.BatchHeader{h2}, voter))
+	if asrt.NotNil(ev) {
+		asrt.Equal(pb.Evidence_DoubleBatchVote, ev.Type())
+		asrt.Equal(voter.PublicKey(), ev.Offender())
+	}
+}
+
+func TestEvidencePool_prune(t *testing.T) {
+	asrt := assert.New(t)
+	pool := newEvidencePool()
+	proposer := core.GenerateKey(nil)
+
+	pool.checkProposal(core.NewBlock().SetHeight(1).Sign(proposer))
+	pool.checkProposal(core.NewBlock().SetHeight(evidenceWindow + 2).Sign(proposer))
+	asrt.Len(pool.proposals, 1)
+}
@@ -15,6 +15,7 @@ import (
 	"golang.org/x/crypto/sha3"
 
 	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/hotstuff"
 	"github.com/wooyang2018/ppov-blockchain/logger"
 	"github.com/wooyang2018/ppov-blockchain/storage"
 )
@@ -22,6 +23,7 @@ import (
 type genesis struct {
 	resources *Resources
 	chainID   int64
+	variant   hotstuff.Variant
 
 	done chan struct{}
 
@@ -53,6 +55,19 @@ func (gns *genesis) run() (*core.Block, *core.QuorumCert) {
 	return gns.getB0(), gns.getQ0()
 }
 
+// load commits the genesis block and qc signed offline, no network round is needed
+func (gns *genesis) load(b0 *core.Block, q0 *core.QuorumCert) (*core.Block, *core.QuorumCert) {
+	err := VerifyGenesis(b0, q0, gns.chainID, gns.variant, gns.resources.VldStore)
+	if err != nil {
+		logger.I().Fatalw("invalid genesis block in genesis file", "error", err)
+	}
+	gns.setB0(b0)
+	gns.setQ0(q0)
+	logger.I().Info("loaded genesis block and qc")
+	gns.commit()
+	return b0, q0
+}
+
 func (gns *genesis) commit() {
 	data := &storage.CommitData{
 		Block: gns.getB0(),
@@ -81,7 +96,7 @@ func (gns *genesis) propose() {
 func (gns *genesis) createGenesisBlock() *core.Block {
 	return core.NewBlock().
 		SetHeight(0).
-		SetParentHash(hashChainID(gns.chainID)).
+		SetParentHash(hashChainID(gns.chainID, gns.variant)).
 		SetTimestamp(time.Now().UnixNano()).
 		Sign(gns.resources.Signer)
 }
@@ -103,15 +118,109 @@ func (gns *genesis) broadcastProposalLoop() {
 }
 
 func (gns *genesis) isLeader(pubKey *core.PublicKey) bool {
-	if !gns.resources.VldStore.IsWorker(pubKey) {
+	return isGenesisProposer(gns.resources.VldStore, pubKey)
+}
+
+func isGenesisProposer(vs core.ValidatorStore, pubKey *core.PublicKey) bool {
+	if !vs.IsWorker(pubKey) {
 		return false
 	}
-	return gns.resources.VldStore.GetWorkerIndex(pubKey) == 0
+	return vs.GetWorkerIndex(pubKey) == 0
+}
+
+// NewGenesisBlock creates the unsigned genesis block of the first worker for validators to sign offline
+func NewGenesisBlock(chainID int64, variant hotstuff.Variant, vs core.ValidatorStore, timestamp int64) *core.Block {
+	return core.NewBlock().
+		SetHeight(0).
+		SetParentHash(hashChainID(chainID, variant)).
+		SetTimestamp(timestamp).
+		SetProposer(vs.GetWorker(0))
+}
+
+// CheckGenesisBlock checks an unsigned genesis block before it is signed
+func CheckGenesisBlock(b0 *core.Block, chainID int64, variant hotstuff.Variant, vs core.ValidatorStore) error {
+	if !b0.IsGenesis() {
+		return fmt.Errorf("not genesis block")
+	}
+	if !bytes.Equal(hashChainID(chainID, variant), b0.ParentHash()) {
+		return fmt.Errorf("different chain id or hotstuff variant genesis")
+	}
+	if !isGenesisProposer(vs, b0.Proposer()) {
+		return fmt.Errorf("proposer is not leader")
+	}
+	if len(b0.Transactions()) != 0 || len(b0.BatchHeaders()) != 0 {
+		return fmt.Errorf("genesis block with txs")
+	}
+	if !bytes.Equal(b0.Sum(), b0.Hash()) {
+		return core.ErrInvalidBlockHash
+	}
+	return nil
 }
 
-func hashChainID(chainID int64) []byte {
+// CertifyGenesisBlock signs the genesis block with the vote of its proposer and builds q0 from the votes
+func CertifyGenesisBlock(b0 *core.Block, votes []*core.Vote, vs core.ValidatorStore) (*core.QuorumCert, error) {
+	accepted := make(map[string]*core.Vote, len(votes))
+	var power uint64
+	var signed bool
+	for _, vote := range votes {
+		if !bytes.Equal(b0.Hash(), vote.BlockHash()) {
+			return nil, fmt.Errorf("vote of %s is not for genesis block", vote.Voter())
+		}
+		if err := vote.Validate(vs); err != nil {
+			return nil, fmt.Errorf("invalid vote of %s, %w", vote.Voter(), err)
+		}
+		if _, found := accepted[vote.Voter().String()]; found {
+			continue
+		}
+		if vote.Voter().Equal(b0.Proposer()) {
+			if err := b0.SetProposerVote(vote); err != nil {
+				return nil, err
+			}
+			signed = true
+		}
+		accepted[vote.Voter().String()] = vote
+		power += vs.GetPower(vote.Voter())
+	}
+	if !signed {
+		return nil, fmt.Errorf("no vote of proposer")
+	}
+	if power < vs.QuorumPower() {
+		return nil, core.ErrNotEnoughSig
+	}
+	vlist := make([]*core.Vote, 0, len(accepted))
+	for _, vote := range accepted {
+		vlist = append(vlist, vote)
+	}
+	return core.NewQuorumCert().Build(vlist), nil
+}
+
+// VerifyGenesis checks the genesis block and qc signed offline
+func VerifyGenesis(b0 *core.Block, q0 *core.QuorumCert, chainID int64,
+	variant hotstuff.Variant, vs core.ValidatorStore,
+) error {
+	if err := CheckGenesisBlock(b0, chainID, variant, vs); err != nil {
+		return err
+	}
+	if err := b0.Validate(vs); err != nil {
+		return err
+	}
+	if q0 == nil {
+		return core.ErrNilQC
+	}
+	if !bytes.Equal(b0.Hash(), q0.BlockHash()) {
+		return fmt.Errorf("invalid qc reference")
+	}
+	return q0.Validate(vs)
+}
+
+// hashChainID binds chain id and hotstuff variant to the genesis block,
+// two-phase chains keep the original hash of chain id only.
+func hashChainID(chainID int64, variant hotstuff.Variant) []byte {
 	h := sha3.New256()
 	binary.Write(h, binary.BigEndian, chainID)
+	if variant != hotstuff.TwoPhase {
+		h.Write([]byte{byte(variant)})
+	}
 	return h.Sum(nil)
 }
 
@@ -159,7 +268,7 @@ func (gns *genesis) newViewLoop() {
 			return
 
 		case e := <-sub.Events():
-			if err := gns.onReceiveNewView(e.(*core.QuorumCert)); err != nil {
+			if err := gns.onReceiveNewView(e.(*core.NewView)); err != nil {
 				logger.I().Warnf("receive new view failed, %+v", err.Error())
 			}
 		}
@@ -174,8 +283,8 @@ func (gns *genesis) onReceiveProposal(proposal *core.Block) error {
 		logger.I().Info("left behind, fetching genesis block...")
 		return gns.fetchGenesisBlockAndQC(proposal.Proposer())
 	}
-	if !bytes.Equal(hashChainID(gns.chainID), proposal.ParentHash()) {
-		return fmt.Errorf("different chain id genesis")
+	if !bytes.Equal(hashChainID(gns.chainID, gns.variant), proposal.ParentHash()) {
+		return fmt.Errorf("different chain id or hotstuff variant genesis")
 	}
 	if !gns.isLeader(proposal.Proposer()) {
 		return fmt.Errorf("proposer is not leader")
@@ -196,6 +305,9 @@ func (gns *genesis) fetchGenesisBlockAndQC(peer *core.PublicKey) error {
 	if !b0.IsGenesis() {
 		return fmt.Errorf("not genesis block")
 	}
+	if !bytes.Equal(hashChainID(gns.chainID, gns.variant), b0.ParentHash()) {
+		return fmt.Errorf("different chain id or hotstuff variant genesis")
+	}
 	b1, err := gns.requestBlockByHeight(peer, 1)
 	if err != nil {
 		return err
@@ -236,13 +348,15 @@ func (gns *genesis) acceptVote(vote *core.Vote) {
 	defer gns.mtxVote.Unlock()
 
 	gns.votes[vote.Voter().String()] = vote
-	if len(gns.votes) < gns.resources.VldStore.MajorityValidatorCount() {
-		return
-	}
+	var power uint64
 	vlist := make([]*core.Vote, 0, len(gns.votes))
 	for _, vote := range gns.votes {
+		power += gns.resources.VldStore.GetPower(vote.Voter())
 		vlist = append(vlist, vote)
 	}
+	if power < gns.resources.VldStore.QuorumPower() {
+		return
+	}
 	gns.setQ0(core.NewQuorumCert().Build(vlist))
 	logger.I().Infow("created qc, broadcasting...")
 	gns.broadcastQC()
@@ -255,17 +369,19 @@ func (gns *genesis) broadcastQC() {
 			return
 		default:
 		}
-		if err := gns.resources.MsgSvc.BroadcastNewView(gns.getQ0()); err != nil {
+		nv := core.NewNewView().Sign(0, gns.getQ0(), gns.resources.Signer)
+		if err := gns.resources.MsgSvc.BroadcastNewView(nv); err != nil {
 			logger.I().Errorw("broadcast proposal failed", "error", err)
 		}
 		time.Sleep(time.Second)
 	}
 }
 
-func (gns *genesis) onReceiveNewView(qc *core.QuorumCert) error {
-	if err := qc.Validate(gns.resources.VldStore); err != nil {
+func (gns *genesis) onReceiveNewView(nv *core.NewView) error {
+	if err := nv.Validate(gns.resources.VldStore); err != nil {
 		return err
 	}
+	qc := nv.QCHigh()
 	b0 := gns.getB0()
 	if b0 == nil {
 		return fmt.Errorf("no received genesis block yet")
@@ -289,7 +405,8 @@ func (gns *genesis) acceptQC(qc *core.QuorumCert) {
 	b0 := gns.getB0()
 	gns.setQ0(qc)
 	if !gns.isLeader(gns.resources.Signer.PublicKey()) {
-		gns.resources.MsgSvc.SendNewView(b0.Proposer(), qc)
+		gns.resources.MsgSvc.SendNewView(b0.Proposer(),
+			core.NewNewView().Sign(0, qc, gns.resources.Signer))
 	}
 	close(gns.done) // when qc is accepted, genesis creation is done
 }
@@ -0,0 +1,115 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/hotstuff"
+	"github.com/wooyang2018/ppov-blockchain/storage"
+)
+
+func TestGenesis_onReceiveProposal(t *testing.T) {
+	priv0 := core.GenerateKey(nil)
+	priv1 := core.GenerateKey(nil)
+	keys := []string{priv0.PublicKey().String(), priv1.PublicKey().String()}
+
+	newGenesis := func(variant hotstuff.Variant) *genesis {
+		resources := &Resources{
+			Signer:   priv1,
+			VldStore: core.NewValidatorStore(keys, keys),
+		}
+		return &genesis{
+			resources: resources,
+			chainID:   1,
+			variant:   variant,
+		}
+	}
+	b0 := core.NewBlock().
+		SetHeight(0).
+		SetParentHash(hashChainID(1, hotstuff.ThreePhase)).
+		Sign(priv0)
+
+	msgSvc := new(MockMsgService)
+	msgSvc.On("SendVote", priv0.PublicKey(), b0.Vote(priv1)).Return(nil)
+
+	gns := newGenesis(hotstuff.ThreePhase)
+	gns.resources.MsgSvc = msgSvc
+
+	assert := assert.New(t)
+	assert.NoError(gns.onReceiveProposal(b0))
+	assert.Equal(b0, gns.getB0())
+	msgSvc.AssertExpectations(t)
+
+	gns = newGenesis(hotstuff.TwoPhase)
+	assert.Error(gns.onReceiveProposal(b0), "should reject genesis with different variant")
+	assert.Nil(gns.getB0())
+}
+
+func TestHashChainID(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(hashChainID(1, hotstuff.TwoPhase), hashChainID(1, hotstuff.TwoPhase))
+	assert.NotEqual(hashChainID(1, hotstuff.TwoPhase), hashChainID(2, hotstuff.TwoPhase))
+	assert.NotEqual(hashChainID(1, hotstuff.TwoPhase), hashChainID(1, hotstuff.ThreePhase))
+}
+
+func TestGenesis_offlineCeremony(t *testing.T) {
+	asrt := assert.New(t)
+
+	privs := []*core.PrivateKey{core.GenerateKey(nil), core.GenerateKey(nil), core.GenerateKey(nil)}
+	keys := make([]string, len(privs))
+	for i, priv := range privs {
+		keys[i] = priv.PublicKey().String()
+	}
+	vs := core.NewValidatorStore(keys, keys)
+	newB0 := func() *core.Block {
+		return NewGenesisBlock(1, hotstuff.TwoPhase, vs, 100)
+	}
+	asrt.NoError(CheckGenesisBlock(newB0(), 1, hotstuff.TwoPhase, vs))
+	asrt.Error(CheckGenesisBlock(newB0(), 2, hotstuff.TwoPhase, vs), "different chain id")
+	asrt.Error(CheckGenesisBlock(newB0().SetTimestamp(200), 1, hotstuff.TwoPhase, vs), "hash changed")
+
+	votes := func(b0 *core.Block, signers ...*core.PrivateKey) []*core.Vote {
+		ret := make([]*core.Vote, len(signers))
+		for i, priv := range signers {
+			ret[i] = b0.Vote(priv)
+		}
+		return ret
+	}
+	b0 := newB0()
+	_, err := CertifyGenesisBlock(b0, votes(b0, privs[1], privs[2]), vs)
+	asrt.Error(err, "no vote of proposer")
+	_, err = CertifyGenesisBlock(b0, votes(b0, privs[0], privs[1], privs[1]), vs)
+	asrt.ErrorIs(err, core.ErrNotEnoughSig, "duplicate votes")
+	other := NewGenesisBlock(1, hotstuff.TwoPhase, vs, 200)
+	_, err = CertifyGenesisBlock(b0, append(votes(b0, privs[0], privs[1]), other.Vote(privs[2])), vs)
+	asrt.Error(err, "vote for another block")
+
+	q0, err := CertifyGenesisBlock(b0, votes(b0, privs...), vs)
+	asrt.NoError(err)
+	asrt.NoError(VerifyGenesis(b0, q0, 1, hotstuff.TwoPhase, vs))
+	asrt.Error(VerifyGenesis(b0, q0, 1, hotstuff.ThreePhase, vs))
+	asrt.Error(VerifyGenesis(b0, nil, 1, hotstuff.TwoPhase, vs))
+
+	// nodes load the signed genesis without network rounds
+	dir, _ := os.MkdirTemp("", "db")
+	rawDB, _ := storage.NewLevelDB(dir)
+	strg := storage.New(rawDB, storage.DefaultConfig)
+	gns := &genesis{
+		resources: &Resources{Signer: privs[1], VldStore: vs, Storage: strg},
+		chainID:   1,
+		variant:   hotstuff.TwoPhase,
+	}
+	gns.load(b0, q0)
+	blk, err := strg.GetBlockByHeight(0)
+	asrt.NoError(err)
+	asrt.Equal(b0.Hash(), blk.Hash())
+	qc, err := strg.GetLastQC()
+	asrt.NoError(err)
+	asrt.Equal(q0.BlockHash(), qc.BlockHash())
+}
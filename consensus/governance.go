@@ -0,0 +1,170 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/logger"
+	"github.com/wooyang2018/ppov-blockchain/storage"
+)
+
+// governance applies committed validator set proposals to the epoch store,
+// a proposal is scheduled once a quorum of current validators approved it
+type governance struct {
+	resources *Resources
+	config    Config
+	epochs    *core.EpochStore
+	gs        *storage.GovernanceState
+}
+
+// newGovernance returns nil if the validator store is not height-aware
+func newGovernance(resources *Resources, config Config) *governance {
+	epochs, ok := resources.VldStore.(*core.EpochStore)
+	if !ok {
+		return nil
+	}
+	return &governance{
+		resources: resources,
+		config:    config,
+		epochs:    epochs,
+		gs: &storage.GovernanceState{
+			Approvals:   make(map[string][]string),
+			ApprovalTxs: make(map[string][][]byte),
+		},
+	}
+}
+
+// restore loads the scheduled epochs on restart
+func (gov *governance) restore() {
+	gs, err := gov.resources.Storage.GetGovernanceState()
+	if err == nil {
+		gov.gs = gs
+		if gov.gs.Approvals == nil {
+			gov.gs.Approvals = make(map[string][]string)
+		}
+		if gov.gs.ApprovalTxs == nil {
+			gov.gs.ApprovalTxs = make(map[string][][]byte)
+		}
+		// skip the epochs already added on a previous start
+		for _, ep := range gov.gs.Epochs[len(gov.epochs.Epochs())-1:] {
+			if err := gov.epochs.AddEpoch(ep); err != nil {
+				logger.I().Fatalw("restore epoch failed", "start", ep.Start, "error", err)
+			}
+		}
+	}
+	gov.epochs.SetHeight(gov.resources.Storage.GetBlockHeight())
+	if gov.epochs.CurrentEpoch() > 0 {
+		gov.onEpochChange()
+	}
+}
+
+// onCommit must be called before the block is written to storage,
+// applying the same block twice after a crash does not change the state
+func (gov *governance) onCommit(blk *core.Block, txs []*core.Transaction) {
+	vs := gov.epochs.AtHeight(blk.Height())
+	updated := false
+	for _, tx := range txs {
+		if !bytes.Equal(tx.CodeAddr(), core.GovernanceAddr) {
+			continue
+		}
+		if err := gov.applyTx(blk, tx, vs); err != nil {
+			logger.I().Warnw("apply governance tx failed", "error", err)
+			continue
+		}
+		updated = true
+	}
+	if updated {
+		if err := gov.resources.Storage.SetGovernanceState(gov.gs); err != nil {
+			logger.I().Fatalw("save governance state failed", "error", err)
+		}
+	}
+	if gov.epochs.SetHeight(blk.Height()) {
+		gov.onEpochChange()
+	}
+}
+
+func (gov *governance) applyTx(blk *core.Block, tx *core.Transaction, vs core.ValidatorStore) error {
+	if !vs.IsWorker(tx.Sender()) && !vs.IsVoter(tx.Sender()) {
+		return errors.New("sender is not a validator")
+	}
+	vset := new(core.ValidatorSet)
+	if err := json.Unmarshal(tx.Input(), vset); err != nil {
+		return err
+	}
+	if err := vset.Validate(); err != nil {
+		return err
+	}
+	if vset.Epoch != uint64(len(gov.epochs.Epochs())) {
+		return core.ErrUnexpectedEpochSeq
+	}
+	key := hex.EncodeToString(vset.Hash())
+	sender := tx.Sender().String()
+	for _, v := range gov.gs.Approvals[key] {
+		if v == sender {
+			return errors.New("duplicate approval")
+		}
+	}
+	txb, err := tx.Marshal()
+	if err != nil {
+		return err
+	}
+	gov.gs.Approvals[key] = append(gov.gs.Approvals[key], sender)
+	gov.gs.ApprovalTxs[key] = append(gov.gs.ApprovalTxs[key], txb)
+	var power uint64
+	for _, v := range gov.gs.Approvals[key] {
+		power += vs.GetPower(core.StringToPubKey(v))
+	}
+	if power < vs.QuorumPower() {
+		return nil
+	}
+
+	ep := &core.Epoch{
+		Start:     blk.Height() + uint64(gov.config.EpochDelay),
+		Workers:   vset.Workers,
+		Voters:    vset.Voters,
+		Powers:    vset.Powers,
+		Scheduled: blk.Height(),
+		Approvals: gov.gs.ApprovalTxs[key],
+	}
+	if err := gov.epochs.AddEpoch(ep); err != nil {
+		return err
+	}
+	gov.gs.Epochs = append(gov.gs.Epochs, ep)
+	// proposals for the same epoch sequence are stale now
+	gov.gs.Approvals = make(map[string][]string)
+	gov.gs.ApprovalTxs = make(map[string][][]byte)
+	logger.I().Infow("scheduled new epoch", "epoch", vset.Epoch, "start", ep.Start,
+		"workers", len(ep.Workers), "voters", len(ep.Voters))
+	return nil
+}
+
+func (gov *governance) onEpochChange() {
+	epochs := gov.epochs.Epochs()
+	ep := epochs[gov.epochs.CurrentEpoch()]
+	logger.I().Infow("epoch changed", "epoch", gov.epochs.CurrentEpoch(), "start", ep.Start)
+	if gov.resources.Host == nil {
+		return
+	}
+	workers := make([]*core.PublicKey, len(ep.Workers))
+	set := make(map[string]*core.PublicKey)
+	for i, v := range ep.Workers {
+		workers[i] = core.StringToPubKey(v)
+		set[v] = workers[i]
+	}
+	for _, v := range ep.Voters {
+		if _, ok := set[v]; !ok {
+			set[v] = core.StringToPubKey(v)
+		}
+	}
+	validators := make([]*core.PublicKey, 0, len(set))
+	for _, v := range set {
+		validators = append(validators, v)
+	}
+	gov.resources.Host.SetValidators(workers, validators)
+}
@@ -0,0 +1,69 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func TestGovernance_onCommit(t *testing.T) {
+	asrt := assert.New(t)
+
+	privs := make([]*core.PrivateKey, 5)
+	keys := make([]string, 5)
+	for i := range privs {
+		privs[i] = core.GenerateKey(nil)
+		keys[i] = privs[i].PublicKey().String()
+	}
+	epochs := core.NewEpochStore(keys[:4], keys[:4], nil)
+	mStrg := new(MockStorage)
+	mStrg.On("SetGovernanceState", mock.Anything).Return(nil)
+	config := DefaultConfig
+	config.EpochDelay = 5
+	gov := newGovernance(&Resources{VldStore: epochs, Storage: mStrg}, config)
+	asrt.NotNil(gov)
+
+	vset := &core.ValidatorSet{Epoch: 1, Workers: keys[1:], Voters: keys[1:]}
+	input, _ := json.Marshal(vset)
+	govTx := func(priv *core.PrivateKey) *core.Transaction {
+		return core.NewTransaction().SetCodeAddr(core.GovernanceAddr).SetInput(input).Sign(priv)
+	}
+
+	// approval from outside of the validator set is ignored
+	blk := core.NewBlock().SetHeight(1).Sign(privs[0])
+	gov.onCommit(blk, []*core.Transaction{govTx(privs[4]), govTx(privs[0]), govTx(privs[0])})
+	asrt.Len(gov.gs.Approvals[hex.EncodeToString(vset.Hash())], 1)
+
+	blk = core.NewBlock().SetHeight(2).Sign(privs[0])
+	gov.onCommit(blk, []*core.Transaction{govTx(privs[1]), govTx(privs[2])})
+	asrt.Len(gov.epochs.Epochs(), 2)
+	asrt.EqualValues(7, gov.epochs.Epochs()[1].Start)
+	asrt.Len(gov.gs.Epochs, 1)
+	asrt.Empty(gov.gs.Approvals)
+	ep := gov.epochs.Epochs()[1]
+	asrt.EqualValues(2, ep.Scheduled)
+	asrt.Len(ep.Approvals, 3)
+	asrt.NoError(ep.VerifyApprovals(1, epochs.AtHeight(ep.Scheduled)))
+
+	// approval for an already scheduled epoch is stale
+	blk = core.NewBlock().SetHeight(3).Sign(privs[0])
+	gov.onCommit(blk, []*core.Transaction{govTx(privs[3])})
+	asrt.Len(gov.epochs.Epochs(), 2)
+
+	for h := uint64(4); h < 6; h++ {
+		gov.onCommit(core.NewBlock().SetHeight(h).Sign(privs[0]), nil)
+	}
+	asrt.EqualValues(0, gov.epochs.CurrentEpoch())
+	gov.onCommit(core.NewBlock().SetHeight(6).Sign(privs[0]), nil)
+	asrt.EqualValues(1, gov.epochs.CurrentEpoch())
+	asrt.True(epochs.IsWorker(privs[4].PublicKey()))
+	asrt.False(epochs.IsWorker(privs[0].PublicKey()))
+}
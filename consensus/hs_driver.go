@@ -18,8 +18,15 @@ type hsDriver struct {
 	config    Config
 
 	state       *state
+	hotstuff    *hotstuff.Hotstuff // receives the own vote forwarded to this node
 	leaderState *leaderState
 	voterState  *voterState
+	governance  *governance // nil if the validator set never changes
+	wal         *consensusWAL
+	checkpoints *checkpointPool // nil if checkpoints are disabled
+
+	// voting state last saved to disk
+	safety *storage.SafetyState
 
 	checkTxDelay time.Duration // 检测TxPool交易数量的延迟
 }
@@ -27,14 +34,30 @@ type hsDriver struct {
 // 验证hsDriver实现了hotstuff的Driver
 var _ hotstuff.Driver = (*hsDriver)(nil)
 
-func (hsd *hsDriver) MajorityValidatorCount() int {
-	return hsd.resources.VldStore.MajorityValidatorCount()
+// QuorumPower returns the quorum of the validators at the height of the voted block
+func (hsd *hsDriver) QuorumPower(b hotstuff.Block) uint64 {
+	return hsd.validatorsAt(b).QuorumPower()
+}
+
+func (hsd *hsDriver) VotePower(v hotstuff.Vote) uint64 {
+	return hsd.validatorsAt(v.Block()).GetPower(v.(*hsVote).vote.Voter())
+}
+
+// validatorsAt returns the validators voting for the block, or the current ones for an unknown block
+func (hsd *hsDriver) validatorsAt(b hotstuff.Block) core.ValidatorStore {
+	if b == nil {
+		return hsd.resources.VldStore
+	}
+	return hsd.resources.VldStore.AtHeight(b.Height())
 }
 
 func (hsd *hsDriver) CreateLeaf(parent hotstuff.Block, qc hotstuff.QC, height uint64) hotstuff.Block {
 	var headers []*core.BatchHeader
-	if VoteBatchFlag {
+	if hsd.config.VoteBatchFlag {
 		headers = hsd.leaderState.popReadyHeaders()
+		if hsd.config.CompactQCFlag {
+			hsd.compactBatchQCs(headers, height)
+		}
 	} else {
 		headers = hsd.voterState.popBatchHeaders()
 	}
@@ -47,16 +70,35 @@ func (hsd *hsDriver) CreateLeaf(parent hotstuff.Block, qc hotstuff.QC, height ui
 		SetTransactions(txs).
 		SetExecHeight(hsd.resources.Storage.GetBlockHeight()).
 		SetMerkleRoot(hsd.resources.Storage.GetMerkleRoot()).
-		SetTimestamp(time.Now().UnixNano()).
-		Sign(hsd.resources.Signer)
+		SetTimestamp(time.Now().UnixNano())
+	if _, err := blk.TrySign(hsd.resources.Signer); err != nil {
+		logger.I().Errorw("sign proposal failed", "height", height, "error", err)
+		return nil
+	}
+	// the proposal is the vote of this node, it is recorded before the proposal is sent
+	if err := hsd.saveSafetyState(newHsBlock(blk, hsd.state)); err != nil {
+		logger.I().Errorw("save safety state failed", "error", err)
+		return nil
+	}
 	hsd.state.setBlock(blk)
+	hsd.wal.appendProposal(blk)
 	idx := hsd.resources.VldStore.GetWorkerIndex(hsd.resources.Signer.PublicKey())
 	logger.I().Debugw("generated block", "batches", len(headers), "txs", len(blk.Transactions()), "leader", idx)
 	return newHsBlock(blk, hsd.state)
 }
 
+// compactBatchQCs encodes the batch qcs with the voters validating the block at height
+func (hsd *hsDriver) compactBatchQCs(headers []*core.BatchHeader, height uint64) {
+	vs := hsd.resources.VldStore.AtHeight(height)
+	for _, header := range headers {
+		if err := header.CompactQC(vs); err != nil {
+			logger.I().Warnw("compact batch qc failed", "error", err)
+		}
+	}
+}
+
 func (hsd *hsDriver) extractBatchTxs(headers []*core.BatchHeader) [][]byte {
-	if !ExecuteTxFlag {
+	if !hsd.config.ExecuteTxFlag {
 		txList := make([][]byte, 0)
 		for _, batch := range headers {
 			txList = append(txList, batch.Transactions()...)
@@ -64,10 +106,8 @@ func (hsd *hsDriver) extractBatchTxs(headers []*core.BatchHeader) [][]byte {
 		return txList
 	}
 
-	for _, batch := range headers {
-		if err := hsd.resources.TxPool.SyncTxs(batch.Proposer(), batch.Transactions()); err != nil {
-			logger.I().Errorw("sync txs failed", "error", err)
-		}
+	if err := fetchMissingBatches(hsd.resources, hsd.config.BatchSizeLimit, nil, headers); err != nil {
+		logger.I().Errorw("fetch batches failed", "error", err)
 	}
 
 	txSet := make(map[string]struct{})
@@ -93,6 +133,15 @@ func (hsd *hsDriver) CreateQC(hsVotes []hotstuff.Vote) hotstuff.QC {
 		votes[i] = hsv.(*hsVote).vote
 	}
 	qc := core.NewQuorumCert().Build(votes)
+	// the qc is validated with the validators of the block it certifies
+	if blk := hsd.state.getBlock(qc.BlockHash()); blk != nil {
+		qc.SetBlockHeight(blk.Height())
+	}
+	if hsd.config.CompactQCFlag {
+		if err := qc.Compact(hsd.resources.VldStore.AtHeight(qc.BlockHeight())); err != nil {
+			logger.I().Warnw("compact qc failed", "error", err)
+		}
+	}
 	return newHsQC(qc, hsd.state)
 }
 
@@ -103,23 +152,65 @@ func (hsd *hsDriver) BroadcastProposal(hsBlk hotstuff.Block) {
 
 func (hsd *hsDriver) VoteBlock(hsBlk hotstuff.Block) {
 	blk := hsBlk.(*hsBlock).block
-	vote := blk.Vote(hsd.resources.Signer)
-	if !PreserveTxFlag {
+	vote, err := blk.TryVote(hsd.resources.Signer)
+	if err != nil {
+		logger.I().Errorw("sign vote failed", "height", blk.Height(), "error", err)
+		return
+	}
+	if !hsd.config.PreserveTxFlag {
 		hsd.resources.TxPool.SetTxsPending(blk.Transactions())
 	}
 	hsd.delayVoteWhenNoTxs()
 	proposer := hsd.resources.VldStore.GetWorkerIndex(blk.Proposer())
-	if proposer != hsd.state.getLeaderIndex() {
+	if !hsd.config.VoteForwardFlag && proposer != hsd.state.getLeaderIndex() {
 		return // view changed happened
 	}
-	hsd.resources.MsgSvc.SendVote(blk.Proposer(), vote)
+	if err := hsd.saveSafetyState(hsBlk); err != nil {
+		logger.I().Errorw("save safety state failed", "error", err)
+		return // never vote without durable record
+	}
+	recipient := blk.Proposer()
+	if hsd.config.VoteForwardFlag {
+		// the leader of the next block aggregates votes into the qc of its proposal
+		recipient = hsd.resources.VldStore.GetWorker(hsd.state.getLeaderIndex())
+	}
+	if recipient.Equal(hsd.resources.Signer.PublicKey()) {
+		hsd.hotstuff.OnReceiveVote(newHsVote(vote, hsd.state))
+	} else {
+		hsd.resources.MsgSvc.SendVote(recipient, vote)
+	}
 	logger.I().Debugw("voted block",
 		"proposer", proposer,
+		"leader", hsd.state.getLeaderIndex(),
 		"height", hsBlk.Height(),
 		"qc", qcRefHeight(hsBlk.Justify()),
 	)
 }
 
+// saveSafetyState flushes the voting state to disk before the vote is sent,
+// bLock and qcHigh are the values hotstuff will hold after updating with the voted block
+func (hsd *hsDriver) saveSafetyState(hsBlk hotstuff.Block) error {
+	ss := *hsd.safety
+	ss.BVote = hsBlk.(*hsBlock).block
+	_, b1, b2 := hotstuff.GetJustifyBlocks(hsBlk)
+	if hotstuff.CmpBlockHeight(b2, newHsBlock(ss.QCHighBlock, hsd.state)) == 1 {
+		ss.QCHigh = hsBlk.Justify().(*hsQC).qc
+		ss.QCHighBlock = b2.(*hsBlock).block
+	}
+	bLock := b1
+	if hsd.config.Variant == hotstuff.TwoPhase {
+		bLock = b2
+	}
+	if hotstuff.CmpBlockHeight(bLock, newHsBlock(ss.BLock, hsd.state)) == 1 {
+		ss.BLock = bLock.(*hsBlock).block
+	}
+	if err := hsd.resources.Storage.SetSafetyState(&ss); err != nil {
+		return err
+	}
+	hsd.safety = &ss
+	return nil
+}
+
 func (hsd *hsDriver) delayVoteWhenNoTxs() {
 	timer := time.NewTimer(hsd.config.TxWaitTime)
 	defer timer.Stop()
@@ -138,12 +229,15 @@ func (hsd *hsDriver) Commit(hsBlk hotstuff.Block) {
 	rawTxs := bexe.Transactions()
 	var txCount int
 	var data *storage.CommitData
-	if ExecuteTxFlag {
+	if hsd.config.ExecuteTxFlag {
 		txs, old := hsd.resources.TxPool.GetTxsToExecute(rawTxs)
 		txCount = len(txs)
 		logger.I().Debugw("committing block", "height", bexe.Height(), "txs", txCount)
 		bcm, txcs := hsd.resources.Execution.Execute(bexe, txs)
 		bcm.SetOldBlockTxs(old)
+		if hsd.governance != nil {
+			hsd.governance.onCommit(bexe, txs)
+		}
 		data = &storage.CommitData{
 			Block:        bexe,
 			QC:           hsd.state.getQC(bexe.Hash()),
@@ -156,6 +250,9 @@ func (hsd *hsDriver) Commit(hsBlk hotstuff.Block) {
 		logger.I().Debugw("committing block", "height", bexe.Height(), "txs", txCount)
 		bcm, txcs := hsd.resources.Execution.MockExecute(bexe)
 		bcm.SetOldBlockTxs(rawTxs)
+		if hsd.governance != nil {
+			hsd.governance.onCommit(bexe, nil)
+		}
 		data = &storage.CommitData{
 			Block:        bexe,
 			QC:           hsd.state.getQC(bexe.Hash()),
@@ -168,8 +265,13 @@ func (hsd *hsDriver) Commit(hsBlk hotstuff.Block) {
 	if err != nil {
 		logger.I().Fatalf("commit storage error: %+v", err)
 	}
+	hsd.resources.VldStore.SignScheme().SetHeight(bexe.Height())
+	pruneCommittedBatches(hsd.resources, bexe)
 	hsd.state.addCommittedTxCount(txCount)
 	hsd.cleanStateOnCommitted(bexe)
+	if hsd.checkpoints != nil {
+		hsd.checkpoints.onCommit(bexe)
+	}
 	logger.I().Debugw("committed bock",
 		"height", bexe.Height(),
 		"batches", len(bexe.BatchHeaders()),
@@ -180,10 +282,11 @@ func (hsd *hsDriver) Commit(hsBlk hotstuff.Block) {
 func (hsd *hsDriver) cleanStateOnCommitted(bexec *core.Block) {
 	// qc for bexec is no longer needed here after committed to storage
 	hsd.state.deleteQC(bexec.Hash())
-	if !PreserveTxFlag {
+	if !hsd.config.PreserveTxFlag {
 		hsd.resources.TxPool.RemoveTxs(bexec.Transactions())
 	}
 	hsd.state.setCommittedBlock(bexec)
+	hsd.wal.onCommit(bexec)
 
 	blks := hsd.state.getUncommittedOlderBlocks(bexec)
 	for _, blk := range blks {
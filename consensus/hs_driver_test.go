@@ -5,10 +5,12 @@
 package consensus
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"github.com/wooyang2018/ppov-blockchain/core"
 	"github.com/wooyang2018/ppov-blockchain/hotstuff"
@@ -16,19 +18,51 @@ import (
 	"github.com/wooyang2018/ppov-blockchain/txpool"
 )
 
+type testMode struct {
+	name   string
+	config Config
+}
+
+// testModes returns the consensus flag combinations to run the tests under
+func testModes() []testMode {
+	modes := make([]testMode, 0, 4)
+	for _, executeTx := range []bool{false, true} {
+		for _, voteBatch := range []bool{false, true} {
+			config := DefaultConfig
+			config.ExecuteTxFlag = executeTx
+			config.PreserveTxFlag = !executeTx
+			config.GenerateTxFlag = !executeTx
+			config.VoteBatchFlag = voteBatch
+			name := "benchmark"
+			if executeTx {
+				name = "execute"
+			}
+			if voteBatch {
+				name += "-votebatch"
+			}
+			modes = append(modes, testMode{name, config})
+		}
+	}
+	return modes
+}
+
 func setupTestHsDriver() *hsDriver {
+	return setupTestHsDriverWithConfig(DefaultConfig)
+}
+
+func setupTestHsDriverWithConfig(config Config) *hsDriver {
 	resources := &Resources{
 		Signer: core.GenerateKey(nil),
 	}
 	state := newState(resources)
 	return &hsDriver{
 		resources: resources,
-		config:    DefaultConfig,
+		config:    config,
 		state:     state,
 	}
 }
 
-func TestHsDriver_TestMajorityCount(t *testing.T) {
+func TestHsDriver_QuorumPower(t *testing.T) {
 	hsd := setupTestHsDriver()
 	validators := []string{
 		core.GenerateKey(nil).PublicKey().String(),
@@ -38,37 +72,111 @@ func TestHsDriver_TestMajorityCount(t *testing.T) {
 	}
 	hsd.resources.VldStore = core.NewValidatorStore(validators, validators)
 
-	res := hsd.MajorityValidatorCount()
+	res := hsd.QuorumPower(newHsBlock(core.NewBlock().SetHeight(1), hsd.state))
 
 	assert := assert.New(t)
-	assert.Equal(hsd.resources.VldStore.MajorityValidatorCount(), res)
+	assert.Equal(hsd.resources.VldStore.QuorumPower(), res)
+	assert.EqualValues(3, res)
+
+	blk := core.NewBlock().Sign(core.GenerateKey(nil))
+	hsd.state.setBlock(blk)
+	vote := blk.ProposerVote()
+	assert.EqualValues(0, hsd.VotePower(newHsVote(vote, hsd.state)), "not a validator")
 }
 
 func TestHsDriver_CreateLeaf(t *testing.T) {
-	hsd := setupTestHsDriver()
+	for _, mode := range testModes() {
+		t.Run(mode.name, func(t *testing.T) {
+			testHsDriverCreateLeaf(t, mode.config)
+		})
+	}
+}
+
+// batch qcs are compacted with the voters of the block height, not the current ones
+func TestHsDriver_CreateLeafCompactQC(t *testing.T) {
+	asrt := assert.New(t)
+
+	config := DefaultConfig
+	config.VoteBatchFlag = true
+	config.CompactQCFlag = true
+	hsd := setupTestHsDriverWithConfig(config)
+
+	worker := hsd.resources.Signer
+	voters := []*core.PrivateKey{core.GenerateKey(nil), core.GenerateKey(nil), core.GenerateKey(nil)}
+	keys := make([]string, len(voters))
+	for i, v := range voters {
+		keys[i] = v.PublicKey().String()
+	}
+	workers := []string{worker.PublicKey().String()}
+	vldStore := core.NewEpochStore(workers, keys, nil)
+	asrt.NoError(vldStore.AddEpoch(&core.Epoch{
+		Start: 5, Workers: workers, Voters: []string{keys[2], keys[0], keys[1]},
+	}))
+	hsd.resources.VldStore = vldStore
+	hsd.leaderState = newLeaderState().setValidatorStore(vldStore).
+		setBatchSignLimit(vldStore.QuorumVoterPower()).
+		setBatchWaitTime(time.Second).setBlockBatchLimit(1)
+
+	header := core.NewBatch().Header().Sign(worker)
+	for _, voter := range voters {
+		hsd.leaderState.addBatchVote(core.NewBatchVote().Build([]*core.BatchHeader{header}, voter))
+	}
+
+	strg := new(MockStorage)
+	strg.On("GetBlockHeight").Return(3)
+	strg.On("GetMerkleRoot").Return(nil)
+	strg.On("SetSafetyState", mock.Anything).Return(nil)
+	strg.On("GetBlock", mock.Anything).Return(nil, errors.New("not found")) // empty qc
+	hsd.resources.Storage = strg
+
+	parent := core.NewBlock().SetHeight(4).Sign(worker)
+	hsd.state.setBlock(parent)
+	hsd.safety = &storage.SafetyState{BVote: parent, BLock: parent, QCHighBlock: parent}
+	leaf := hsd.CreateLeaf(newHsBlock(parent, hsd.state), newHsQC(core.NewQuorumCert(), hsd.state), 5)
+	asrt.NotNil(leaf)
+
+	data, err := leaf.(*hsBlock).block.BatchHeaders()[0].Marshal()
+	asrt.NoError(err)
+	received := core.NewBatchHeader()
+	asrt.NoError(received.Unmarshal(data))
+	qc := received.BatchQuorumCert()
+	if asrt.NotNil(qc) {
+		asrt.Equal(core.QCVersionCompact, qc.Version())
+		asrt.NoError(qc.Validate(vldStore.AtHeight(5)))
+		asrt.Error(qc.Validate(vldStore.AtHeight(4)))
+	}
+}
+
+func testHsDriverCreateLeaf(t *testing.T, config Config) {
+	hsd := setupTestHsDriverWithConfig(config)
 	parent := newHsBlock(core.NewBlock().Sign(hsd.resources.Signer), hsd.state)
 	hsd.state.setBlock(parent.(*hsBlock).block)
 	qc := newHsQC(core.NewQuorumCert(), hsd.state)
 	height := uint64(5)
 
-	storage := new(MockStorage)
-	storage.On("GetBlockHeight").Return(2) // driver should get bexec height from storage
-	storage.On("GetMerkleRoot").Return([]byte("merkle-root"))
-	hsd.resources.Storage = storage
+	strg := new(MockStorage)
+	strg.On("GetBlockHeight").Return(2) // driver should get bexec height from strg
+	strg.On("GetMerkleRoot").Return([]byte("merkle-root"))
+	strg.On("SetSafetyState", mock.Anything).Return(nil)
+	strg.On("GetBlock", mock.Anything).Return(nil, errors.New("not found")) // empty qc
+	hsd.resources.Storage = strg
+	b0 := parent.(*hsBlock).block
+	hsd.safety = &storage.SafetyState{BVote: b0, BLock: b0, QCHighBlock: b0}
 
 	signer := core.GenerateKey(nil)
 	hsd.resources.VldStore = core.NewValidatorStore([]string{signer.PublicKey().String()}, []string{signer.PublicKey().String()})
 
 	tx1, tx2 := []byte("tx1"), []byte("tx2")
 	txsInQ := [][]byte{tx1, tx2}
-	if ExecuteTxFlag {
-		storage.On("HasTx", tx1).Return(false)
-		storage.On("HasTx", tx2).Return(false)
+	if hsd.config.ExecuteTxFlag {
+		strg.On("HasTx", tx1).Return(false)
+		strg.On("HasTx", tx2).Return(false)
 	}
 
 	batch := core.NewBatch().Header().SetTransactions(txsInQ).Sign(signer)
-	if VoteBatchFlag {
-		hsd.leaderState = newLeaderState().setBatchWaitTime(3 * time.Second).setBatchSignLimit(1).setBlockBatchLimit(1)
+	if hsd.config.VoteBatchFlag {
+		hsd.leaderState = newLeaderState().setBatchWaitTime(3 * time.Second).setBatchSignLimit(1).setBlockBatchLimit(1).
+			setValidatorStore(hsd.resources.VldStore)
 		batchVote := core.NewBatchVote().Build([]*core.BatchHeader{batch}, signer)
 		hsd.leaderState.addBatchVote(batchVote)
 	} else {
@@ -76,15 +184,16 @@ func TestHsDriver_CreateLeaf(t *testing.T) {
 		hsd.voterState.addBatch(batch, 0, len(batch.Transactions()))
 	}
 
-	txpool := new(MockTxPool)
-	if ExecuteTxFlag {
-		txpool.On("SyncTxs", batch.Proposer(), batch.Transactions()).Return(nil)
+	if hsd.config.ExecuteTxFlag {
+		strg.On("HasBatch", batch.Hash()).Return(true) // leader received the batch
 	}
-	hsd.resources.TxPool = txpool
+	txPool := new(MockTxPool)
+	txPool.On("GetTx", mock.Anything).Return(core.NewTransaction())
+	hsd.resources.TxPool = txPool
 
 	leaf := hsd.CreateLeaf(parent, qc, height)
 
-	storage.AssertExpectations(t)
+	strg.AssertExpectations(t)
 
 	assert := assert.New(t)
 	assert.NotNil(leaf)
@@ -93,6 +202,7 @@ func TestHsDriver_CreateLeaf(t *testing.T) {
 	assert.Equal(height, leaf.Height())
 
 	blk := leaf.(*hsBlock).block
+	assert.Equal(blk, hsd.safety.BVote, "proposal is recorded as the vote")
 	assert.Equal(txsInQ, blk.Transactions())
 	assert.EqualValues(2, blk.ExecHeight())
 	assert.Equal([]byte("merkle-root"), blk.MerkleRoot())
@@ -102,7 +212,15 @@ func TestHsDriver_CreateLeaf(t *testing.T) {
 }
 
 func TestHsDriver_VoteBlock(t *testing.T) {
-	hsd := setupTestHsDriver()
+	for _, mode := range testModes() {
+		t.Run(mode.name, func(t *testing.T) {
+			testHsDriverVoteBlock(t, mode.config)
+		})
+	}
+}
+
+func testHsDriverVoteBlock(t *testing.T, config Config) {
+	hsd := setupTestHsDriverWithConfig(config)
 	hsd.checkTxDelay = time.Millisecond
 	hsd.config.TxWaitTime = 20 * time.Millisecond
 
@@ -112,9 +230,15 @@ func TestHsDriver_VoteBlock(t *testing.T) {
 	validators := []string{blk.Proposer().String()}
 	hsd.resources.VldStore = core.NewValidatorStore(validators, validators)
 
+	q0 := core.NewQuorumCert().Build([]*core.Vote{blk.ProposerVote()})
+	hsd.safety = &storage.SafetyState{BVote: blk, BLock: blk, QCHigh: q0, QCHighBlock: blk}
+	strg := new(MockStorage)
+	strg.On("SetSafetyState", mock.Anything).Return(nil)
+	hsd.resources.Storage = strg
+
 	txPool := new(MockTxPool)
 	txPool.On("GetStatus").Return(txpool.Status{}) // no txs in the pool
-	if !PreserveTxFlag {
+	if !hsd.config.PreserveTxFlag {
 		txPool.On("SetTxsPending", blk.Transactions())
 	}
 	hsd.resources.TxPool = txPool
@@ -130,13 +254,14 @@ func TestHsDriver_VoteBlock(t *testing.T) {
 
 	txPool.AssertExpectations(t)
 	msgSvc.AssertExpectations(t)
+	strg.AssertExpectations(t)
 
 	assert := assert.New(t)
 	assert.GreaterOrEqual(elapsed, hsd.config.TxWaitTime, "should delay if no txs in the pool")
 
 	txPool = new(MockTxPool)
 	txPool.On("GetStatus").Return(txpool.Status{Total: 1}) // one txs in the pool
-	if !PreserveTxFlag {
+	if !hsd.config.PreserveTxFlag {
 		txPool.On("SetTxsPending", blk.Transactions())
 	}
 	hsd.resources.TxPool = txPool
@@ -149,10 +274,126 @@ func TestHsDriver_VoteBlock(t *testing.T) {
 	msgSvc.AssertExpectations(t)
 
 	assert.Less(elapsed, hsd.config.TxWaitTime, "should not delay if txs in the pool")
+
+	strg = new(MockStorage)
+	strg.On("SetSafetyState", mock.Anything).Return(errors.New("disk failure"))
+	hsd.resources.Storage = strg
+	msgSvc = new(MockMsgService)
+	hsd.resources.MsgSvc = msgSvc
+
+	hsd.VoteBlock(newHsBlock(blk, hsd.state))
+
+	msgSvc.AssertNotCalled(t, "SendVote", mock.Anything, mock.Anything)
+}
+
+func TestHsDriver_VoteBlockForward(t *testing.T) {
+	config := DefaultConfig
+	config.VoteForwardFlag = true
+	config.TxWaitTime = 0
+	hsd := setupTestHsDriverWithConfig(config)
+
+	proposer := core.GenerateKey(nil)
+	next := core.GenerateKey(nil)
+	validators := []string{
+		proposer.PublicKey().String(),
+		next.PublicKey().String(),
+		hsd.resources.Signer.PublicKey().String(),
+	}
+	hsd.resources.VldStore = core.NewValidatorStore(validators, validators)
+	b0 := core.NewBlock().Sign(proposer)
+	q0 := core.NewQuorumCert().Build([]*core.Vote{b0.ProposerVote()})
+	blk := core.NewBlock().SetHeight(1).SetParentHash(b0.Hash()).SetQuorumCert(q0).Sign(proposer)
+	hsd.state.setBlock(b0)
+	hsd.state.setBlock(blk)
+	hsd.safety = &storage.SafetyState{BVote: b0, BLock: b0, QCHigh: q0, QCHighBlock: b0}
+
+	strg := new(MockStorage)
+	strg.On("SetSafetyState", mock.Anything).Return(nil)
+	hsd.resources.Storage = strg
+	txPool := new(MockTxPool)
+	txPool.On("GetStatus").Return(txpool.Status{Total: 1})
+	hsd.resources.TxPool = txPool
+
+	// view changed to the next leader after the block is proposed
+	hsd.state.setLeaderIndex(0)
+	hsd.state.setLeaderIndex(1)
+	msgSvc := new(MockMsgService)
+	msgSvc.On("SendVote", next.PublicKey(), blk.Vote(hsd.resources.Signer)).Return(nil)
+	hsd.resources.MsgSvc = msgSvc
+
+	hsd.VoteBlock(newHsBlock(blk, hsd.state))
+	msgSvc.AssertExpectations(t)
+
+	// this node is the next leader
+	hsd.state.setLeaderIndex(2)
+	hsd.hotstuff = hotstuff.New(hsd, nil, newHsBlock(b0, hsd.state), newHsQC(q0, hsd.state))
+	hsd.hotstuff.CollectVotes(newHsBlock(blk, hsd.state))
+	msgSvc = new(MockMsgService)
+	hsd.resources.MsgSvc = msgSvc
+
+	hsd.VoteBlock(newHsBlock(blk, hsd.state))
+	msgSvc.AssertNotCalled(t, "SendVote", mock.Anything, mock.Anything)
+	assert.Equal(t, 1, hsd.hotstuff.GetVoteCount(), "own vote is added to hotstuff")
+}
+
+func TestHsDriver_saveSafetyState(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant hotstuff.Variant
+		lock    int
+	}{
+		{"two-phase", hotstuff.TwoPhase, 2},
+		{"three-phase", hotstuff.ThreePhase, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			hsd := setupTestHsDriver()
+			hsd.config.Variant = tt.variant
+			strg := new(MockStorage)
+			strg.On("SetSafetyState", mock.Anything).Return(nil)
+			hsd.resources.Storage = strg
+
+			blks := make([]*core.Block, 4)
+			qcs := make([]*core.QuorumCert, 4)
+			for i := range blks {
+				blk := core.NewBlock().SetHeight(uint64(i))
+				if i > 0 {
+					blk.SetParentHash(blks[i-1].Hash()).SetQuorumCert(qcs[i-1])
+				}
+				blks[i] = blk.Sign(hsd.resources.Signer)
+				qcs[i] = core.NewQuorumCert().Build([]*core.Vote{blks[i].ProposerVote()})
+				hsd.state.setBlock(blks[i])
+			}
+			hsd.safety = &storage.SafetyState{
+				BVote: blks[0], BLock: blks[0], QCHigh: qcs[0], QCHighBlock: blks[0],
+			}
+
+			assert.NoError(hsd.saveSafetyState(newHsBlock(blks[3], hsd.state)))
+			assert.Equal(blks[3], hsd.safety.BVote)
+			assert.Equal(blks[tt.lock], hsd.safety.BLock)
+			assert.Equal(qcs[2], hsd.safety.QCHigh)
+			assert.Equal(blks[2], hsd.safety.QCHighBlock)
+			strg.AssertCalled(t, "SetSafetyState", hsd.safety)
+
+			assert.NoError(hsd.saveSafetyState(newHsBlock(blks[2], hsd.state)))
+			assert.Equal(blks[tt.lock], hsd.safety.BLock, "lock should not move backward")
+			assert.Equal(qcs[2], hsd.safety.QCHigh, "qc should not move backward")
+		})
+	}
 }
 
 func TestHsDriver_Commit(t *testing.T) {
-	hsd := setupTestHsDriver()
+	for _, mode := range testModes() {
+		t.Run(mode.name, func(t *testing.T) {
+			testHsDriverCommit(t, mode.config)
+		})
+	}
+}
+
+func testHsDriverCommit(t *testing.T, config Config) {
+	hsd := setupTestHsDriverWithConfig(config)
 	parent := core.NewBlock().SetHeight(10).Sign(hsd.resources.Signer)
 	batch := core.NewBatch().Header().SetTransactions([][]byte{[]byte("txfromfolk")}).Sign(hsd.resources.Signer)
 	bfolk := core.NewBlock().SetBatchHeaders([]*core.BatchHeader{batch}, true).SetHeight(10).Sign(hsd.resources.Signer)
@@ -167,10 +408,10 @@ func TestHsDriver_Commit(t *testing.T) {
 
 	txs := []*core.Transaction{tx}
 	txPool := new(MockTxPool)
-	if ExecuteTxFlag {
+	if hsd.config.ExecuteTxFlag {
 		txPool.On("GetTxsToExecute", bexec.Transactions()).Return(txs, nil)
 	}
-	if !PreserveTxFlag {
+	if !hsd.config.PreserveTxFlag {
 		txPool.On("RemoveTxs", bexec.Transactions()).Once() // should remove txs from pool after commit
 	}
 	txPool.On("PutTxsToQueue", bfolk.Transactions()).Once() // should put txs of folked block back to queue from pending
@@ -186,7 +427,7 @@ func TestHsDriver_Commit(t *testing.T) {
 	}
 
 	execution := new(MockExecution)
-	if ExecuteTxFlag {
+	if hsd.config.ExecuteTxFlag {
 		execution.On("Execute", bexec, txs).Return(bcm, txcs)
 	} else {
 		cdata.Transactions = nil
@@ -198,8 +439,14 @@ func TestHsDriver_Commit(t *testing.T) {
 	storage.On("Commit", cdata).Return(nil)
 	hsd.resources.Storage = storage
 
+	// messages signed after the commit are for height 12
+	vldStore := core.NewEpochStore(nil, nil, nil)
+	vldStore.SetSignScheme(core.NewSignScheme(1, 12))
+	hsd.resources.VldStore = vldStore
+
 	hsd.Commit(newHsBlock(bexec, hsd.state))
 
+	assert.Equal(t, core.SignDomain, vldStore.SignScheme().CurrentVersion())
 	txPool.AssertExpectations(t)
 	execution.AssertExpectations(t)
 	storage.AssertExpectations(t)
@@ -213,7 +460,7 @@ func TestHsDriver_Commit(t *testing.T) {
 
 func TestHsDriver_CreateQC(t *testing.T) {
 	hsd := setupTestHsDriver()
-	blk := core.NewBlock().Sign(hsd.resources.Signer)
+	blk := core.NewBlock().SetHeight(4).Sign(hsd.resources.Signer)
 	hsd.state.setBlock(blk)
 	votes := []hotstuff.Vote{
 		newHsVote(blk.ProposerVote(), hsd.state),
@@ -223,6 +470,7 @@ func TestHsDriver_CreateQC(t *testing.T) {
 
 	assert := assert.New(t)
 	assert.Equal(blk, qc.Block().(*hsBlock).block, "should get qc reference block")
+	assert.EqualValues(4, qc.(*hsQC).qc.BlockHeight(), "should set certified block height")
 }
 
 func TestHsDriver_BroadcastProposal(t *testing.T) {
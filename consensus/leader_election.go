@@ -0,0 +1,126 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/wooyang2018/ppov-blockchain/hotstuff"
+	"github.com/wooyang2018/ppov-blockchain/logger"
+)
+
+// leader election policies
+const (
+	ElectionRoundRobin = "round-robin"
+	ElectionReputation = "reputation"
+	ElectionRandom     = "random"
+)
+
+// LeaderElection decides the leader of each view.
+// It must return the same leader on every node for the same view and block height.
+type LeaderElection interface {
+	Leader(view, height uint64) int // returns worker index of the view leader, height is the electionHeight
+}
+
+// electionHeight is the height given to the leader election for the view whose leader proposes on the qc,
+// the leader and the validators approving its proposal derive it from the same qc
+func electionHeight(qc hotstuff.QC) uint64 {
+	return qcRefHeight(qc) + 1
+}
+
+func newLeaderElection(config Config, resources *Resources) (LeaderElection, error) {
+	switch config.LeaderElection {
+	case "", ElectionRoundRobin:
+		return &roundRobin{resources: resources}, nil
+	case ElectionReputation:
+		if config.ReputationWindow <= 0 {
+			return nil, fmt.Errorf("invalid reputation window %d", config.ReputationWindow)
+		}
+		return &reputation{resources: resources, window: uint64(config.ReputationWindow)}, nil
+	case ElectionRandom:
+		return &random{resources: resources}, nil
+	default:
+		return nil, fmt.Errorf("unknown leader election %q", config.LeaderElection)
+	}
+}
+
+// roundRobin rotates leader over all workers
+type roundRobin struct {
+	resources *Resources
+}
+
+var _ LeaderElection = (*roundRobin)(nil)
+
+func (rr *roundRobin) Leader(view, height uint64) int {
+	return int(view % uint64(rr.resources.VldStore.WorkerCount()))
+}
+
+// random picks leader by the hash of view
+type random struct {
+	resources *Resources
+}
+
+var _ LeaderElection = (*random)(nil)
+
+func (r *random) Leader(view, height uint64) int {
+	h := sha3.New256()
+	binary.Write(h, binary.BigEndian, view)
+	seed := binary.BigEndian.Uint64(h.Sum(nil))
+	return int(seed % uint64(r.resources.VldStore.WorkerCount()))
+}
+
+// blocks this far below the proposed block are committed under both hotstuff variants
+const reputationLag = 4
+
+// reputation rotates leader like round-robin but skips inactive workers.
+// A worker is active if it proposed a committed block in the window.
+// The window is derived from the election height, it ends at the latest multiple of its size
+// which is reputationLag blocks below, so that nodes agree on it regardless of their commits.
+// If any block of the window is not committed, the whole window falls back to round-robin.
+type reputation struct {
+	resources *Resources
+	window    uint64
+}
+
+var _ LeaderElection = (*reputation)(nil)
+
+func (rep *reputation) Leader(view, height uint64) int {
+	count := rep.resources.VldStore.WorkerCount()
+	active := rep.activeWorkers(height)
+	for i := 0; i < count; i++ {
+		idx := int((view + uint64(i)) % uint64(count))
+		if active[idx] {
+			return idx
+		}
+	}
+	return int(view % uint64(count)) // no reputation yet or incomplete window
+}
+
+func (rep *reputation) activeWorkers(height uint64) map[int]bool {
+	active := make(map[int]bool)
+	if height < reputationLag {
+		return active
+	}
+	end := height - reputationLag
+	end -= end % rep.window
+	if end < rep.window {
+		return active // not enough blocks
+	}
+	for h := end - rep.window + 1; h <= end; h++ {
+		blk, err := rep.resources.Storage.GetBlockByHeight(h)
+		if err != nil {
+			// a partial window could elect a leader which other nodes do not
+			logger.I().Warnw("reputation window block not committed, using round-robin",
+				"height", h, "error", err)
+			return nil
+		}
+		if rep.resources.VldStore.IsWorker(blk.Proposer()) {
+			active[rep.resources.VldStore.GetWorkerIndex(blk.Proposer())] = true
+		}
+	}
+	return active
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func setupElectionResources(count int) (*Resources, []*core.PrivateKey) {
+	privKeys := make([]*core.PrivateKey, count)
+	keys := make([]string, count)
+	for i := range privKeys {
+		privKeys[i] = core.GenerateKey(nil)
+		keys[i] = privKeys[i].PublicKey().String()
+	}
+	return &Resources{VldStore: core.NewValidatorStore(keys, keys)}, privKeys
+}
+
+func TestNewLeaderElection(t *testing.T) {
+	assert := assert.New(t)
+
+	resources, _ := setupElectionResources(4)
+	config := DefaultConfig
+
+	election, err := newLeaderElection(config, resources)
+	assert.NoError(err)
+	assert.IsType(&roundRobin{}, election)
+
+	config.LeaderElection = ElectionReputation
+	election, err = newLeaderElection(config, resources)
+	assert.NoError(err)
+	assert.IsType(&reputation{}, election)
+
+	config.ReputationWindow = 0
+	_, err = newLeaderElection(config, resources)
+	assert.Error(err)
+
+	config.LeaderElection = ElectionRandom
+	election, err = newLeaderElection(config, resources)
+	assert.NoError(err)
+	assert.IsType(&random{}, election)
+
+	config.LeaderElection = "unknown"
+	_, err = newLeaderElection(config, resources)
+	assert.Error(err)
+}
+
+func TestRoundRobin_Leader(t *testing.T) {
+	assert := assert.New(t)
+
+	resources, _ := setupElectionResources(4)
+	rr := &roundRobin{resources: resources}
+
+	assert.Equal(0, rr.Leader(0, 1))
+	assert.Equal(3, rr.Leader(3, 1))
+	assert.Equal(1, rr.Leader(5, 1))
+}
+
+func TestRandom_Leader(t *testing.T) {
+	assert := assert.New(t)
+
+	resources, _ := setupElectionResources(4)
+	r1 := &random{resources: resources}
+	r2 := &random{resources: resources}
+
+	leaders := make(map[int]struct{})
+	for view := uint64(0); view < 100; view++ {
+		leader := r1.Leader(view, 1)
+		assert.Equal(leader, r2.Leader(view, 1), "must be deterministic")
+		assert.GreaterOrEqual(leader, 0)
+		assert.Less(leader, 4)
+		leaders[leader] = struct{}{}
+	}
+	assert.Len(leaders, 4)
+}
+
+func TestReputation_Leader(t *testing.T) {
+	assert := assert.New(t)
+
+	resources, keys := setupElectionResources(4)
+	storage := new(MockStorage)
+	resources.Storage = storage
+
+	// worker 2 proposes no blocks, batch proposers are not counted
+	for h := 1; h <= 20; h++ {
+		proposer := keys[[]int{0, 1, 3}[h%3]]
+		headers := []*core.BatchHeader{core.NewBatchHeader().Sign(keys[2])}
+		blk := core.NewBlock().SetHeight(uint64(h)).SetBatchHeaders(headers, false).Sign(proposer)
+		storage.On("GetBlockByHeight", uint64(h)).Return(blk, nil)
+	}
+
+	rep := &reputation{resources: resources, window: 10}
+
+	assert.Equal(2, rep.Leader(2, 13), "not enough blocks")
+	storage.AssertNotCalled(t, "GetBlockByHeight", mock.Anything)
+
+	assert.Equal(1, rep.Leader(1, 14))
+	assert.Equal(3, rep.Leader(2, 14))
+	assert.Equal(3, rep.Leader(6, 14))
+	assert.Equal(0, rep.Leader(4, 23), "same window until the proposed height passes the next boundary")
+	storage.AssertNumberOfCalls(t, "GetBlockByHeight", 40)
+
+	// the window depends on the election height only, not the committed height of this node
+	storage.On("GetBlockHeight").Return(100)
+	assert.Equal(3, rep.Leader(2, 20))
+}
+
+func TestReputation_LeaderCommitHeights(t *testing.T) {
+	assert := assert.New(t)
+
+	resources, keys := setupElectionResources(4)
+	blocks := make([]*core.Block, 21)
+	for h := 1; h <= 20; h++ {
+		blocks[h] = core.NewBlock().SetHeight(uint64(h)).Sign(keys[[]int{0, 1, 3}[h%3]])
+	}
+	// newReputation creates the election of a node which committed the blocks up to height
+	newReputation := func(height uint64, missing ...uint64) *reputation {
+		storage := new(MockStorage)
+		for h := uint64(1); h <= 20; h++ {
+			if h > height || slices.Contains(missing, h) {
+				storage.On("GetBlockByHeight", h).Return(nil, errors.New("not found"))
+			} else {
+				storage.On("GetBlockByHeight", h).Return(blocks[h], nil)
+			}
+		}
+		storage.On("GetBlockHeight").Return(height)
+		res := *resources
+		res.Storage = storage
+		return &reputation{resources: &res, window: 10}
+	}
+
+	ahead := newReputation(20)
+	behind := newReputation(12)
+	for view := uint64(0); view < 8; view++ {
+		// both nodes elect on the same qc
+		assert.Equal(ahead.Leader(view, 14), behind.Leader(view, 14), "view %d", view)
+	}
+	assert.Equal(3, behind.Leader(2, 14))
+
+	// a window with any block not committed falls back to round-robin as a whole
+	partial := newReputation(20, 5)
+	for view := uint64(0); view < 8; view++ {
+		assert.Equal(int(view%4), partial.Leader(view, 14))
+	}
+	assert.Equal(2, behind.Leader(2, 24))
+}
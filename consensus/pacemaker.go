@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/emitter"
 	"github.com/wooyang2018/ppov-blockchain/hotstuff"
 	"github.com/wooyang2018/ppov-blockchain/logger"
 )
@@ -64,8 +65,13 @@ func (pm *pacemaker) batchRun() {
 }
 
 func (pm *pacemaker) run() {
-	//subQC := pm.hotstuff.SubscribeNewQCHigh()
-	//defer subQC.Unsubscribe()
+	// with vote forwarding, the next leader proposes as soon as it aggregates the qc
+	var qcCh <-chan emitter.Event
+	if pm.config.VoteForwardFlag {
+		subQC := pm.hotstuff.SubscribeNewQCHigh()
+		defer subQC.Unsubscribe()
+		qcCh = subQC.Events()
+	}
 	for {
 		blkDelayT := pm.nextBlockDelay()
 		pm.newBlock()
@@ -77,7 +83,7 @@ func (pm *pacemaker) run() {
 
 		// either beatdelay timeout or I'm able to create qc
 		case <-beatT.C:
-			//case <-subQC.Events():
+		case <-qcCh:
 		}
 		beatT.Stop()
 
@@ -92,7 +98,7 @@ func (pm *pacemaker) run() {
 
 func (pm *pacemaker) nextBlockDelay() *time.Timer {
 	delay := pm.config.BlockDelay
-	if VoteBatchFlag {
+	if pm.config.VoteBatchFlag {
 		if pm.leaderState.getBatchReadyNum() < pm.config.BlockBatchLimit {
 			delay += time.Duration(pm.config.BlockBatchLimit-
 				pm.leaderState.getBatchReadyNum()) * (pm.config.TxWaitTime / 2)
@@ -124,8 +130,18 @@ func (pm *pacemaker) newBlock() {
 	if !pm.state.isThisNodeLeader() {
 		return
 	}
+	if pm.isWaitingForwardedVotes() {
+		return
+	}
+	if pm.state.isWaitingNewViews() {
+		return // qc high of the new view is not decided yet
+	}
 
 	blk := pm.hotstuff.OnPropose()
+	if blk == nil {
+		return // the signer refused the proposal
+	}
+	pm.state.setForwardStart(0)
 	logger.I().Debugw("proposed block", "height", blk.Height(),
 		"qc", qcRefHeight(blk.Justify()), "txs", len(blk.Transactions()))
 	vote := blk.(*hsBlock).block.ProposerVote()
@@ -133,6 +149,32 @@ func (pm *pacemaker) newBlock() {
 	pm.hotstuff.Update(blk)
 }
 
+func (pm *pacemaker) takeTxsFromQueue() []*core.Transaction {
+	if pm.config.PartitionTxFlag {
+		// workers receiving the same txs produce disjoint batches
+		filter := txPartitionFilter(pm.resources.VldStore,
+			pm.resources.Signer.PublicKey(), pm.config.PartitionTimeout)
+		if pm.config.PreserveTxFlag {
+			return pm.resources.TxPool.GetTxsFromQueueBy(pm.config.BatchTxLimit, filter)
+		}
+		return pm.resources.TxPool.PopTxsFromQueueBy(pm.config.BatchTxLimit, filter)
+	}
+	if pm.config.PreserveTxFlag {
+		return pm.resources.TxPool.GetTxsFromQueue(pm.config.BatchTxLimit)
+	}
+	return pm.resources.TxPool.PopTxsFromQueue(pm.config.BatchTxLimit)
+}
+
+// isWaitingForwardedVotes returns true if the qc of the previous leader's block is being aggregated,
+// proposing now would abandon that block
+func (pm *pacemaker) isWaitingForwardedVotes() bool {
+	if !pm.config.VoteForwardFlag || !pm.hotstuff.IsProposing() {
+		return false
+	}
+	elapsed := time.Now().UnixNano() - pm.state.getForwardStart()
+	return elapsed < int64(pm.config.ProposeTimeout)
+}
+
 func (pm *pacemaker) newBatch() {
 	pm.state.mtxUpdate.Lock()
 	defer pm.state.mtxUpdate.Unlock()
@@ -147,11 +189,13 @@ func (pm *pacemaker) newBatch() {
 		return
 	}
 
-	var txs []*core.Transaction
-	if PreserveTxFlag {
-		txs = pm.resources.TxPool.GetTxsFromQueue(pm.config.BatchTxLimit)
-	} else {
-		txs = pm.resources.TxPool.PopTxsFromQueue(pm.config.BatchTxLimit)
+	txs, rest := takeTxsBySize(pm.takeTxsFromQueue(), pm.config.BatchSizeLimit)
+	if len(rest) > 0 && !pm.config.PreserveTxFlag {
+		hashes := make([][]byte, len(rest))
+		for i, tx := range rest {
+			hashes[i] = tx.Hash()
+		}
+		pm.resources.TxPool.PutTxsToQueue(hashes) // left for the next batch
 	}
 	if len(txs) == 0 { // 忽略打包空Batch
 		return
@@ -162,8 +206,11 @@ func (pm *pacemaker) newBatch() {
 	widx := pm.resources.VldStore.GetWorkerIndex(signer.PublicKey())
 	txCount := len(batch.Header().Transactions())
 	logger.I().Debugw("generated batch", "worker", widx, "txs", txCount)
+	if err := pm.resources.Storage.StoreBatch(batch); err != nil {
+		logger.I().Errorw("store batch failed", "error", err)
+	}
 
-	if VoteBatchFlag {
+	if pm.config.VoteBatchFlag {
 		if pm.state.isThisNodeVoter() {
 			pm.voterState.addBatch(batch.Header(), widx, txCount)
 		}
@@ -14,6 +14,8 @@ import (
 type voterState struct {
 	batchQ         []*core.BatchHeader //待投票的Batch队列
 	voteBatchLimit int
+	sizeLimit      int  //弹出的Batch头部总大小上限，0表示不限制
+	preserveTx     bool //是否在打包后保留交易，保留时需限制队列长度
 	mtxState       sync.RWMutex
 
 	writer  *csv.Writer
@@ -35,6 +37,20 @@ func (v *voterState) setVoteBatchLimit(voteBatchLimit int) *voterState {
 	return v
 }
 
+func (v *voterState) setSizeLimit(sizeLimit int) *voterState {
+	v.mtxState.Lock()
+	defer v.mtxState.Unlock()
+	v.sizeLimit = sizeLimit
+	return v
+}
+
+func (v *voterState) setPreserveTx(preserveTx bool) *voterState {
+	v.mtxState.Lock()
+	defer v.mtxState.Unlock()
+	v.preserveTx = preserveTx
+	return v
+}
+
 func (v *voterState) addBatch(batch *core.BatchHeader, widx int, txs int) {
 	t1 := time.Now().UnixNano()
 	v.saveItem(widx, t1, txs)
@@ -42,7 +58,7 @@ func (v *voterState) addBatch(batch *core.BatchHeader, widx int, txs int) {
 	v.mtxState.Lock()
 	defer v.mtxState.Unlock()
 	v.batchQ = append(v.batchQ, batch)
-	if PreserveTxFlag && len(v.batchQ) > 3*v.voteBatchLimit {
+	if v.preserveTx && len(v.batchQ) > 3*v.voteBatchLimit {
 		v.batchQ = v.batchQ[1:]
 	}
 }
@@ -64,15 +80,7 @@ func (v *voterState) popBatchHeaders() []*core.BatchHeader {
 	v.mtxState.Lock()
 	defer v.mtxState.Unlock()
 
-	num := v.voteBatchLimit
-	res := make([]*core.BatchHeader, 0, num)
-	for _, batch := range v.batchQ {
-		if num <= 0 {
-			break
-		}
-		num--
-		res = append(res, batch)
-	}
+	res := takeBatchHeaders(v.batchQ, v.voteBatchLimit, v.sizeLimit)
 	v.batchQ = v.batchQ[len(res):]
 	return res
 }
@@ -128,7 +136,9 @@ type leaderState struct {
 
 	batchWaitTime   time.Duration //Batch超时时间
 	blockBatchLimit int
-	batchSignLimit  int
+	sizeLimit       int    //弹出的Batch头部总大小上限，0表示不限制
+	batchSignLimit  uint64 // voting power required for a batch quorum cert
+	vldStore        core.ValidatorStore
 
 	mtxState sync.RWMutex //TODO 锁粒度优化
 }
@@ -142,7 +152,14 @@ func newLeaderState() *leaderState {
 	}
 }
 
-func (l *leaderState) setBatchSignLimit(batchSignLimit int) *leaderState {
+func (l *leaderState) setValidatorStore(vldStore core.ValidatorStore) *leaderState {
+	l.mtxState.Lock()
+	defer l.mtxState.Unlock()
+	l.vldStore = vldStore
+	return l
+}
+
+func (l *leaderState) setBatchSignLimit(batchSignLimit uint64) *leaderState {
 	l.mtxState.Lock()
 	defer l.mtxState.Unlock()
 	l.batchSignLimit = batchSignLimit
@@ -156,6 +173,13 @@ func (l *leaderState) setBlockBatchLimit(blockBatchLimit int) *leaderState {
 	return l
 }
 
+func (l *leaderState) setSizeLimit(sizeLimit int) *leaderState {
+	l.mtxState.Lock()
+	defer l.mtxState.Unlock()
+	l.sizeLimit = sizeLimit
+	return l
+}
+
 func (l *leaderState) setBatchWaitTime(batchWaitTime time.Duration) *leaderState {
 	l.mtxState.Lock()
 	defer l.mtxState.Unlock()
@@ -183,7 +207,11 @@ func (l *leaderState) addBatchVote(vote *core.BatchVote) {
 			go l.waitCleanState(hash, time.NewTimer(l.batchWaitTime), l.batchStopCh[hash])
 		}
 		l.batchSigns[hash] = append(l.batchSigns[hash], sig)
-		if len(l.batchSigns[hash]) >= l.batchSignLimit {
+		var power uint64
+		for _, s := range l.batchSigns[hash] {
+			power += l.vldStore.GetPower(s.PublicKey())
+		}
+		if power >= l.batchSignLimit {
 			batchQC := core.NewBatchQuorumCert().Build(batch.Hash(), l.batchSigns[hash])
 			batch.SetBatchQuorumCert(batchQC)
 			l.batchReadyQ = append(l.batchReadyQ, batch)
@@ -201,15 +229,7 @@ func (l *leaderState) popReadyHeaders() []*core.BatchHeader {
 	l.mtxState.Lock()
 	defer l.mtxState.Unlock()
 
-	num := l.blockBatchLimit
-	res := make([]*core.BatchHeader, 0, num)
-	for _, batch := range l.batchReadyQ {
-		if num <= 0 { //TODO 改写法
-			break
-		}
-		num--
-		res = append(res, batch)
-	}
+	res := takeBatchHeaders(l.batchReadyQ, l.blockBatchLimit, l.sizeLimit)
 	l.batchReadyQ = l.batchReadyQ[len(res):]
 	return res
 }
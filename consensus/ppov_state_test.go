@@ -0,0 +1,61 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func TestVoterState_addBatch(t *testing.T) {
+	signer := core.GenerateKey(nil)
+	tests := []struct {
+		name       string
+		preserveTx bool
+		want       int
+	}{
+		{"preserve tx limits queue", true, 3},
+		{"pop tx keeps all batches", false, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vs := newVoterState().setVoteBatchLimit(1).setPreserveTx(tt.preserveTx)
+			for i := 0; i < 5; i++ {
+				header := core.NewBatch().Header().SetTimestamp(int64(i)).Sign(signer)
+				vs.addBatch(header, 0, 0)
+			}
+			assert.Equal(t, tt.want, vs.getBatchNum())
+		})
+	}
+}
+
+func TestLeaderState_addBatchVoteWeighted(t *testing.T) {
+	asrt := assert.New(t)
+
+	worker := core.GenerateKey(nil)
+	voters := []*core.PrivateKey{core.GenerateKey(nil), core.GenerateKey(nil), core.GenerateKey(nil)}
+	keys := make([]string, len(voters))
+	for i, v := range voters {
+		keys[i] = v.PublicKey().String()
+	}
+	// total voter power 6, quorum power 5
+	vldStore := core.NewWeightedValidatorStore([]string{worker.PublicKey().String()}, keys,
+		map[string]uint64{keys[0]: 4})
+	ls := newLeaderState().setValidatorStore(vldStore).
+		setBatchSignLimit(vldStore.QuorumVoterPower()).
+		setBatchWaitTime(time.Second).setBlockBatchLimit(1)
+
+	header := core.NewBatch().Header().Sign(worker)
+	ls.addBatchVote(core.NewBatchVote().Build([]*core.BatchHeader{header}, voters[1]))
+	ls.addBatchVote(core.NewBatchVote().Build([]*core.BatchHeader{header}, voters[2]))
+	asrt.Equal(0, ls.getBatchReadyNum(), "majority count but not enough power")
+
+	ls.addBatchVote(core.NewBatchVote().Build([]*core.BatchHeader{header}, voters[0]))
+	asrt.Equal(1, ls.getBatchReadyNum())
+	asrt.NoError(ls.popReadyHeaders()[0].BatchQuorumCert().Validate(vldStore))
+}
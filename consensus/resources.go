@@ -18,11 +18,12 @@ type TxPool interface {
 	StorePendingTxs(txs *core.TxList) error
 	PopTxsFromQueue(max int) []*core.Transaction
 	GetTxsFromQueue(max int) []*core.Transaction
+	PopTxsFromQueueBy(max int, filter txpool.TxFilter) []*core.Transaction
+	GetTxsFromQueueBy(max int, filter txpool.TxFilter) []*core.Transaction
 	SetTxsPending(hashes [][]byte)
 	GetTxsToExecute(hashes [][]byte) ([]*core.Transaction, [][]byte)
 	RemoveTxs(hashes [][]byte)
 	PutTxsToQueue(hashes [][]byte)
-	SyncTxs(peer *core.PublicKey, hashes [][]byte) error
 	GetTx(hash []byte) *core.Transaction
 	GetStatus() txpool.Status
 	GetTxStatus(hash []byte) txpool.TxStatus
@@ -33,26 +34,53 @@ type Storage interface {
 	Commit(data *storage.CommitData) error
 	GetBlock(hash []byte) (*core.Block, error)
 	GetLastBlock() (*core.Block, error)
+	GetBlockByHeight(height uint64) (*core.Block, error)
 	GetLastQC() (*core.QuorumCert, error)
 	GetBlockHeight() uint64
 	HasTx(hash []byte) bool
+	GetSafetyState() (*storage.SafetyState, error)
+	SetSafetyState(ss *storage.SafetyState) error
+	GetSafetyView() (uint64, error)
+	SetSafetyView(view uint64) error
+	HasEvidence(ev *core.Evidence) bool
+	GetGovernanceState() (*storage.GovernanceState, error)
+	SetGovernanceState(gs *storage.GovernanceState) error
+	SetEvidence(ev *core.Evidence) error
+	GetWALEntries() ([]*storage.WALEntry, error)
+	AppendWAL(entry *storage.WALEntry) error
+	DeleteWAL(seqs []uint64) error
+	GetBatch(hash []byte) (*core.Batch, error)
+	HasBatch(hash []byte) bool
+	StoreBatch(batch *core.Batch) error
+	DeleteBatches(hashes [][]byte) error
+	HasCheckpointCert(height uint64) bool
+	SetCheckpointCert(cert *core.CheckpointCert) error
 }
 
 type MsgService interface {
 	BroadcastProposal(blk *core.Block) error
 	BroadcastBatch(batch *core.Batch) error
-	BroadcastNewView(qc *core.QuorumCert) error
+	BroadcastNewView(nv *core.NewView) error
+	BroadcastTimeout(to *core.Timeout) error
+	BroadcastTimeoutCert(tc *core.TimeoutCert) error
+	BroadcastEvidence(ev *core.Evidence) error
+	BroadcastCheckpointVote(vote *core.CheckpointVote) error
 	SendBatch(pubKey *core.PublicKey, batch *core.Batch) error
 	SendVote(pubKey *core.PublicKey, vote *core.Vote) error
 	SendBatchVote(pubKey *core.PublicKey, vote *core.BatchVote) error
 	RequestBlock(pubKey *core.PublicKey, hash []byte) (*core.Block, error)
 	RequestBlockByHeight(pubKey *core.PublicKey, height uint64) (*core.Block, error)
-	SendNewView(pubKey *core.PublicKey, qc *core.QuorumCert) error
+	RequestBatch(pubKey *core.PublicKey, hash []byte) (*core.Batch, error)
+	SendNewView(pubKey *core.PublicKey, nv *core.NewView) error
 	SubscribeBatch(buffer int) *emitter.Subscription
 	SubscribeProposal(buffer int) *emitter.Subscription
 	SubscribeVote(buffer int) *emitter.Subscription
 	SubscribeBatchVote(buffer int) *emitter.Subscription
 	SubscribeNewView(buffer int) *emitter.Subscription
+	SubscribeTimeout(buffer int) *emitter.Subscription
+	SubscribeTimeoutCert(buffer int) *emitter.Subscription
+	SubscribeEvidence(buffer int) *emitter.Subscription
+	SubscribeCheckpointVote(buffer int) *emitter.Subscription
 }
 
 type Execution interface {
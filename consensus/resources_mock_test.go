@@ -44,6 +44,16 @@ func (m *MockTxPool) GetTxsFromQueue(max int) []*core.Transaction {
 	return castTransactions(args.Get(0))
 }
 
+func (m *MockTxPool) PopTxsFromQueueBy(max int, filter txpool.TxFilter) []*core.Transaction {
+	args := m.Called(max, filter)
+	return castTransactions(args.Get(0))
+}
+
+func (m *MockTxPool) GetTxsFromQueueBy(max int, filter txpool.TxFilter) []*core.Transaction {
+	args := m.Called(max, filter)
+	return castTransactions(args.Get(0))
+}
+
 func (m *MockTxPool) SetTxsPending(hashes [][]byte) {
 	m.Called(hashes)
 }
@@ -61,11 +71,6 @@ func (m *MockTxPool) PutTxsToQueue(hashes [][]byte) {
 	m.Called(hashes)
 }
 
-func (m *MockTxPool) SyncTxs(peer *core.PublicKey, hashes [][]byte) error {
-	args := m.Called(peer, hashes)
-	return args.Error(0)
-}
-
 func (m *MockTxPool) GetTx(hash []byte) *core.Transaction {
 	args := m.Called(hash)
 	return castTransaction(args.Get(0))
@@ -107,6 +112,11 @@ func (m *MockStorage) GetLastBlock() (*core.Block, error) {
 	return castBlock(args.Get(0)), args.Error(1)
 }
 
+func (m *MockStorage) GetBlockByHeight(height uint64) (*core.Block, error) {
+	args := m.Called(height)
+	return castBlock(args.Get(0)), args.Error(1)
+}
+
 func (m *MockStorage) GetLastQC() (*core.QuorumCert, error) {
 	args := m.Called()
 	return castQC(args.Get(0)), args.Error(1)
@@ -122,6 +132,94 @@ func (m *MockStorage) HasTx(hash []byte) bool {
 	return args.Bool(0)
 }
 
+func (m *MockStorage) GetSafetyState() (*storage.SafetyState, error) {
+	args := m.Called()
+	ss, _ := args.Get(0).(*storage.SafetyState)
+	return ss, args.Error(1)
+}
+
+func (m *MockStorage) SetSafetyState(ss *storage.SafetyState) error {
+	args := m.Called(ss)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetSafetyView() (uint64, error) {
+	args := m.Called()
+	return uint64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockStorage) SetSafetyView(view uint64) error {
+	args := m.Called(view)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetGovernanceState() (*storage.GovernanceState, error) {
+	args := m.Called()
+	gs, _ := args.Get(0).(*storage.GovernanceState)
+	return gs, args.Error(1)
+}
+
+func (m *MockStorage) SetGovernanceState(gs *storage.GovernanceState) error {
+	args := m.Called(gs)
+	return args.Error(0)
+}
+
+func (m *MockStorage) HasEvidence(ev *core.Evidence) bool {
+	args := m.Called(ev)
+	return args.Bool(0)
+}
+
+func (m *MockStorage) SetEvidence(ev *core.Evidence) error {
+	args := m.Called(ev)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetWALEntries() ([]*storage.WALEntry, error) {
+	args := m.Called()
+	entries, _ := args.Get(0).([]*storage.WALEntry)
+	return entries, args.Error(1)
+}
+
+func (m *MockStorage) GetBatch(hash []byte) (*core.Batch, error) {
+	args := m.Called(hash)
+	return castBatch(args.Get(0)), args.Error(1)
+}
+
+func (m *MockStorage) HasBatch(hash []byte) bool {
+	args := m.Called(hash)
+	return args.Bool(0)
+}
+
+func (m *MockStorage) StoreBatch(batch *core.Batch) error {
+	args := m.Called(batch)
+	return args.Error(0)
+}
+
+func (m *MockStorage) DeleteBatches(hashes [][]byte) error {
+	args := m.Called(hashes)
+	return args.Error(0)
+}
+
+func (m *MockStorage) HasCheckpointCert(height uint64) bool {
+	args := m.Called(height)
+	return args.Bool(0)
+}
+
+func (m *MockStorage) SetCheckpointCert(cert *core.CheckpointCert) error {
+	args := m.Called(cert)
+	return args.Error(0)
+}
+
+func (m *MockStorage) AppendWAL(entry *storage.WALEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockStorage) DeleteWAL(seqs []uint64) error {
+	args := m.Called(seqs)
+	return args.Error(0)
+}
+
 type MockMsgService struct {
 	mock.Mock
 }
@@ -138,8 +236,28 @@ func (m *MockMsgService) BroadcastProposal(blk *core.Block) error {
 	return args.Error(0)
 }
 
-func (m *MockMsgService) BroadcastNewView(qc *core.QuorumCert) error {
-	args := m.Called(qc)
+func (m *MockMsgService) BroadcastNewView(nv *core.NewView) error {
+	args := m.Called(nv)
+	return args.Error(0)
+}
+
+func (m *MockMsgService) BroadcastTimeout(to *core.Timeout) error {
+	args := m.Called(to)
+	return args.Error(0)
+}
+
+func (m *MockMsgService) BroadcastTimeoutCert(tc *core.TimeoutCert) error {
+	args := m.Called(tc)
+	return args.Error(0)
+}
+
+func (m *MockMsgService) BroadcastEvidence(ev *core.Evidence) error {
+	args := m.Called(ev)
+	return args.Error(0)
+}
+
+func (m *MockMsgService) BroadcastCheckpointVote(vote *core.CheckpointVote) error {
+	args := m.Called(vote)
 	return args.Error(0)
 }
 
@@ -168,8 +286,13 @@ func (m *MockMsgService) RequestBlockByHeight(pubKey *core.PublicKey, height uin
 	return castBlock(args.Get(0)), args.Error(1)
 }
 
-func (m *MockMsgService) SendNewView(pubKey *core.PublicKey, qc *core.QuorumCert) error {
-	args := m.Called(pubKey, qc)
+func (m *MockMsgService) RequestBatch(pubKey *core.PublicKey, hash []byte) (*core.Batch, error) {
+	args := m.Called(pubKey, hash)
+	return castBatch(args.Get(0)), args.Error(1)
+}
+
+func (m *MockMsgService) SendNewView(pubKey *core.PublicKey, nv *core.NewView) error {
+	args := m.Called(pubKey, nv)
 	return args.Error(0)
 }
 
@@ -198,6 +321,26 @@ func (m *MockMsgService) SubscribeNewView(buffer int) *emitter.Subscription {
 	return castSubscription(args.Get(0))
 }
 
+func (m *MockMsgService) SubscribeTimeout(buffer int) *emitter.Subscription {
+	args := m.Called(buffer)
+	return castSubscription(args.Get(0))
+}
+
+func (m *MockMsgService) SubscribeTimeoutCert(buffer int) *emitter.Subscription {
+	args := m.Called(buffer)
+	return castSubscription(args.Get(0))
+}
+
+func (m *MockMsgService) SubscribeEvidence(buffer int) *emitter.Subscription {
+	args := m.Called(buffer)
+	return castSubscription(args.Get(0))
+}
+
+func (m *MockMsgService) SubscribeCheckpointVote(buffer int) *emitter.Subscription {
+	args := m.Called(buffer)
+	return castSubscription(args.Get(0))
+}
+
 type MockExecution struct {
 	mock.Mock
 }
@@ -237,6 +380,13 @@ func castBlock(val interface{}) *core.Block {
 	return val.(*core.Block)
 }
 
+func castBatch(val interface{}) *core.Batch {
+	if val == nil {
+		return nil
+	}
+	return val.(*core.Batch)
+}
+
 func castQC(val interface{}) *core.QuorumCert {
 	if val == nil {
 		return nil
@@ -8,19 +8,26 @@ import (
 	"sync"
 	"time"
 
+	"github.com/wooyang2018/ppov-blockchain/core"
 	"github.com/wooyang2018/ppov-blockchain/hotstuff"
 	"github.com/wooyang2018/ppov-blockchain/logger"
 )
 
+// rounds of workers searched for the view of an approved leader
+const maxViewSyncRounds = 16
+
 type rotator struct {
 	resources *Resources
 	config    Config
 
 	state    *state
 	hotstuff *hotstuff.Hotstuff
+	election LeaderElection
+	wal      *consensusWAL
 
-	leaderTimer *time.Timer
-	viewTimer   *time.Timer
+	leaderTimer  *time.Timer
+	viewTimer    *time.Timer
+	newViewTimer *time.Timer
 
 	// start timestamp in second of current view
 	viewStart int64
@@ -30,8 +37,13 @@ type rotator struct {
 	pendingViewChange bool
 	mtxPVC            sync.RWMutex
 
-	leaderTimeoutCount int
-	stopCh             chan struct{}
+	// latest timeout received from each validator
+	timeouts map[string]*core.Timeout
+
+	// latest new view received from each validator
+	newViews map[string]*core.NewView
+
+	stopCh chan struct{}
 }
 
 func (rot *rotator) start() {
@@ -39,6 +51,10 @@ func (rot *rotator) start() {
 		return
 	}
 	rot.stopCh = make(chan struct{})
+	rot.timeouts = make(map[string]*core.Timeout)
+	rot.newViews = make(map[string]*core.NewView)
+	rot.newViewTimer = time.NewTimer(rot.config.NewViewTimeout)
+	rot.newViewTimer.Stop()
 	rot.setViewStart()
 	go rot.run()
 	logger.I().Info("started rotator")
@@ -62,6 +78,16 @@ func (rot *rotator) run() {
 	subQC := rot.hotstuff.SubscribeNewQCHigh()
 	defer subQC.Unsubscribe()
 
+	subTimeout := rot.resources.MsgSvc.SubscribeTimeout(100)
+	defer subTimeout.Unsubscribe()
+
+	subTC := rot.resources.MsgSvc.SubscribeTimeoutCert(10)
+	defer subTC.Unsubscribe()
+
+	subNV := rot.resources.MsgSvc.SubscribeNewView(100)
+	defer subNV.Unsubscribe()
+	defer rot.newViewTimer.Stop()
+
 	rot.viewTimer = time.NewTimer(rot.config.ViewWidth)
 	defer rot.viewTimer.Stop()
 
@@ -79,8 +105,26 @@ func (rot *rotator) run() {
 		case <-rot.leaderTimer.C:
 			rot.onLeaderTimeout()
 
+		case <-rot.newViewTimer.C:
+			rot.onNewViewTimeout()
+
 		case e := <-subQC.Events():
 			rot.onNewQCHigh(e.(hotstuff.QC))
+
+		case e := <-subTimeout.Events():
+			if err := rot.onReceiveTimeout(e.(*core.Timeout)); err != nil {
+				logger.I().Warnw("received timeout failed", "error", err)
+			}
+
+		case e := <-subTC.Events():
+			if err := rot.onReceiveTimeoutCert(e.(*core.TimeoutCert)); err != nil {
+				logger.I().Warnw("received timeout cert failed", "error", err)
+			}
+
+		case e := <-subNV.Events():
+			if err := rot.onReceiveNewView(e.(*core.NewView)); err != nil {
+				logger.I().Warnw("received new view failed", "error", err)
+			}
 		}
 	}
 }
@@ -102,45 +146,199 @@ func (rot *rotator) drainStopTimer(timer *time.Timer) {
 }
 
 func (rot *rotator) onLeaderTimeout() {
-	logger.I().Warnw("leader timeout", "leader", rot.state.getLeaderIndex())
-	rot.leaderTimeoutCount++
-	rot.changeView()
+	logger.I().Warnw("leader timeout", "leader", rot.state.getLeaderIndex(), "view", rot.state.getView())
 	rot.drainStopTimer(rot.viewTimer)
-	if rot.leaderTimeoutCount > rot.state.getFaultyCount() {
-		rot.leaderTimer.Stop()
-		rot.setPendingViewChange(false)
-	} else {
-		rot.leaderTimer.Reset(rot.config.LeaderTimeout)
-	}
+	rot.broadcastTimeout()
+	rot.leaderTimer.Reset(rot.config.LeaderTimeout) // keep sending timeout until a tc is formed
 }
 
 func (rot *rotator) onViewTimeout() {
-	rot.changeView()
+	rot.broadcastTimeout()
+	rot.drainResetTimer(rot.leaderTimer, rot.config.LeaderTimeout)
+}
+
+// broadcastTimeout signs timeout of current view, view is changed only after a tc is formed
+func (rot *rotator) broadcastTimeout() {
+	if !rot.state.isThisNodeVoter() && !rot.state.isThisNodeWorker() {
+		return
+	}
+	to := core.NewTimeout().Sign(rot.state.getView(),
+		rot.hotstuff.GetQCHigh().(*hsQC).qc, rot.resources.Signer)
+	if err := rot.resources.MsgSvc.BroadcastTimeout(to); err != nil {
+		logger.I().Errorw("broadcast timeout failed", "error", err)
+	}
+	if err := rot.onReceiveTimeout(to); err != nil { // own message is not delivered by topic
+		logger.I().Errorw("add own timeout failed", "error", err)
+	}
+}
+
+func (rot *rotator) onReceiveTimeout(to *core.Timeout) error {
+	if to.View() < rot.state.getView() {
+		return nil // stale timeout
+	}
+	if err := to.Validate(rot.resources.VldStore); err != nil {
+		return err
+	}
+	if to.QCHigh() != nil {
+		rot.updateQCHigh(to.QCHigh())
+	}
+	tc := rot.addTimeout(to)
+	if tc == nil {
+		return nil
+	}
+	if err := rot.resources.MsgSvc.BroadcastTimeoutCert(tc); err != nil {
+		logger.I().Errorw("broadcast timeout cert failed", "error", err)
+	}
+	rot.onTimeoutCert(tc)
+	return nil
+}
+
+// updateQCHigh takes the qc high of a timeout or new view if it certifies a known block at its height
+func (rot *rotator) updateQCHigh(qc *core.QuorumCert) {
+	if err := rot.state.verifyQCHeight(qc); err != nil {
+		logger.I().Debugw("ignored qc high", "error", err)
+		return
+	}
+	rot.hotstuff.UpdateQCHigh(newHsQC(qc, rot.state))
+}
+
+// addTimeout returns a new tc when majority validators timeout at the same view
+func (rot *rotator) addTimeout(to *core.Timeout) *core.TimeoutCert {
+	key := to.Sender().String()
+	if last, found := rot.timeouts[key]; found && last.View() >= to.View() {
+		return nil
+	}
+	rot.timeouts[key] = to
+	var power uint64
+	timeouts := make([]*core.Timeout, 0, len(rot.timeouts))
+	for _, t := range rot.timeouts {
+		if t.View() == to.View() {
+			power += rot.resources.VldStore.GetPower(t.Sender())
+			timeouts = append(timeouts, t)
+		}
+	}
+	if power < rot.resources.VldStore.QuorumPower() {
+		return nil
+	}
+	return core.NewTimeoutCert().Build(timeouts)
+}
+
+func (rot *rotator) onReceiveTimeoutCert(tc *core.TimeoutCert) error {
+	if tc.View() < rot.state.getView() {
+		return nil // stale tc
+	}
+	if err := tc.Validate(rot.resources.VldStore); err != nil {
+		return err
+	}
+	rot.onTimeoutCert(tc)
+	return nil
+}
+
+// onTimeoutCert moves this node to the view next to the tc
+func (rot *rotator) onTimeoutCert(tc *core.TimeoutCert) {
+	view := tc.View() + 1
+	for key, to := range rot.timeouts {
+		if to.View() < view {
+			delete(rot.timeouts, key)
+		}
+	}
+	for key, nv := range rot.newViews {
+		if nv.View() < view {
+			delete(rot.newViews, key)
+		}
+	}
+	rot.changeView(view)
+	rot.drainStopTimer(rot.viewTimer)
 	rot.drainResetTimer(rot.leaderTimer, rot.config.LeaderTimeout)
 }
 
-func (rot *rotator) changeView() {
-	leaderIdx := rot.nextLeader()
+func (rot *rotator) changeView(view uint64) {
+	leaderIdx := rot.election.Leader(view, electionHeight(rot.hotstuff.GetQCHigh()))
+	rot.state.setView(view)
+	rot.saveView(view)
 	rot.state.setLeaderIndex(leaderIdx)
 	rot.setPendingViewChange(true)
 	rot.setViewStart()
-	idx := rot.state.getLeaderIndex()
-	leader := rot.resources.VldStore.GetWorker(idx)
-	rot.resources.Host.SetLeader(idx)
-	rot.resources.MsgSvc.SendNewView(leader, rot.hotstuff.GetQCHigh().(*hsQC).qc)
-	logger.I().Infow("view changed",
+	leader := rot.resources.VldStore.GetWorker(leaderIdx)
+	rot.resources.Host.SetLeader(leaderIdx)
+	logger.I().Infow("view changed", "view", view,
 		"leader", leaderIdx, "qc", qcRefHeight(rot.hotstuff.GetQCHigh()))
+	rot.sendNewView(view, leader)
+}
+
+// sendNewView sends qc high to the leader of the view,
+// the new leader proposes after it collects new views from a quorum or the wait times out
+func (rot *rotator) sendNewView(view uint64, leader *core.PublicKey) {
+	rot.state.setWaitingNewViews(false)
+	// with vote forwarding, qc high reaches the new leader by timeouts and forwarded votes
+	if rot.config.VoteForwardFlag {
+		return
+	}
+	if !rot.state.isThisNodeVoter() && !rot.state.isThisNodeWorker() {
+		return
+	}
+	nv := core.NewNewView().Sign(view, rot.hotstuff.GetQCHigh().(*hsQC).qc, rot.resources.Signer)
+	if !rot.state.isThisNodeLeader() {
+		if err := rot.resources.MsgSvc.SendNewView(leader, nv); err != nil {
+			logger.I().Errorw("send new view failed", "error", err)
+		}
+		return
+	}
+	rot.state.setWaitingNewViews(true)
+	rot.drainResetTimer(rot.newViewTimer, rot.config.NewViewTimeout)
+	rot.addNewView(nv) // new views received before this node changed view may already form a quorum
+}
+
+func (rot *rotator) onReceiveNewView(nv *core.NewView) error {
+	if nv.View() < rot.state.getView() {
+		return nil // stale new view
+	}
+	if err := nv.Validate(rot.resources.VldStore); err != nil {
+		return err
+	}
+	rot.updateQCHigh(nv.QCHigh())
+	rot.addNewView(nv)
+	return nil
+}
+
+// addNewView ends the wait of the leader when validators of a quorum sent new views of current view
+func (rot *rotator) addNewView(nv *core.NewView) {
+	key := nv.Sender().String()
+	if last, found := rot.newViews[key]; found && last.View() >= nv.View() {
+		return
+	}
+	rot.newViews[key] = nv
+	if !rot.state.isWaitingNewViews() {
+		return
+	}
+	var power uint64
+	var count int
+	for _, v := range rot.newViews {
+		if v.View() == rot.state.getView() {
+			power += rot.resources.VldStore.GetPower(v.Sender())
+			count++
+		}
+	}
+	if power < rot.resources.VldStore.QuorumPower() {
+		return
+	}
+	rot.state.setWaitingNewViews(false)
+	rot.drainStopTimer(rot.newViewTimer)
+	logger.I().Infow("collected new views", "view", rot.state.getView(),
+		"count", count, "qc", qcRefHeight(rot.hotstuff.GetQCHigh()))
 }
 
-func (rot *rotator) nextLeader() int {
-	leaderIdx := rot.state.getLeaderIndex() + 1
-	if leaderIdx >= rot.resources.VldStore.WorkerCount() {
-		leaderIdx = 0
+func (rot *rotator) onNewViewTimeout() {
+	if !rot.state.isWaitingNewViews() {
+		return
 	}
-	return leaderIdx
+	rot.state.setWaitingNewViews(false)
+	logger.I().Warnw("new view timeout, proposing with current qc", "view", rot.state.getView(),
+		"qc", qcRefHeight(rot.hotstuff.GetQCHigh()))
 }
 
 func (rot *rotator) onNewQCHigh(qc hotstuff.QC) {
+	rot.wal.appendQC(qc.(*hsQC).qc, qcRefHeight(qc))
 	rot.state.setQC(qc.(*hsQC).qc)
 	proposer := rot.resources.VldStore.GetWorkerIndex(qcRefProposer(qc))
 	logger.I().Debugw("updated qc", "proposer", proposer, "qc", qcRefHeight(qc))
@@ -151,7 +349,12 @@ func (rot *rotator) onNewQCHigh(qc hotstuff.QC) {
 	if rot.isNewViewApproval(proposer) {
 		ltreset = true
 		vtreset = true
-		rot.approveViewLeader(proposer)
+		// the proposer was elected on the qc its block carries
+		var height uint64
+		if ref := qc.Block(); ref != nil {
+			height = electionHeight(ref.Justify())
+		}
+		rot.approveViewLeader(proposer, height)
 	}
 	if ltreset {
 		rot.drainResetTimer(rot.leaderTimer, rot.config.LeaderTimeout)
@@ -168,12 +371,37 @@ func (rot *rotator) isNewViewApproval(proposer int) bool {
 		(pending && proposer == leaderIdx) // expecting leader
 }
 
-func (rot *rotator) approveViewLeader(proposer int) {
+func (rot *rotator) approveViewLeader(proposer int, height uint64) {
 	rot.setPendingViewChange(false)
+	rot.syncView(proposer, height)
 	rot.state.setLeaderIndex(proposer)
 	rot.setViewStart()
-	logger.I().Infow("approved leader", "leader", rot.state.getLeaderIndex())
-	rot.leaderTimeoutCount = 0
+	logger.I().Infow("approved leader", "leader", rot.state.getLeaderIndex(), "view", rot.state.getView())
+}
+
+// syncView moves to the first view led by the approved leader at the election height,
+// this node is behind the leader after restart or when it missed tcs
+func (rot *rotator) syncView(proposer int, height uint64) {
+	view := rot.state.getView()
+	limit := view + uint64(maxViewSyncRounds*rot.resources.VldStore.WorkerCount())
+	for v := view; v < limit; v++ {
+		if rot.election.Leader(v, height) != proposer {
+			continue
+		}
+		if v != view {
+			rot.state.setView(v)
+			rot.saveView(v)
+		}
+		return
+	}
+	logger.I().Warnw("no view led by the approved leader", "leader", proposer, "view", view)
+}
+
+// saveView persists the view, so that the node rejoins its view after restart
+func (rot *rotator) saveView(view uint64) {
+	if err := rot.resources.Storage.SetSafetyView(view); err != nil {
+		logger.I().Errorw("save view failed", "view", view, "error", err)
+	}
 }
 
 func (rot *rotator) setViewStart() {
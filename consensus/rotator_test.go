@@ -6,24 +6,38 @@ package consensus
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"github.com/wooyang2018/ppov-blockchain/core"
 	"github.com/wooyang2018/ppov-blockchain/hotstuff"
 )
 
 func setupRotator() (*rotator, *core.Block) {
-	key1 := core.GenerateKey(nil)
-	key2 := core.GenerateKey(nil)
-	keys := []string{
-		key1.PublicKey().String(),
-		key2.PublicKey().String(),
+	rot, b0, _ := setupRotatorWithKeys(2)
+	return rot, b0
+}
+
+func setupRotatorWithKeys(count int) (*rotator, *core.Block, []*core.PrivateKey) {
+	privKeys := make([]*core.PrivateKey, count)
+	keys := make([]string, count)
+	for i := range privKeys {
+		privKeys[i] = core.GenerateKey(nil)
+		keys[i] = privKeys[i].PublicKey().String()
 	}
 
-	resources := &Resources{VldStore: core.NewValidatorStore(keys, keys)}
+	storage := new(MockStorage)
+	storage.On("SetSafetyView", mock.Anything).Return(nil)
+	resources := &Resources{
+		Signer:   privKeys[0],
+		VldStore: core.NewValidatorStore(keys, keys),
+		MsgSvc:   new(MockMsgService),
+		Storage:  storage,
+	}
 
-	b0 := core.NewBlock().Sign(key1)
+	b0 := core.NewBlock().Sign(privKeys[0])
 	q0 := core.NewQuorumCert().Build([]*core.Vote{b0.ProposerVote()})
 	b0.SetQuorumCert(q0)
 
@@ -34,12 +48,18 @@ func setupRotator() (*rotator, *core.Block) {
 		state:     state,
 	}
 	hotstuff := hotstuff.New(hsDriver, nil, newHsBlock(b0, state), newHsQC(q0, state))
+	newViewTimer := time.NewTimer(DefaultConfig.NewViewTimeout)
+	newViewTimer.Stop()
 	return &rotator{
-		resources: resources,
-		config:    DefaultConfig,
-		state:     state,
-		hotstuff:  hotstuff,
-	}, b0
+		resources:    resources,
+		config:       DefaultConfig,
+		state:        state,
+		hotstuff:     hotstuff,
+		election:     &roundRobin{resources: resources},
+		timeouts:     make(map[string]*core.Timeout),
+		newViews:     make(map[string]*core.NewView),
+		newViewTimer: newViewTimer,
+	}, b0, privKeys
 }
 
 func Test_rotator_isNewViewApproval(t *testing.T) {
@@ -75,8 +95,137 @@ func TestRotator_resetViewTimer(t *testing.T) {
 	rot, _ := setupRotator()
 	rot.setPendingViewChange(true)
 
-	rot.approveViewLeader(1)
+	rot.approveViewLeader(1, 1)
 
 	assert.False(rot.getPendingViewChange())
 	assert.EqualValues(rot.state.getLeaderIndex(), 1)
+	assert.EqualValues(1, rot.state.getView(), "view synced to the approved leader")
+}
+
+func Test_rotator_syncView(t *testing.T) {
+	assert := assert.New(t)
+
+	rot, _, _ := setupRotatorWithKeys(4)
+	storage := rot.resources.Storage.(*MockStorage)
+
+	rot.state.setView(5)
+	rot.approveViewLeader(1, 1)
+	assert.EqualValues(5, rot.state.getView(), "leader of current view")
+	storage.AssertNotCalled(t, "SetSafetyView", mock.Anything)
+
+	// restarted node approves the leader of a later view
+	rot.approveViewLeader(3, 1)
+	assert.EqualValues(7, rot.state.getView())
+	assert.EqualValues(3, rot.state.getLeaderIndex())
+	storage.AssertCalled(t, "SetSafetyView", uint64(7))
+}
+
+func Test_rotator_addTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	rot, _, keys := setupRotatorWithKeys(4)
+
+	assert.Nil(rot.addTimeout(core.NewTimeout().Sign(1, nil, keys[0])))
+	assert.Nil(rot.addTimeout(core.NewTimeout().Sign(1, nil, keys[1])))
+	assert.Nil(rot.addTimeout(core.NewTimeout().Sign(1, nil, keys[1])), "duplicate sender")
+	assert.Nil(rot.addTimeout(core.NewTimeout().Sign(0, nil, keys[2])), "different view")
+
+	tc := rot.addTimeout(core.NewTimeout().Sign(1, nil, keys[2]))
+	if assert.NotNil(tc) {
+		assert.EqualValues(1, tc.View())
+		assert.Len(tc.Signatures(), 3)
+		assert.NoError(tc.Validate(rot.resources.VldStore))
+	}
+
+	assert.Nil(rot.addTimeout(core.NewTimeout().Sign(0, nil, keys[3])), "older view of sender")
+}
+
+func Test_rotator_onReceiveTimeoutCert(t *testing.T) {
+	assert := assert.New(t)
+
+	rot, _, keys := setupRotatorWithKeys(4)
+	rot.state.setView(5)
+
+	timeouts := make([]*core.Timeout, 3)
+	for i := range timeouts {
+		timeouts[i] = core.NewTimeout().Sign(4, nil, keys[i])
+	}
+	stale := core.NewTimeoutCert().Build(timeouts)
+	assert.NoError(rot.onReceiveTimeoutCert(stale))
+	assert.EqualValues(5, rot.state.getView())
+
+	for i := range timeouts {
+		timeouts[i] = core.NewTimeout().Sign(6, nil, keys[i])
+	}
+	notEnoughSig := core.NewTimeoutCert().Build(timeouts[:2])
+	assert.Error(rot.onReceiveTimeoutCert(notEnoughSig))
+	assert.EqualValues(5, rot.state.getView())
+}
+
+func Test_rotator_collectNewViews(t *testing.T) {
+	assert := assert.New(t)
+
+	rot, b0, keys := setupRotatorWithKeys(4)
+	q0 := rot.hotstuff.GetQCHigh().(*hsQC).qc
+	b1 := core.NewBlock().SetHeight(1).SetParentHash(b0.Hash()).SetQuorumCert(q0).Sign(keys[1])
+	rot.state.setBlock(b1)
+	q1 := newTestQuorumCert(b1, keys[1:])
+
+	rot.state.setView(4)
+	rot.state.setLeaderIndex(0)
+	rot.sendNewView(4, keys[0].PublicKey())
+	assert.True(rot.state.isWaitingNewViews(), "leader waits for new views")
+
+	assert.NoError(rot.onReceiveNewView(core.NewNewView().Sign(3, q1, keys[1])), "stale view is ignored")
+	assert.True(rot.state.isWaitingNewViews())
+	assert.Equal(q0, rot.hotstuff.GetQCHigh().(*hsQC).qc)
+
+	assert.NoError(rot.onReceiveNewView(core.NewNewView().Sign(4, q1, keys[1])))
+	assert.True(rot.state.isWaitingNewViews(), "two of four validators")
+	assert.Equal(q1, rot.hotstuff.GetQCHigh().(*hsQC).qc, "picks the highest qc")
+
+	assert.NoError(rot.onReceiveNewView(core.NewNewView().Sign(4, q1, keys[1])), "duplicate sender")
+	assert.True(rot.state.isWaitingNewViews())
+
+	assert.NoError(rot.onReceiveNewView(core.NewNewView().Sign(4, newTestQuorumCert(b0, keys[1:]), keys[2])))
+	assert.False(rot.state.isWaitingNewViews(), "new views from a quorum")
+	assert.Equal(q1, rot.hotstuff.GetQCHigh().(*hsQC).qc, "lower qc does not replace qc high")
+}
+
+func Test_rotator_newViewTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	rot, b0, keys := setupRotatorWithKeys(4)
+	q0 := newTestQuorumCert(b0, keys[1:])
+
+	// new view received before the leader changed view
+	assert.NoError(rot.onReceiveNewView(core.NewNewView().Sign(4, q0, keys[1])))
+
+	rot.state.setView(4)
+	rot.state.setLeaderIndex(0)
+	rot.sendNewView(4, keys[0].PublicKey())
+	assert.True(rot.state.isWaitingNewViews())
+
+	rot.onNewViewTimeout()
+	assert.False(rot.state.isWaitingNewViews(), "bounded wait")
+
+	// next view, the leader is another node
+	msgSvc := rot.resources.MsgSvc.(*MockMsgService)
+	msgSvc.On("SendNewView", keys[1].PublicKey(), mock.Anything).Return(nil).Once()
+	rot.state.setView(5)
+	rot.state.setLeaderIndex(1)
+	rot.sendNewView(5, keys[1].PublicKey())
+	assert.False(rot.state.isWaitingNewViews())
+	msgSvc.AssertExpectations(t)
+	nv := msgSvc.Calls[0].Arguments.Get(1).(*core.NewView)
+	assert.EqualValues(5, nv.View())
+	assert.Equal(keys[0].PublicKey(), nv.Sender())
+}
+
+func newTestQuorumCert(blk *core.Block, keys []*core.PrivateKey) *core.QuorumCert {
+	votes := make([]*core.Vote, len(keys))
+	for i, key := range keys {
+		votes[i] = blk.Vote(key)
+	}
+	return core.NewQuorumCert().Build(votes)
 }
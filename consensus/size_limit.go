@@ -0,0 +1,79 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"fmt"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/p2p"
+)
+
+const (
+	batchSizeReserve = 1024 // header fields and signature of a batch
+	blockSizeReserve = 1024 // header fields and signature of a block, without qc
+	qcSignatureSize  = 128  // upper bound of a qc signature
+)
+
+// checkSizeLimits makes sure the limited messages can be sent over p2p
+func checkSizeLimits(config Config) error {
+	if config.TxSizeLimit <= 0 || config.BatchSizeLimit <= 0 || config.BlockSizeLimit <= 0 {
+		return fmt.Errorf("size limits must be positive")
+	}
+	if config.TxSizeLimit+batchSizeReserve > config.BatchSizeLimit {
+		return fmt.Errorf("tx size limit %d too large for batch size limit %d",
+			config.TxSizeLimit, config.BatchSizeLimit)
+	}
+	if config.BatchSizeLimit > int(p2p.MessageSizeLimit) || config.BlockSizeLimit > int(p2p.MessageSizeLimit) {
+		return fmt.Errorf("size limit exceeds p2p message size limit %d", p2p.MessageSizeLimit)
+	}
+	return nil
+}
+
+// checkBatchSize rejects a received batch over the size limit
+func checkBatchSize(batch *core.Batch, sizeLimit int) error {
+	if size := batch.Size(); size > sizeLimit {
+		return fmt.Errorf("batch size %d exceeds limit %d", size, sizeLimit)
+	}
+	return nil
+}
+
+// headersSizeLimit returns the size available to batch headers in a block,
+// the qc of the block is signed by at most all validators
+func headersSizeLimit(blockSizeLimit int, vs core.ValidatorStore) int {
+	return blockSizeLimit - blockSizeReserve - qcSignatureSize*vs.ValidatorCount()
+}
+
+// takeBatchHeaders returns the leading headers within the count and size limits,
+// at least one header is taken to make progress
+func takeBatchHeaders(headers []*core.BatchHeader, countLimit, sizeLimit int) []*core.BatchHeader {
+	res := make([]*core.BatchHeader, 0, countLimit)
+	size := 0
+	for _, header := range headers {
+		if len(res) >= countLimit {
+			break
+		}
+		// tx hashes of a header are also listed in the block
+		size += 2 * header.Size()
+		if sizeLimit > 0 && size > sizeLimit && len(res) > 0 {
+			break
+		}
+		res = append(res, header)
+	}
+	return res
+}
+
+// takeTxsBySize splits the txs to fit in a batch of the size limit,
+// at least one tx is taken to make progress
+func takeTxsBySize(txs []*core.Transaction, sizeLimit int) ([]*core.Transaction, []*core.Transaction) {
+	size := batchSizeReserve
+	for i, tx := range txs {
+		// tx in the batch body and its hash in the header, with field tags and lengths
+		size += tx.Size() + len(tx.Hash()) + 8
+		if size > sizeLimit && i > 0 {
+			return txs[:i], txs[i:]
+		}
+	}
+	return txs, nil
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func TestCheckSizeLimits(t *testing.T) {
+	asrt := assert.New(t)
+
+	asrt.NoError(checkSizeLimits(DefaultConfig))
+
+	config := DefaultConfig
+	config.TxSizeLimit = config.BatchSizeLimit
+	asrt.Error(checkSizeLimits(config), "tx must fit in a batch")
+
+	config = DefaultConfig
+	config.BlockSizeLimit = 200 << 20
+	asrt.Error(checkSizeLimits(config), "block must fit in a p2p message")
+
+	config = DefaultConfig
+	config.BatchSizeLimit = 0
+	asrt.Error(checkSizeLimits(config))
+}
+
+func TestTakeTxsBySize(t *testing.T) {
+	asrt := assert.New(t)
+
+	priv := core.GenerateKey(nil)
+	txs := make([]*core.Transaction, 4)
+	for i := range txs {
+		txs[i] = core.NewTransaction().SetInput(make([]byte, 1000)).SetNonce(int64(i)).Sign(priv)
+	}
+
+	fit, rest := takeTxsBySize(txs, DefaultConfig.BatchSizeLimit)
+	asrt.Equal(txs, fit)
+	asrt.Empty(rest)
+
+	fit, rest = takeTxsBySize(txs, batchSizeReserve+2500)
+	asrt.Equal(txs[:2], fit)
+	asrt.Equal(txs[2:], rest)
+
+	batch := core.NewBatch().SetTransactions(fit).Sign(priv)
+	asrt.LessOrEqual(batch.Size(), batchSizeReserve+2500)
+
+	fit, rest = takeTxsBySize(txs, 10)
+	asrt.Equal(txs[:1], fit, "oversized tx is still batched")
+	asrt.Len(rest, 3)
+}
+
+func TestTakeBatchHeaders(t *testing.T) {
+	asrt := assert.New(t)
+
+	priv := core.GenerateKey(nil)
+	headers := make([]*core.BatchHeader, 4)
+	for i := range headers {
+		hashes := make([][]byte, 30)
+		for j := range hashes {
+			hashes[j] = make([]byte, 32)
+			hashes[j][0] = byte(i)
+		}
+		headers[i] = core.NewBatchHeader().SetTransactions(hashes).Sign(priv)
+	}
+
+	asrt.Equal(headers[:3], takeBatchHeaders(headers, 3, 0))
+	asrt.Equal(headers[:1], takeBatchHeaders(headers, 3, 10), "oversized header is still taken")
+
+	limit := 2*headers[0].Size() + 2*headers[1].Size()
+	res := takeBatchHeaders(headers, 4, limit)
+	asrt.Equal(headers[:2], res)
+
+	var txs [][]byte
+	for _, header := range res {
+		txs = append(txs, header.Transactions()...)
+	}
+	blk := core.NewBlock().SetBatchHeaders(res, false).SetTransactions(txs).Sign(priv)
+	asrt.LessOrEqual(blk.Size(), limit+blockSizeReserve)
+}
@@ -5,6 +5,7 @@
 package consensus
 
 import (
+	"fmt"
 	"sync"
 	"sync/atomic"
 
@@ -27,6 +28,18 @@ type state struct {
 
 	leaderIndex int64
 
+	// leader of the previous view, -1 if leader never changed
+	prevLeaderIndex int64
+
+	// start timestamp of collecting votes forwarded for the block of the previous leader
+	forwardStart int64
+
+	// current view, advanced by timeout certs and synced to the approved leader
+	view uint64
+
+	// 1 while the new leader collects new views before proposing
+	waitingNewViews int32
+
 	// committed block height. on node restart, it's zero until a block is committed
 	committedHeight uint64
 
@@ -39,11 +52,12 @@ type state struct {
 
 func newState(resources *Resources) *state {
 	return &state{
-		resources:   resources,
-		blocks:      make(map[string]*core.Block),
-		committed:   make(map[string]struct{}),
-		qcs:         make(map[string]*core.QuorumCert),
-		leaderState: &leaderState{},
+		resources:       resources,
+		blocks:          make(map[string]*core.Block),
+		committed:       make(map[string]struct{}),
+		qcs:             make(map[string]*core.QuorumCert),
+		leaderState:     &leaderState{},
+		prevLeaderIndex: -1,
 	}
 }
 
@@ -102,6 +116,19 @@ func (state *state) getQC(blkHash []byte) *core.QuorumCert {
 	return state.qcs[string(blkHash)]
 }
 
+// verifyQCHeight makes sure the qc was validated with the validators of the block it certifies,
+// the height is not signed by the voters, so the certified block must be known
+func (state *state) verifyQCHeight(qc *core.QuorumCert) error {
+	ref := state.getBlock(qc.BlockHash())
+	if ref == nil {
+		return fmt.Errorf("unknown certified block %s", base64String(qc.BlockHash()))
+	}
+	if ref.Height() != qc.BlockHeight() {
+		return fmt.Errorf("invalid qc height %d, certified block %d", qc.BlockHeight(), ref.Height())
+	}
+	return nil
+}
+
 func (state *state) deleteQC(blkHash []byte) {
 	state.mtxQCs.Lock()
 	defer state.mtxQCs.Unlock()
@@ -179,13 +206,52 @@ func (state *state) isVoter(pubKey *core.PublicKey) bool {
 }
 
 func (state *state) setLeaderIndex(idx int) {
-	atomic.StoreInt64(&state.leaderIndex, int64(idx))
+	prev := atomic.SwapInt64(&state.leaderIndex, int64(idx))
+	if prev != int64(idx) {
+		atomic.StoreInt64(&state.prevLeaderIndex, prev)
+	}
+}
+
+// isPrevLeader returns true if the node was the leader of the previous view
+func (state *state) isPrevLeader(pubKey *core.PublicKey) bool {
+	if !state.resources.VldStore.IsWorker(pubKey) {
+		return false
+	}
+	return atomic.LoadInt64(&state.prevLeaderIndex) == int64(state.resources.VldStore.GetWorkerIndex(pubKey))
+}
+
+func (state *state) setForwardStart(t int64) {
+	atomic.StoreInt64(&state.forwardStart, t)
+}
+
+func (state *state) getForwardStart() int64 {
+	return atomic.LoadInt64(&state.forwardStart)
+}
+
+func (state *state) setWaitingNewViews(val bool) {
+	var i int32
+	if val {
+		i = 1
+	}
+	atomic.StoreInt32(&state.waitingNewViews, i)
+}
+
+func (state *state) isWaitingNewViews() bool {
+	return atomic.LoadInt32(&state.waitingNewViews) == 1
 }
 
 func (state *state) getLeaderIndex() int {
 	return int(atomic.LoadInt64(&state.leaderIndex))
 }
 
+func (state *state) setView(view uint64) {
+	atomic.StoreUint64(&state.view, view)
+}
+
+func (state *state) getView() uint64 {
+	return atomic.LoadUint64(&state.view)
+}
+
 func (state *state) getFaultyCount() int {
 	return state.resources.VldStore.ValidatorCount() - state.resources.VldStore.MajorityValidatorCount()
 }
@@ -19,6 +19,14 @@ type Status struct {
 	// set to false once the view leader created the first qc
 	PendingViewChange bool
 	LeaderIndex       int
+	View              uint64
+	Epoch             uint64
+
+	// block sync progress of a lagging node (block heights, target is exclusive)
+	Syncing    bool
+	SyncStart  uint64
+	SyncTarget uint64
+	SyncHeight uint64
 
 	// hotstuff state (block heights)
 	BVote  uint64
@@ -0,0 +1,37 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/txpool"
+)
+
+// txOwner returns the index of the worker responsible for batching the tx
+func txOwner(hash []byte, workerCount int) int {
+	if len(hash) < 8 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(hash[:8]) % uint64(workerCount))
+}
+
+// txPartitionFilter accepts the txs of which this node is the responsible worker,
+// after each partition timeout a tx left in the queue is also accepted by the next worker
+func txPartitionFilter(vs core.ValidatorStore, self *core.PublicKey, timeout time.Duration) txpool.TxFilter {
+	count := vs.WorkerCount()
+	widx := vs.GetWorkerIndex(self)
+	return func(tx *core.Transaction, waited time.Duration) bool {
+		if count <= 1 {
+			return true
+		}
+		distance := (widx - txOwner(tx.Hash(), count) + count) % count
+		if distance == 0 {
+			return true
+		}
+		return timeout > 0 && waited >= time.Duration(distance)*timeout
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/txpool"
+)
+
+func TestTxPartitionFilter(t *testing.T) {
+	asrt := assert.New(t)
+
+	privKeys := make([]*core.PrivateKey, 4)
+	keys := make([]string, len(privKeys))
+	for i := range privKeys {
+		privKeys[i] = core.GenerateKey(nil)
+		keys[i] = privKeys[i].PublicKey().String()
+	}
+	vs := core.NewValidatorStore(keys, keys)
+	timeout := time.Second
+
+	txs := make([]*core.Transaction, 40)
+	for i := range txs {
+		txs[i] = core.NewTransaction().SetNonce(int64(i)).Sign(privKeys[0])
+	}
+
+	// each tx is accepted by exactly one worker before timeout
+	filters := make([]txpool.TxFilter, len(privKeys))
+	for i, priv := range privKeys {
+		filters[i] = txPartitionFilter(vs, priv.PublicKey(), timeout)
+	}
+	for _, tx := range txs {
+		owner := txOwner(tx.Hash(), len(privKeys))
+		asrt.Equal(owner, txOwner(tx.Hash(), len(privKeys)))
+		accepted := 0
+		for i, filter := range filters {
+			if filter(tx, 0) {
+				accepted++
+				asrt.Equal(owner, vs.GetWorkerIndex(privKeys[i].PublicKey()))
+			}
+		}
+		asrt.Equal(1, accepted)
+	}
+
+	// failover to the next worker after timeout, all workers after n-1 timeouts
+	tx := txs[0]
+	owner := txOwner(tx.Hash(), len(privKeys))
+	next := (owner + 1) % len(privKeys)
+	prev := (owner + len(privKeys) - 1) % len(privKeys)
+	asrt.False(filters[next](tx, timeout/2))
+	asrt.True(filters[next](tx, timeout))
+	asrt.False(filters[prev](tx, timeout))
+	for _, filter := range filters {
+		asrt.True(filter(tx, 3*timeout))
+	}
+
+	// single worker accepts all txs
+	single := core.NewValidatorStore(keys[:1], keys[:1])
+	asrt.True(txPartitionFilter(single, privKeys[0].PublicKey(), timeout)(tx, 0))
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/wooyang2018/ppov-blockchain/core"
 	"github.com/wooyang2018/ppov-blockchain/hotstuff"
@@ -17,11 +18,17 @@ import (
 
 type validator struct {
 	resources *Resources
+	config    Config
 	state     *state
 	hotstuff  *hotstuff.Hotstuff
 
 	voterState  *voterState
 	leaderState *leaderState
+	evidence    *evidencePool
+	syncer      *blockSyncer
+	wal         *consensusWAL
+	checkpoints *checkpointPool // nil if checkpoints are disabled
+	earlyVotes  *voteBuffer     // forwarded votes received before the proposal
 
 	mtxProposal sync.Mutex
 	stopCh      chan struct{}
@@ -33,12 +40,15 @@ func (vld *validator) start() {
 	}
 	vld.stopCh = make(chan struct{})
 	go vld.batchLoop()
-	if VoteBatchFlag {
+	if vld.config.VoteBatchFlag {
 		go vld.batchVoteLoop()
 	}
 	go vld.proposalLoop()
 	go vld.voteLoop()
-	go vld.newViewLoop()
+	go vld.evidenceLoop()
+	if vld.checkpoints != nil {
+		go vld.checkpointLoop()
+	}
 	logger.I().Info("started validator")
 }
 
@@ -124,8 +134,8 @@ func (vld *validator) batchVoteLoop() {
 	}
 }
 
-func (vld *validator) newViewLoop() {
-	sub := vld.resources.MsgSvc.SubscribeNewView(10)
+func (vld *validator) evidenceLoop() {
+	sub := vld.resources.MsgSvc.SubscribeEvidence(10)
 	defer sub.Unsubscribe()
 
 	for {
@@ -134,18 +144,46 @@ func (vld *validator) newViewLoop() {
 			return
 
 		case e := <-sub.Events():
-			if err := vld.onReceiveNewView(e.(*core.QuorumCert)); err != nil {
-				logger.I().Warnf("received new view failed, %+v", err)
+			if err := vld.onReceiveEvidence(e.(*core.Evidence)); err != nil {
+				logger.I().Warnf("received evidence failed, %+v", err)
+			}
+		}
+	}
+}
+
+func (vld *validator) checkpointLoop() {
+	sub := vld.resources.MsgSvc.SubscribeCheckpointVote(100)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-vld.stopCh:
+			return
+
+		case e := <-sub.Events():
+			if err := vld.checkpoints.onReceiveVote(e.(*core.CheckpointVote)); err != nil {
+				logger.I().Warnf("received checkpoint vote failed, %+v", err)
 			}
 		}
 	}
 }
 
 func (vld *validator) onReceiveBatch(batch *core.Batch) error {
+	if err := checkBatchSize(batch, vld.config.BatchSizeLimit); err != nil {
+		return err
+	}
 	if err := batch.Header().Validate(vld.resources.VldStore); err != nil {
 		return err
 	}
-	if !PreserveTxFlag {
+	vld.wal.appendBatch(batch)
+	return vld.processBatch(batch)
+}
+
+func (vld *validator) processBatch(batch *core.Batch) error {
+	if err := vld.resources.Storage.StoreBatch(batch); err != nil {
+		return err
+	}
+	if !vld.config.PreserveTxFlag {
 		if err := vld.resources.TxPool.StorePendingTxs(batch.TxList()); err != nil {
 			return err
 		}
@@ -154,7 +192,7 @@ func (vld *validator) onReceiveBatch(batch *core.Batch) error {
 	txs := len(batch.Header().Transactions())
 	logger.I().Debugw("received batch", "worker", widx, "txs", txs)
 
-	if VoteBatchFlag {
+	if vld.config.VoteBatchFlag {
 		if vld.state.isThisNodeVoter() {
 			vld.voterState.addBatch(batch.Header(), widx, txs)
 			if vld.voterState.hasEnoughBatch() {
@@ -181,9 +219,16 @@ func (vld *validator) onReceiveBatch(batch *core.Batch) error {
 func (vld *validator) onReceiveProposal(proposal *core.Block) error {
 	vld.mtxProposal.Lock()
 	defer vld.mtxProposal.Unlock()
+	if size := proposal.Size(); size > vld.config.BlockSizeLimit {
+		return fmt.Errorf("proposal size %d exceeds limit %d", size, vld.config.BlockSizeLimit)
+	}
 	if err := proposal.Validate(vld.resources.VldStore); err != nil {
 		return err
 	}
+	if ev := vld.evidence.checkProposal(proposal); ev != nil {
+		vld.reportEvidence(ev)
+	}
+	vld.wal.appendProposal(proposal)
 	pidx := vld.resources.VldStore.GetWorkerIndex(proposal.Proposer())
 	logger.I().Debugw("received proposal", "proposer", pidx, "height", proposal.Height(), "txs", len(proposal.Transactions()))
 	parent, err := vld.getParentBlock(proposal)
@@ -224,33 +269,17 @@ func (vld *validator) syncMissingCommittedBlocks(proposal *core.Block) error {
 			return err
 		}
 	}
+	if qcRef.Height() != proposal.QuorumCert().BlockHeight() {
+		return fmt.Errorf("invalid qc height %d, certified block %d",
+			proposal.QuorumCert().BlockHeight(), qcRef.Height())
+	}
 	if qcRef.Height() < commitHeight {
 		return fmt.Errorf("old qc ref %d", qcRef.Height())
 	}
-	return vld.syncForwardCommittedBlocks(
+	return vld.syncer.syncForward(
 		proposal.Proposer(), commitHeight+1, proposal.ExecHeight())
 }
 
-func (vld *validator) syncForwardCommittedBlocks(peer *core.PublicKey, start, end uint64) error {
-	var blk *core.Block
-	for height := start; height < end; height++ { // end is exclusive
-		var err error
-		blk, err = vld.requestBlockByHeight(peer, height)
-		if err != nil {
-			return err
-		}
-		parent := vld.state.getBlock(blk.ParentHash())
-		if parent == nil {
-			return fmt.Errorf("cannot connect chain, parent not found")
-		}
-		err = vld.verifyWithParentAndUpdateHotstuff(peer, blk, parent, false)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 func (vld *validator) syncMissingParentBlocksRecursive(
 	peer *core.PublicKey, blk *core.Block,
 ) (*core.Block, error) {
@@ -284,17 +313,6 @@ func (vld *validator) requestBlock(peer *core.PublicKey, hash []byte) (*core.Blo
 	return blk, nil
 }
 
-func (vld *validator) requestBlockByHeight(peer *core.PublicKey, height uint64) (*core.Block, error) {
-	blk, err := vld.resources.MsgSvc.RequestBlockByHeight(peer, height)
-	if err != nil {
-		return nil, fmt.Errorf("cannot get block by height %d, %w", height, err)
-	}
-	if err := blk.Validate(vld.resources.VldStore); err != nil {
-		return nil, fmt.Errorf("validate block error %w", err)
-	}
-	return blk, nil
-}
-
 func (vld *validator) verifyWithParentAndUpdateHotstuff(
 	peer *core.PublicKey, blk, parent *core.Block, voting bool,
 ) error {
@@ -302,9 +320,12 @@ func (vld *validator) verifyWithParentAndUpdateHotstuff(
 		return fmt.Errorf("invalid block height %d, parent %d",
 			blk.Height(), parent.Height())
 	}
-	if ExecuteTxFlag {
-		// must sync transactions before updating block to hotstuff
-		if err := vld.resources.TxPool.SyncTxs(peer, blk.Transactions()); err != nil {
+	if err := vld.state.verifyQCHeight(blk.QuorumCert()); err != nil {
+		return err
+	}
+	if vld.config.ExecuteTxFlag {
+		// must fetch batches of the transactions before updating block to hotstuff
+		if err := fetchMissingBatches(vld.resources, vld.config.BatchSizeLimit, peer, blk.BatchHeaders()); err != nil {
 			return err
 		}
 	}
@@ -324,15 +345,42 @@ func (vld *validator) updateHotstuff(blk *core.Block, voting bool) error {
 		vld.hotstuff.Update(newHsBlock(blk, vld.state))
 		return err
 	}
+	if vld.isForwardedProposal(blk) && vld.state.isThisNodeLeader() {
+		// votes for the block of the previous leader are forwarded to me
+		vld.collectForwardedVotes(blk)
+	}
 	vld.hotstuff.OnReceiveProposal(newHsBlock(blk, vld.state))
 	return nil
 }
 
+// collectForwardedVotes starts collecting votes for the block with the votes received before it
+func (vld *validator) collectForwardedVotes(blk *core.Block) {
+	votes, ok := vld.earlyVotes.startCollecting(blk.Hash(), func() bool {
+		return vld.hotstuff.CollectVotes(newHsBlock(blk, vld.state))
+	})
+	if ok {
+		vld.state.setForwardStart(time.Now().UnixNano())
+		vld.replayEarlyVotes(votes, blk)
+	}
+}
+
+// isForwardedProposal returns true if the proposal is from the previous leader and
+// its votes go to the current leader in vote forwarding mode
+func (vld *validator) isForwardedProposal(proposal *core.Block) bool {
+	return vld.config.VoteForwardFlag &&
+		!vld.state.isLeader(proposal.Proposer()) && vld.state.isPrevLeader(proposal.Proposer())
+}
+
 func (vld *validator) verifyProposalToVote(proposal *core.Block) error {
-	if !vld.state.isLeader(proposal.Proposer()) {
+	if !vld.state.isLeader(proposal.Proposer()) && !vld.isForwardedProposal(proposal) {
 		pidx := vld.resources.VldStore.GetWorkerIndex(proposal.Proposer())
 		return fmt.Errorf("proposer %d is not leader", pidx)
 	}
+	if vld.config.VoteBatchFlag {
+		if err := vld.verifyBatchQuorumCerts(proposal); err != nil {
+			return err
+		}
+	}
 	// on node restart, not committed any blocks yet, don't check merkle root
 	if vld.state.getCommittedHeight() != 0 {
 		if err := vld.verifyMerkleRoot(proposal); err != nil {
@@ -342,12 +390,31 @@ func (vld *validator) verifyProposalToVote(proposal *core.Block) error {
 	return vld.verifyProposalTxs(proposal)
 }
 
+// verifyBatchQuorumCerts makes sure a voter quorum attested every batch in the proposal,
+// otherwise the leader could pack batches that voters never received
+func (vld *validator) verifyBatchQuorumCerts(proposal *core.Block) error {
+	vs := vld.resources.VldStore.AtHeight(proposal.Height())
+	for _, header := range proposal.BatchHeaders() {
+		qc := header.BatchQuorumCert()
+		if qc == nil {
+			return fmt.Errorf("batch %s, %w", base64String(header.Hash()), core.ErrMissingBatchQC)
+		}
+		if !bytes.Equal(qc.BatchHash(), header.Hash()) {
+			return fmt.Errorf("batch %s, %w", base64String(header.Hash()), core.ErrUnmatchedBatchQC)
+		}
+		if err := qc.Validate(vs); err != nil {
+			return fmt.Errorf("batch %s, %w", base64String(header.Hash()), err)
+		}
+	}
+	return nil
+}
+
 func (vld *validator) verifyMerkleRoot(proposal *core.Block) error {
 	bh := vld.resources.Storage.GetBlockHeight()
 	if bh != proposal.ExecHeight() {
 		return fmt.Errorf("invalid exec height")
 	}
-	if ExecuteTxFlag {
+	if vld.config.ExecuteTxFlag {
 		mr := vld.resources.Storage.GetMerkleRoot()
 		if !bytes.Equal(mr, proposal.MerkleRoot()) {
 			return fmt.Errorf("invalid merkle root")
@@ -357,7 +424,7 @@ func (vld *validator) verifyMerkleRoot(proposal *core.Block) error {
 }
 
 func (vld *validator) verifyProposalTxs(proposal *core.Block) error {
-	if ExecuteTxFlag {
+	if vld.config.ExecuteTxFlag {
 		for _, hash := range proposal.Transactions() {
 			if vld.resources.Storage.HasTx(hash) {
 				return fmt.Errorf("already committed tx: %s", base64String(hash))
@@ -375,17 +442,58 @@ func (vld *validator) verifyProposalTxs(proposal *core.Block) error {
 }
 
 func (vld *validator) onReceiveVote(vote *core.Vote) error {
-	if err := vote.Validate(vld.resources.VldStore); err != nil {
+	blk := vld.state.getBlock(vote.BlockHash())
+	vs := vld.resources.VldStore
+	if blk != nil {
+		vs = vs.AtHeight(blk.Height()) // voters of the block at an epoch boundary
+	}
+	if blk != nil && vote.BlockHeight() != blk.Height() {
+		return fmt.Errorf("invalid vote height %d, block %d", vote.BlockHeight(), blk.Height())
+	}
+	if err := vote.Validate(vs); err != nil {
 		return err
 	}
+	if blk != nil {
+		if ev := vld.evidence.checkVote(vote, blk); ev != nil {
+			vld.reportEvidence(ev)
+		}
+	}
+	if vld.config.VoteForwardFlag && vld.earlyVotes.addUnlessCollecting(vote, func() bool {
+		return blk != nil && vld.hotstuff.IsCollecting(newHsBlock(blk, vld.state))
+	}) {
+		return nil // replayed when the forwarded proposal is received
+	}
 	vld.hotstuff.OnReceiveVote(newHsVote(vote, vld.state))
 	return nil
 }
 
+// replayEarlyVotes validates the buffered votes again with the voters of the block
+func (vld *validator) replayEarlyVotes(votes []*core.Vote, blk *core.Block) {
+	vs := vld.resources.VldStore.AtHeight(blk.Height())
+	for _, vote := range votes {
+		if vote.BlockHeight() != blk.Height() {
+			logger.I().Warnw("replay early vote failed", "height", blk.Height(), "voteHeight", vote.BlockHeight())
+			continue
+		}
+		if err := vote.Validate(vs); err != nil {
+			logger.I().Warnw("replay early vote failed", "height", blk.Height(), "error", err)
+			continue
+		}
+		if ev := vld.evidence.checkVote(vote, blk); ev != nil {
+			vld.reportEvidence(ev)
+		}
+		vld.hotstuff.OnReceiveVote(newHsVote(vote, vld.state))
+	}
+}
+
 func (vld *validator) onReceiveBatchVote(vote *core.BatchVote) error {
 	if err := vote.Validate(vld.resources.VldStore); err != nil {
 		return err
 	}
+	if ev := vld.evidence.checkBatchVote(vote); ev != nil {
+		vld.reportEvidence(ev)
+	}
+	vld.wal.appendBatchVote(vote)
 	if vld.state.isThisNodeLeader() {
 		vld.leaderState.addBatchVote(vote)
 		pidx := vld.resources.VldStore.GetVoterIndex(vote.Voter())
@@ -394,12 +502,30 @@ func (vld *validator) onReceiveBatchVote(vote *core.BatchVote) error {
 	return nil
 }
 
-func (vld *validator) onReceiveNewView(qc *core.QuorumCert) error {
-	if err := qc.Validate(vld.resources.VldStore); err != nil {
+func (vld *validator) onReceiveEvidence(ev *core.Evidence) error {
+	if vld.resources.Storage.HasEvidence(ev) {
+		return nil // only stored evidence is checked, so unverified evidence can't shadow a valid one
+	}
+	if err := ev.Validate(vld.resources.VldStore); err != nil {
 		return err
 	}
-	vld.hotstuff.UpdateQCHigh(newHsQC(qc, vld.state))
-	return nil
+	logger.I().Warnw("received evidence", "type", ev.Type().String(), "offender", ev.Offender().String())
+	return vld.resources.Storage.SetEvidence(ev)
+}
+
+// reportEvidence persists the locally detected evidence and gossips it
+func (vld *validator) reportEvidence(ev *core.Evidence) {
+	if vld.resources.Storage.HasEvidence(ev) {
+		return
+	}
+	logger.I().Warnw("detected equivocation", "type", ev.Type().String(), "offender", ev.Offender().String())
+	if err := vld.resources.Storage.SetEvidence(ev); err != nil {
+		logger.I().Errorw("save evidence failed", "error", err)
+		return
+	}
+	if err := vld.resources.MsgSvc.BroadcastEvidence(ev); err != nil {
+		logger.I().Errorw("broadcast evidence failed", "error", err)
+	}
 }
 
 func base64String(b []byte) string {
@@ -5,14 +5,27 @@
 package consensus
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/hotstuff"
+	"github.com/wooyang2018/ppov-blockchain/pb"
 )
 
 func TestValidator_verifyProposalToVote(t *testing.T) {
+	for _, mode := range testModes() {
+		t.Run(mode.name, func(t *testing.T) {
+			testValidatorVerifyProposalToVote(t, mode.config)
+		})
+	}
+}
+
+func testValidatorVerifyProposalToVote(t *testing.T, config Config) {
 	priv0 := core.GenerateKey(nil)
 	priv1 := core.GenerateKey(nil)
 	keys := []string{
@@ -49,6 +62,18 @@ func TestValidator_verifyProposalToVote(t *testing.T) {
 	header2 := core.NewBatch().Header().SetTransactions([][]byte{tx1.Hash(), tx2.Hash(), tx4.Hash()}).Sign(priv0)
 	header3 := core.NewBatch().Header().SetTransactions([][]byte{tx1.Hash(), tx3.Hash(), tx4.Hash()}).Sign(priv0)
 	header4 := core.NewBatch().Header().SetTransactions([][]byte{tx1.Hash(), tx5.Hash(), tx4.Hash()}).Sign(priv0)
+	for _, header := range []*core.BatchHeader{header1, header2, header3, header4} {
+		header.SetBatchQuorumCert(newTestBatchQC(header.Hash(), priv0, priv1))
+	}
+	// batch headers with bad availability certificates
+	noQC := core.NewBatch().Header().SetTransactions([][]byte{tx1.Hash()}).Sign(priv0)
+	weakQC := core.NewBatch().Header().SetTransactions([][]byte{tx4.Hash()}).Sign(priv0)
+	weakQC.SetBatchQuorumCert(newTestBatchQC(weakQC.Hash(), priv0))
+	forgedQC := core.NewBatch().Header().SetTransactions([][]byte{tx1.Hash(), tx4.Hash()}).SetTimestamp(1).Sign(priv0)
+	forgedQC.SetBatchQuorumCert(newTestBatchQC(forgedQC.Hash(), priv0, core.GenerateKey(nil)))
+	copiedQC := newTestBatchHeaderWithQC(
+		core.NewBatch().Header().SetTransactions([][]byte{tx4.Hash(), tx1.Hash()}).Sign(priv0),
+		header1.BatchQuorumCert())
 
 	mStrg.On("HasTx", tx1.Hash()).Return(false)
 	mStrg.On("HasTx", tx2.Hash()).Return(true)
@@ -63,6 +88,7 @@ func TestValidator_verifyProposalToVote(t *testing.T) {
 
 	vld := &validator{
 		resources: resources,
+		config:    config,
 		state:     newState(resources),
 	}
 	vld.state.committedHeight = mStrg.GetBlockHeight()
@@ -89,7 +115,7 @@ func TestValidator_verifyProposalToVote(t *testing.T) {
 			Sign(priv1),
 		},
 	}
-	if ExecuteTxFlag {
+	if config.ExecuteTxFlag {
 		tests = append(tests, []testCase{
 			{"different merkle root", false, core.NewBlock().
 				SetHeight(14).SetExecHeight(10).SetMerkleRoot([]byte("different")).
@@ -113,6 +139,20 @@ func TestValidator_verifyProposalToVote(t *testing.T) {
 			},
 		}...)
 	}
+	if config.VoteBatchFlag {
+		for name, header := range map[string]*core.BatchHeader{
+			"missing batch qc":     noQC,
+			"underweight batch qc": weakQC,
+			"forged batch qc":      forgedQC,
+			"copied batch qc":      copiedQC,
+		} {
+			tests = append(tests, testCase{name, false, core.NewBlock().
+				SetHeight(14).SetExecHeight(10).SetMerkleRoot(mRoot).
+				SetBatchHeaders([]*core.BatchHeader{header1, header}, true).
+				Sign(priv1),
+			})
+		}
+	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			assert := assert.New(t)
@@ -124,3 +164,141 @@ func TestValidator_verifyProposalToVote(t *testing.T) {
 		})
 	}
 }
+
+func newTestBatchQC(hash []byte, signers ...core.Signer) *core.BatchQuorumCert {
+	sigs := make([]*core.Signature, len(signers))
+	for i, signer := range signers {
+		sigs[i] = signer.Sign(hash)
+	}
+	return core.NewBatchQuorumCert().Build(hash, sigs)
+}
+
+// newTestBatchHeaderWithQC attaches qc without the hash check of SetBatchQuorumCert,
+// like a header received from a malicious leader
+func newTestBatchHeaderWithQC(header *core.BatchHeader, qc *core.BatchQuorumCert) *core.BatchHeader {
+	b, _ := header.Marshal()
+	data := new(pb.BatchHeader)
+	proto.Unmarshal(b, data)
+	b, _ = qc.Marshal()
+	data.BatchQuorumCert = new(pb.BatchQuorumCert)
+	proto.Unmarshal(b, data.BatchQuorumCert)
+	b, _ = proto.Marshal(data)
+	ret := core.NewBatchHeader()
+	ret.Unmarshal(b)
+	return ret
+}
+
+func TestValidator_verifyForwardedProposal(t *testing.T) {
+	asrt := assert.New(t)
+	priv0 := core.GenerateKey(nil)
+	priv1 := core.GenerateKey(nil)
+	keys := []string{priv0.PublicKey().String(), priv1.PublicKey().String()}
+	resources := &Resources{
+		Signer:   priv1,
+		VldStore: core.NewValidatorStore(keys, keys),
+	}
+	vld := &validator{
+		resources: resources,
+		config:    DefaultConfig,
+		state:     newState(resources),
+	}
+	vld.state.setLeaderIndex(0)
+	vld.state.setLeaderIndex(1) // view changed
+	proposal := core.NewBlock().SetHeight(5).Sign(priv0)
+
+	asrt.False(vld.isForwardedProposal(proposal))
+	vld.config.VoteForwardFlag = true
+	asrt.True(vld.isForwardedProposal(proposal), "votes of the previous leader's block are forwarded")
+	asrt.False(vld.isForwardedProposal(core.NewBlock().SetHeight(5).Sign(priv1)), "current leader")
+}
+
+func TestValidator_voteBeforeForwardedProposal(t *testing.T) {
+	asrt := assert.New(t)
+	privs := []*core.PrivateKey{core.GenerateKey(nil), core.GenerateKey(nil),
+		core.GenerateKey(nil), core.GenerateKey(nil)}
+	keys := make([]string, len(privs))
+	for i, priv := range privs {
+		keys[i] = priv.PublicKey().String()
+	}
+	storage := new(MockStorage)
+	storage.On("GetBlock", mock.Anything).Return(nil, errors.New("not found"))
+	resources := &Resources{
+		Signer:   privs[1],
+		VldStore: core.NewValidatorStore(keys, keys),
+		Storage:  storage,
+	}
+	config := DefaultConfig
+	config.VoteForwardFlag = true
+	state := newState(resources)
+	b0 := core.NewBlock().Sign(privs[0])
+	q0 := core.NewQuorumCert().Build([]*core.Vote{b0.ProposerVote()})
+	state.setBlock(b0)
+	hs := hotstuff.New(&hsDriver{resources: resources, config: config, state: state}, nil,
+		newHsBlock(b0, state), newHsQC(q0, state))
+	vld := &validator{
+		resources:  resources,
+		config:     config,
+		state:      state,
+		hotstuff:   hs,
+		evidence:   newEvidencePool(),
+		earlyVotes: newVoteBuffer(),
+	}
+
+	// votes are forwarded to the next leader before it receives the proposal
+	blk := core.NewBlock().SetHeight(1).SetParentHash(b0.Hash()).SetQuorumCert(q0).Sign(privs[0])
+	asrt.NoError(vld.onReceiveVote(blk.Vote(privs[2])))
+	asrt.NoError(vld.onReceiveVote(blk.Vote(privs[3])))
+	asrt.Equal(0, hs.GetVoteCount())
+
+	state.setBlock(blk)
+	vld.collectForwardedVotes(blk)
+	asrt.Equal(2, hs.GetVoteCount(), "early votes are replayed")
+
+	asrt.NoError(vld.onReceiveVote(blk.ProposerVote()))
+	asrt.EqualValues(1, qcRefHeight(hs.GetQCHigh()), "quorum reached with early votes")
+}
+
+func TestValidator_verifyQCHeight(t *testing.T) {
+	asrt := assert.New(t)
+	priv := core.GenerateKey(nil)
+	keys := []string{priv.PublicKey().String()}
+	storage := new(MockStorage)
+	storage.On("GetBlock", mock.Anything).Return(nil, errors.New("not found"))
+	resources := &Resources{
+		Signer:   priv,
+		VldStore: core.NewValidatorStore(keys, keys),
+		Storage:  storage,
+	}
+	vld := &validator{
+		resources: resources,
+		config:    DefaultConfig,
+		state:     newState(resources),
+		evidence:  newEvidencePool(),
+	}
+	blk := core.NewBlock().SetHeight(4).Sign(priv)
+	qc := core.NewQuorumCert().Build([]*core.Vote{blk.ProposerVote()})
+
+	asrt.Error(vld.state.verifyQCHeight(qc), "unknown certified block")
+	vld.state.setBlock(blk)
+	asrt.NoError(vld.state.verifyQCHeight(qc))
+
+	// the relayer rewrites the unsigned height
+	data := new(pb.QuorumCert)
+	b, _ := qc.Marshal()
+	asrt.NoError(proto.Unmarshal(b, data))
+	data.BlockHeight = 3
+	b, _ = proto.Marshal(data)
+	rewritten := core.NewQuorumCert()
+	asrt.NoError(rewritten.Unmarshal(b))
+	asrt.NoError(rewritten.Validate(resources.VldStore), "the height is not signed")
+	asrt.Error(vld.state.verifyQCHeight(rewritten))
+
+	vote := new(pb.Vote)
+	b, _ = blk.ProposerVote().Marshal()
+	asrt.NoError(proto.Unmarshal(b, vote))
+	vote.BlockHeight = 3
+	b, _ = proto.Marshal(vote)
+	rewrittenVote := core.NewVote()
+	asrt.NoError(rewrittenVote.Unmarshal(b))
+	asrt.ErrorContains(vld.onReceiveVote(rewrittenVote), "invalid vote height")
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"sync"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+// blocks whose early votes are kept, the oldest block is dropped first
+const voteBufferBlocks = 8
+
+// voteBuffer keeps the forwarded votes which arrive before the next leader
+// starts collecting votes for the block, keyed by block hash
+type voteBuffer struct {
+	votes map[string]map[string]*core.Vote // block hash to voter to vote
+	order []string
+	mtx   sync.Mutex
+}
+
+func newVoteBuffer() *voteBuffer {
+	return &voteBuffer{
+		votes: make(map[string]map[string]*core.Vote),
+	}
+}
+
+// addUnlessCollecting buffers the vote if collecting returns false,
+// the check and the insertion are atomic with startCollecting
+func (buf *voteBuffer) addUnlessCollecting(vote *core.Vote, collecting func() bool) bool {
+	if buf == nil {
+		return false
+	}
+	buf.mtx.Lock()
+	defer buf.mtx.Unlock()
+
+	if collecting() {
+		return false
+	}
+	key := string(vote.BlockHash())
+	if _, found := buf.votes[key]; !found {
+		if len(buf.order) == voteBufferBlocks {
+			delete(buf.votes, buf.order[0])
+			buf.order = buf.order[1:]
+		}
+		buf.votes[key] = make(map[string]*core.Vote)
+		buf.order = append(buf.order, key)
+	}
+	buf.votes[key][vote.Voter().String()] = vote
+	return true
+}
+
+// startCollecting calls collect and returns the buffered votes of the block if it starts collecting
+func (buf *voteBuffer) startCollecting(hash []byte, collect func() bool) ([]*core.Vote, bool) {
+	if buf == nil {
+		return nil, collect()
+	}
+	buf.mtx.Lock()
+	defer buf.mtx.Unlock()
+
+	if !collect() {
+		return nil, false
+	}
+	key := string(hash)
+	votes := make([]*core.Vote, 0, len(buf.votes[key]))
+	for _, vote := range buf.votes[key] {
+		votes = append(votes, vote)
+	}
+	delete(buf.votes, key)
+	for i, k := range buf.order {
+		if k == key {
+			buf.order = append(buf.order[:i], buf.order[i+1:]...)
+			break
+		}
+	}
+	return votes, true
+}
@@ -0,0 +1,272 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/logger"
+	"github.com/wooyang2018/ppov-blockchain/storage"
+)
+
+// entry types of the consensus write-ahead log
+const (
+	walProposal byte = iota + 1
+	walQC
+	walBatch
+	walBatchVote
+)
+
+// committed blocks to keep the batches which are not packed into a committed block
+const walBatchWindow = 20
+
+type walMeta struct {
+	typ     byte
+	height  uint64
+	batches map[string]struct{} // batch hashes not committed yet
+}
+
+// consensusWAL records received consensus messages before they are processed,
+// entries are dropped once the referenced blocks or batches are committed
+type consensusWAL struct {
+	storage Storage
+	seq     uint64
+	entries map[uint64]*walMeta
+	mtx     sync.Mutex
+}
+
+func newConsensusWAL(storage Storage) *consensusWAL {
+	return &consensusWAL{
+		storage: storage,
+		entries: make(map[uint64]*walMeta),
+	}
+}
+
+// load returns the entries recorded before restart in order
+func (wal *consensusWAL) load() []*storage.WALEntry {
+	wal.mtx.Lock()
+	defer wal.mtx.Unlock()
+
+	entries, err := wal.storage.GetWALEntries()
+	if err != nil {
+		logger.I().Errorw("load consensus wal failed", "error", err)
+		return nil
+	}
+	for _, e := range entries {
+		meta := &walMeta{typ: e.Type, height: e.Height}
+		switch e.Type {
+		case walBatch:
+			batch := core.NewBatch()
+			if err := batch.Unmarshal(e.Data); err == nil {
+				meta.batches = batchHashSet([]*core.BatchHeader{batch.Header()})
+			}
+		case walBatchVote:
+			vote := core.NewBatchVote()
+			if err := vote.Unmarshal(e.Data); err == nil {
+				meta.batches = batchHashSet(vote.BatchHeaders())
+			}
+		}
+		wal.entries[e.Seq] = meta
+		wal.seq = e.Seq
+	}
+	return entries
+}
+
+func batchHashSet(headers []*core.BatchHeader) map[string]struct{} {
+	ret := make(map[string]struct{}, len(headers))
+	for _, header := range headers {
+		ret[string(header.Hash())] = struct{}{}
+	}
+	return ret
+}
+
+func (wal *consensusWAL) appendProposal(blk *core.Block) {
+	if wal == nil {
+		return
+	}
+	data, err := blk.Marshal()
+	wal.append(&walMeta{typ: walProposal, height: blk.Height()}, data, err)
+}
+
+func (wal *consensusWAL) appendQC(qc *core.QuorumCert, height uint64) {
+	if wal == nil {
+		return
+	}
+	data, err := qc.Marshal()
+	wal.append(&walMeta{typ: walQC, height: height}, data, err)
+}
+
+func (wal *consensusWAL) appendBatch(batch *core.Batch) {
+	if wal == nil {
+		return
+	}
+	data, err := batch.Marshal()
+	wal.append(&walMeta{
+		typ:     walBatch,
+		height:  wal.storage.GetBlockHeight(),
+		batches: batchHashSet([]*core.BatchHeader{batch.Header()}),
+	}, data, err)
+}
+
+func (wal *consensusWAL) appendBatchVote(vote *core.BatchVote) {
+	if wal == nil {
+		return
+	}
+	data, err := vote.Marshal()
+	wal.append(&walMeta{
+		typ:     walBatchVote,
+		height:  wal.storage.GetBlockHeight(),
+		batches: batchHashSet(vote.BatchHeaders()),
+	}, data, err)
+}
+
+func (wal *consensusWAL) append(meta *walMeta, data []byte, err error) {
+	if err != nil {
+		logger.I().Errorw("marshal wal entry failed", "type", meta.typ, "error", err)
+		return
+	}
+	wal.mtx.Lock()
+	defer wal.mtx.Unlock()
+
+	wal.seq++
+	err = wal.storage.AppendWAL(&storage.WALEntry{
+		Seq:    wal.seq,
+		Type:   meta.typ,
+		Height: meta.height,
+		Data:   data,
+	})
+	if err != nil {
+		logger.I().Errorw("append consensus wal failed", "type", meta.typ, "error", err)
+		return
+	}
+	wal.entries[wal.seq] = meta
+}
+
+// onCommit drops the entries which are not needed to restart after the block is committed
+func (wal *consensusWAL) onCommit(blk *core.Block) {
+	if wal == nil {
+		return
+	}
+	wal.mtx.Lock()
+	defer wal.mtx.Unlock()
+
+	committed := batchHashSet(blk.BatchHeaders())
+	seqs := make([]uint64, 0)
+	for seq, meta := range wal.entries {
+		var drop bool
+		switch meta.typ {
+		case walProposal:
+			drop = meta.height <= blk.Height()
+		case walQC:
+			drop = meta.height < blk.Height()
+		default:
+			for hash := range committed {
+				delete(meta.batches, hash)
+			}
+			drop = len(meta.batches) == 0 || meta.height+walBatchWindow < blk.Height()
+		}
+		if drop {
+			seqs = append(seqs, seq)
+		}
+	}
+	if len(seqs) == 0 {
+		return
+	}
+	if err := wal.storage.DeleteWAL(seqs); err != nil {
+		logger.I().Errorw("prune consensus wal failed", "error", err)
+		return
+	}
+	for _, seq := range seqs {
+		delete(wal.entries, seq)
+	}
+}
+
+// replayWAL restores the uncommitted blocks, qcs and batches received before restart,
+// it must run after the safety state is restored so that replay only raises the voting state
+func (vld *validator) replayWAL() {
+	entries := vld.wal.load()
+	if len(entries) == 0 {
+		return
+	}
+	var count int
+	for _, e := range entries {
+		if err := vld.replayWALEntry(e); err != nil {
+			logger.I().Warnw("replay wal entry failed", "seq", e.Seq, "type", e.Type, "error", err)
+			continue
+		}
+		count++
+	}
+	logger.I().Infow("replayed consensus wal", "entries", len(entries), "replayed", count)
+}
+
+func (vld *validator) replayWALEntry(e *storage.WALEntry) error {
+	committed := vld.resources.Storage.GetBlockHeight()
+	switch e.Type {
+	case walProposal:
+		if e.Height <= committed {
+			return nil
+		}
+		blk := core.NewBlock()
+		if err := blk.Unmarshal(e.Data); err != nil {
+			return err
+		}
+		if err := blk.Validate(vld.resources.VldStore); err != nil {
+			return err
+		}
+		if vld.config.ExecuteTxFlag {
+			// transactions are needed to commit the block
+			if err := fetchMissingBatches(vld.resources, vld.config.BatchSizeLimit, blk.Proposer(), blk.BatchHeaders()); err != nil {
+				return err
+			}
+		}
+		vld.state.setBlock(blk)
+		if vld.state.getBlock(blk.ParentHash()) != nil {
+			// raise qcHigh, bLock and bLeaf as the proposal did before restart,
+			// bVote is restored from the safety state which is saved before voting
+			return vld.updateHotstuff(blk, false)
+		}
+
+	case walQC:
+		if e.Height <= committed {
+			return nil
+		}
+		qc := core.NewQuorumCert()
+		if err := qc.Unmarshal(e.Data); err != nil {
+			return err
+		}
+		if err := qc.Validate(vld.resources.VldStore); err != nil {
+			return err
+		}
+		vld.state.setQC(qc)
+		vld.hotstuff.UpdateQCHigh(newHsQC(qc, vld.state))
+
+	case walBatch:
+		batch := core.NewBatch()
+		if err := batch.Unmarshal(e.Data); err != nil {
+			return err
+		}
+		if err := batch.Header().Validate(vld.resources.VldStore); err != nil {
+			return err
+		}
+		return vld.processBatch(batch)
+
+	case walBatchVote:
+		vote := core.NewBatchVote()
+		if err := vote.Unmarshal(e.Data); err != nil {
+			return err
+		}
+		if err := vote.Validate(vld.resources.VldStore); err != nil {
+			return err
+		}
+		if vld.state.isThisNodeLeader() {
+			vld.leaderState.addBatchVote(vote)
+		}
+
+	default:
+		return fmt.Errorf("unknown wal entry type %d", e.Type)
+	}
+	return nil
+}
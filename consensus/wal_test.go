@@ -0,0 +1,96 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package consensus
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/hotstuff"
+	"github.com/wooyang2018/ppov-blockchain/storage"
+)
+
+func TestConsensusWAL_replay(t *testing.T) {
+	asrt := assert.New(t)
+
+	dir, _ := os.MkdirTemp("", "db")
+	rawDB, _ := storage.NewLevelDB(dir)
+	strg := storage.New(rawDB, storage.DefaultConfig)
+
+	priv := core.GenerateKey(nil)
+	keys := []string{priv.PublicKey().String()}
+	resources := &Resources{
+		Signer:   priv,
+		VldStore: core.NewValidatorStore(keys, keys),
+		Storage:  strg,
+	}
+	b0 := core.NewBlock().SetHeight(0).Sign(priv)
+	q0 := core.NewQuorumCert().Build([]*core.Vote{b0.ProposerVote()})
+	batch := core.NewBatch().SetTransactions([]*core.Transaction{
+		core.NewTransaction().Sign(priv),
+	}).Sign(priv)
+	b1 := core.NewBlock().SetHeight(1).SetParentHash(b0.Hash()).SetQuorumCert(q0).
+		SetBatchHeaders([]*core.BatchHeader{batch.Header()}, false).Sign(priv)
+	q1 := core.NewQuorumCert().Build([]*core.Vote{b1.ProposerVote()})
+	b2 := core.NewBlock().SetHeight(2).SetParentHash(b1.Hash()).SetQuorumCert(q1).Sign(priv)
+	vote := core.NewBatchVote().Build([]*core.BatchHeader{batch.Header()}, priv)
+
+	wal := newConsensusWAL(strg)
+	asrt.Empty(wal.load())
+	wal.appendProposal(b1)
+	wal.appendQC(q1, b1.Height())
+	wal.appendBatch(batch)
+	wal.appendBatchVote(vote)
+	wal.appendProposal(b2)
+
+	// restart
+	state := newState(resources)
+	state.setBlock(b0)
+	state.setLeaderIndex(0)
+	leaderState := newLeaderState().setValidatorStore(resources.VldStore).
+		setBatchSignLimit(2).setBatchWaitTime(time.Minute)
+	hs := hotstuff.New(&hsDriver{resources: resources, state: state}, nil,
+		newHsBlock(b0, state), newHsQC(q0, state))
+	vld := &validator{
+		resources:   resources,
+		config:      DefaultConfig,
+		state:       state,
+		hotstuff:    hs,
+		voterState:  newVoterState().setVoteBatchLimit(1),
+		leaderState: leaderState,
+		wal:         newConsensusWAL(strg),
+	}
+	vld.replayWAL()
+
+	asrt.NotNil(state.getBlock(b1.Hash()))
+	asrt.NotNil(state.getBlock(b2.Hash()))
+	asrt.NotNil(state.getQC(b1.Hash()))
+	// replayed proposals and qcs raise the hotstuff state
+	asrt.EqualValues(1, qcRefHeight(hs.GetQCHigh()))
+	asrt.EqualValues(1, hs.GetBLock().Height())
+	asrt.EqualValues(0, hs.GetBVote().Height(), "bVote is restored from the safety state")
+	asrt.Equal(1, vld.voterState.getBatchNum())
+	asrt.Len(vld.leaderState.batchMap, 1)
+
+	vld.wal.appendProposal(core.NewBlock().SetHeight(3).SetParentHash(b2.Hash()).
+		SetQuorumCert(core.NewQuorumCert().Build([]*core.Vote{b2.ProposerVote()})).Sign(priv))
+	asrt.EqualValues(6, vld.wal.seq, "sequence continues after restart")
+
+	// proposal and batch entries are dropped after commit, qc for the committed block is kept
+	vld.wal.onCommit(b1)
+	entries, err := strg.GetWALEntries()
+	asrt.NoError(err)
+	asrt.Len(entries, 3)
+	asrt.Equal([]byte{walQC, walProposal, walProposal},
+		[]byte{entries[0].Type, entries[1].Type, entries[2].Type})
+
+	vld.wal.onCommit(b2)
+	entries, _ = strg.GetWALEntries()
+	asrt.Len(entries, 1)
+	asrt.EqualValues(3, entries[0].Height)
+}
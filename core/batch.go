@@ -1,7 +1,9 @@
 package core
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
@@ -9,6 +11,11 @@ import (
 	"github.com/wooyang2018/ppov-blockchain/pb"
 )
 
+// errors
+var (
+	ErrUnmatchedBatchTxs = errors.New("batch txs not matched with header")
+)
+
 type Batch struct {
 	data   *pb.Batch
 	txList *TxList
@@ -47,6 +54,23 @@ func (b *Batch) setData(data *pb.Batch) error {
 	return nil
 }
 
+// Validate batch header and the txs against the tx hashes in header
+func (b *Batch) Validate(vs ValidatorStore) error {
+	if err := b.header.Validate(vs); err != nil {
+		return err
+	}
+	hashes := b.header.Transactions()
+	if len(*b.txList) != len(hashes) {
+		return ErrUnmatchedBatchTxs
+	}
+	for i, tx := range *b.txList {
+		if !bytes.Equal(tx.Hash(), hashes[i]) {
+			return ErrUnmatchedBatchTxs
+		}
+	}
+	return nil
+}
+
 func (b *Batch) SetTransactions(val []*Transaction) *Batch {
 	hashes := make([][]byte, len(val), len(val))
 	data := make([]*pb.Transaction, len(val), len(val))
@@ -67,7 +91,7 @@ func (b *Batch) Sign(signer Signer) *Batch {
 	b.data.Header.Proposer = b.header.data.Proposer
 	b.header.data.Hash = b.header.Sum()
 	b.data.Header.Hash = b.header.data.Hash
-	b.header.data.Signature = signer.Sign(b.header.data.Hash).data.Value
+	b.header.data.Signature = signHash(signer, DomainBatch, b.header.data.Hash).data.Value
 	b.data.Header.Signature = b.header.data.Signature
 	return b
 }
@@ -81,6 +105,11 @@ func (b *Batch) SetTimestamp(val int64) *Batch {
 func (b *Batch) Header() *BatchHeader { return b.header }
 func (b *Batch) TxList() *TxList      { return b.txList }
 
+// Size returns the encoded size in bytes
+func (b *Batch) Size() int {
+	return proto.Size(b.data)
+}
+
 func (b *Batch) Marshal() ([]byte, error) {
 	return proto.Marshal(b.data)
 }
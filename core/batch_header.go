@@ -18,6 +18,8 @@ import (
 var (
 	ErrInvalidBatchHeaderHash = errors.New("invalid batch header hash")
 	ErrNilBatchHeader         = errors.New("nil batch header")
+	ErrMissingBatchQC         = errors.New("missing batch quorum cert")
+	ErrUnmatchedBatchQC       = errors.New("batch quorum cert for different batch")
 )
 
 type BatchHeader struct {
@@ -48,11 +50,19 @@ func (b *BatchHeader) Sum() []byte {
 
 // Validate batch header
 func (b *BatchHeader) Validate(vs ValidatorStore) error {
+	return b.validateAt(vs, vs.SignScheme().nextHeight())
+}
+
+// validateAt validates the header in the context of the block at height
+func (b *BatchHeader) validateAt(vs ValidatorStore, height uint64) error {
 	if b.data == nil {
 		return ErrNilBatchHeader
 	}
 	if b.batchQuorumCert != nil {
-		if err := b.batchQuorumCert.Validate(vs); err != nil {
+		if !bytes.Equal(b.batchQuorumCert.BatchHash(), b.data.Hash) {
+			return ErrUnmatchedBatchQC
+		}
+		if err := b.batchQuorumCert.validateAt(vs, height); err != nil {
 			return err
 		}
 	}
@@ -69,7 +79,7 @@ func (b *BatchHeader) Validate(vs ValidatorStore) error {
 	if !vs.IsWorker(sig.PublicKey()) {
 		return ErrInvalidValidator
 	}
-	if !sig.Verify(b.data.Hash) {
+	if !vs.SignScheme().verifyAround(sig, DomainBatch, b.data.Hash, height) {
 		return ErrInvalidSig
 	}
 	return nil
@@ -77,7 +87,7 @@ func (b *BatchHeader) Validate(vs ValidatorStore) error {
 
 func (b *BatchHeader) setData(data *pb.BatchHeader) error {
 	b.data = data
-	if data.BatchQuorumCert != nil && data.BatchQuorumCert.Signatures != nil {
+	if qc := data.BatchQuorumCert; qc != nil && (qc.Signatures != nil || qc.Version == QCVersionCompact) {
 		b.batchQuorumCert = NewBatchQuorumCert()
 		if err := b.batchQuorumCert.setData(data.BatchQuorumCert); err != nil {
 			return err
@@ -101,6 +111,19 @@ func (b *BatchHeader) SetBatchQuorumCert(val *BatchQuorumCert) *BatchHeader {
 	return b
 }
 
+// CompactQC encodes the batch qc with the voters of vs, the validators at the height of the block
+// including the header which validate it
+func (b *BatchHeader) CompactQC(vs ValidatorStore) error {
+	if b.batchQuorumCert == nil {
+		return nil
+	}
+	if err := b.batchQuorumCert.Compact(vs); err != nil {
+		return err
+	}
+	b.data.BatchQuorumCert = b.batchQuorumCert.data
+	return nil
+}
+
 func (b *BatchHeader) SetTimestamp(val int64) *BatchHeader {
 	b.data.Timestamp = val
 	return b
@@ -116,7 +139,7 @@ func (b *BatchHeader) Sign(signer Signer) *BatchHeader {
 	b.proposer = signer.PublicKey()
 	b.data.Proposer = signer.PublicKey().key
 	b.data.Hash = b.Sum()
-	b.data.Signature = signer.Sign(b.data.Hash).data.Value
+	b.data.Signature = signHash(signer, DomainBatch, b.data.Hash).data.Value
 	return b
 }
 
@@ -126,6 +149,11 @@ func (b *BatchHeader) BatchQuorumCert() *BatchQuorumCert { return b.batchQuorumC
 func (b *BatchHeader) Timestamp() int64                  { return b.data.Timestamp }
 func (b *BatchHeader) Transactions() [][]byte            { return b.data.Transactions }
 
+// Size returns the encoded size in bytes
+func (b *BatchHeader) Size() int {
+	return proto.Size(b.data)
+}
+
 func (b *BatchHeader) Marshal() ([]byte, error) {
 	return proto.Marshal(b.data)
 }
@@ -149,3 +177,18 @@ func (b *BatchHeader) UnmarshalJSON(bytes []byte) error {
 func (b *BatchHeader) MarshalJSON() ([]byte, error) {
 	return protojson.Marshal(b.data)
 }
+
+// validateBatchHeaders validates the headers of the block at height in parallel with the shared verifier
+func validateBatchHeaders(headers []*BatchHeader, vs ValidatorStore, height uint64) error {
+	errs := make([]error, len(headers))
+	GetSigVerifier().run(len(headers), func(i int) bool {
+		errs[i] = headers[i].validateAt(vs, height)
+		return errs[i] == nil
+	})
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -30,19 +30,32 @@ func NewBatchQuorumCert() *BatchQuorumCert {
 }
 
 func (qc *BatchQuorumCert) Validate(vs ValidatorStore) error {
+	return qc.validateAt(vs, vs.SignScheme().nextHeight())
+}
+
+// validateAt validates the qc in the context of the block at height
+func (qc *BatchQuorumCert) validateAt(vs ValidatorStore, height uint64) error {
 	if qc.data == nil {
 		return ErrNilBatchQC
 	}
-	if len(qc.sigs) < vs.MajorityVoterCount() {
+	sigs := qc.sigs
+	if qc.data.Version == QCVersionCompact {
+		var err error
+		sigs, err = resolveSigList(qc.data.Signers, qc.data.SigValues, vs.VoterCount(), vs.GetVoter)
+		if err != nil {
+			return err
+		}
+	}
+	if sigs.power(vs) < vs.QuorumVoterPower() {
 		return ErrNotEnoughBatchSig
 	}
-	if qc.sigs.hasDuplicate() {
+	if sigs.hasDuplicate() {
 		return ErrDuplicateBatchSig
 	}
-	if qc.sigs.hasInvalidVoter(vs) {
+	if sigs.hasInvalidVoter(vs) {
 		return ErrInvalidBatchVoter
 	}
-	if qc.sigs.hasInvalidSig(qc.data.BatchHash) {
+	if sigs.hasInvalidSigIn(vs.SignScheme().messagesAround(DomainBatch, qc.data.BatchHash, height)) {
 		return ErrInvalidBatchSig
 	}
 	return nil
@@ -53,6 +66,13 @@ func (qc *BatchQuorumCert) setData(data *pb.BatchQuorumCert) error {
 		return ErrNilBatchQC
 	}
 	qc.data = data
+	if err := checkQCVersion(data.Version); err != nil {
+		return err
+	}
+	if data.Version == QCVersionCompact {
+		qc.sigs = nil // resolved with the validator store on validation
+		return nil
+	}
 	sigs, err := newSigList(qc.data.Signatures)
 	if err != nil {
 		return err
@@ -72,7 +92,35 @@ func (qc *BatchQuorumCert) Build(hash []byte, signs []*Signature) *BatchQuorumCe
 	return qc
 }
 
-func (qc *BatchQuorumCert) BatchHash() []byte        { return qc.data.BatchHash }
+// Compact encodes the signers as a bitmap of the voter indexes of vs,
+// the qc must be validated with the same validator set
+func (qc *BatchQuorumCert) Compact(vs ValidatorStore) error {
+	if qc.data.Version == QCVersionCompact {
+		return nil
+	}
+	indexOf := func(pubKey *PublicKey) int {
+		if !vs.IsVoter(pubKey) {
+			return -1
+		}
+		return vs.GetVoterIndex(pubKey)
+	}
+	bitmap, values, err := compactSigList(qc.sigs, vs.VoterCount(), indexOf)
+	if err != nil {
+		return err
+	}
+	qc.data = &pb.BatchQuorumCert{
+		BatchHash: qc.data.BatchHash,
+		Version:   QCVersionCompact,
+		Signers:   bitmap,
+		SigValues: values,
+	}
+	return nil
+}
+
+func (qc *BatchQuorumCert) BatchHash() []byte { return qc.data.BatchHash }
+func (qc *BatchQuorumCert) Version() uint32   { return qc.data.Version }
+
+// Signatures returns nil for a received compact qc
 func (qc *BatchQuorumCert) Signatures() []*Signature { return qc.sigs }
 
 // Marshal encodes quorum cert as bytes
@@ -33,6 +33,7 @@ func (vote *BatchVote) Validate(vs ValidatorStore) error {
 	if vote.data == nil {
 		return ErrNilBatchVote
 	}
+	scheme := vs.SignScheme()
 	for i, s := range vote.data.Signatures {
 		sig, err := newSignature(s)
 		if err != nil {
@@ -41,7 +42,7 @@ func (vote *BatchVote) Validate(vs ValidatorStore) error {
 		if !vs.IsVoter(sig.PublicKey()) {
 			return ErrInvalidBatchVoter
 		}
-		if !sig.Verify(vote.data.BatchHeaders[i].Hash) {
+		if !scheme.verifyAround(sig, DomainBatch, vote.data.BatchHeaders[i].Hash, scheme.nextHeight()) {
 			return ErrInvalidSig
 		}
 	}
@@ -89,7 +90,7 @@ func (vote *BatchVote) Build(headers []*BatchHeader, signer Signer) *BatchVote {
 	data.Signatures = make([]*pb.Signature, 0, length)
 	for i := 0; i < length; i++ {
 		data.BatchHeaders = append(data.BatchHeaders, headers[i].data)
-		data.Signatures = append(data.Signatures, signer.Sign(headers[i].data.Hash).data)
+		data.Signatures = append(data.Signatures, signHash(signer, DomainBatch, headers[i].data.Hash).data)
 	}
 	vote.setData(data)
 	return vote
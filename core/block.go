@@ -21,6 +21,7 @@ import (
 var (
 	ErrInvalidBlockHash = errors.New("invalid block hash")
 	ErrNilBlock         = errors.New("nil block")
+	ErrInvalidQCHeight  = errors.New("qc height is not below block height")
 )
 
 // Block type
@@ -64,13 +65,18 @@ func (blk *Block) Validate(vs ValidatorStore) error {
 		return ErrNilBlock
 	}
 	if !blk.IsGenesis() { // skip quorum cert validation for genesis block
+		// quorum cert is signed by the validators at the height of the certified block
+		if blk.quorumCert.BlockHeight() >= blk.Height() {
+			return ErrInvalidQCHeight
+		}
 		if err := blk.quorumCert.Validate(vs); err != nil {
 			return err
 		}
-		for _, header := range blk.BatchHeaders() {
-			if err := header.Validate(vs); err != nil {
-				return err
-			}
+	}
+	vs = vs.AtHeight(blk.Height())
+	if !blk.IsGenesis() {
+		if err := validateBatchHeaders(blk.BatchHeaders(), vs, blk.Height()); err != nil {
+			return err
 		}
 	}
 	if !bytes.Equal(blk.Sum(), blk.Hash()) {
@@ -86,20 +92,32 @@ func (blk *Block) Validate(vs ValidatorStore) error {
 	if err != nil {
 		return err
 	}
-	if !sig.Verify(blk.data.Hash) {
+	if !vs.SignScheme().verifyAt(sig, DomainBlock, blk.data.Hash, blk.Height()) {
 		return ErrInvalidSig
 	}
 	return nil
 }
 
-// Vote creates a vote for block
+// Vote creates a vote for block, the vote has no signature if the signer refuses it
 func (blk *Block) Vote(signer Signer) *Vote {
+	vote, _ := blk.TryVote(signer)
+	return vote
+}
+
+// TryVote creates a vote for block, it fails if a remote signer refuses the block
+func (blk *Block) TryVote(signer Signer) (*Vote, error) {
 	vote := NewVote()
+	sig, err := signBlockHash(signer, blk.data, false)
+	if err != nil {
+		vote.data = &pb.Vote{BlockHash: blk.data.Hash, BlockHeight: blk.data.Height}
+		return vote, err
+	}
 	vote.setData(&pb.Vote{
-		BlockHash: blk.data.Hash,
-		Signature: signer.Sign(blk.data.Hash).data,
+		BlockHash:   blk.data.Hash,
+		Signature:   sig.data,
+		BlockHeight: blk.data.Height,
 	})
-	return vote
+	return vote, nil
 }
 
 func (blk *Block) ProposerVote() *Vote {
@@ -110,6 +128,7 @@ func (blk *Block) ProposerVote() *Vote {
 			PubKey: blk.data.Proposer,
 			Value:  blk.data.Signature,
 		},
+		BlockHeight: blk.data.Height,
 	})
 	return vote
 }
@@ -196,12 +215,44 @@ func (blk *Block) SetTransactions(val [][]byte) *Block {
 	return blk
 }
 
+// SetProposer sets the proposer and the hash of an unsigned block, it must be called after other fields are set
+func (blk *Block) SetProposer(val *PublicKey) *Block {
+	blk.proposer = val
+	blk.data.Proposer = val.key
+	blk.data.Hash = blk.Sum()
+	return blk
+}
+
+// SetProposerVote adds the signature of the proposer from its vote on the block
+func (blk *Block) SetProposerVote(vote *Vote) error {
+	if !bytes.Equal(blk.data.Hash, vote.BlockHash()) {
+		return ErrInvalidBlockHash
+	}
+	if !vote.Voter().Equal(blk.proposer) {
+		return ErrInvalidValidator
+	}
+	blk.data.Signature = vote.data.Signature.Value
+	return nil
+}
+
+// Sign signs the block as its proposer, the signature is empty if the signer refuses it
 func (blk *Block) Sign(signer Signer) *Block {
+	blk.TrySign(signer)
+	return blk
+}
+
+// TrySign signs the block as its proposer, it fails if a remote signer refuses the block
+func (blk *Block) TrySign(signer Signer) (*Block, error) {
 	blk.proposer = signer.PublicKey()
 	blk.data.Proposer = signer.PublicKey().key
 	blk.data.Hash = blk.Sum()
-	blk.data.Signature = signer.Sign(blk.data.Hash).data.Value
-	return blk
+	blk.data.Signature = nil
+	sig, err := signBlockHash(signer, blk.data, true)
+	if err != nil {
+		return blk, err
+	}
+	blk.data.Signature = sig.data.Value
+	return blk, nil
 }
 
 func (blk *Block) Hash() []byte                 { return blk.data.Hash }
@@ -221,6 +272,11 @@ func (blk *Block) Marshal() ([]byte, error) {
 	return proto.Marshal(blk.data)
 }
 
+// Size returns the encoded size in bytes
+func (blk *Block) Size() int {
+	return proto.Size(blk.data)
+}
+
 // Unmarshal decodes block from bytes
 func (blk *Block) Unmarshal(b []byte) error {
 	data := new(pb.Block)
@@ -5,6 +5,8 @@
 package core
 
 import (
+	"bytes"
+	"encoding/hex"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -49,8 +51,10 @@ func TestBlock(t *testing.T) {
 
 	vs := new(MockValidatorStore)
 	vs.On("VoterCount").Return(1)
-	vs.On("MajorityValidatorCount").Return(1)
-	vs.On("MajorityVoterCount").Return(1)
+	vs.On("QuorumPower").Return(uint64(1))
+	vs.On("QuorumVoterPower").Return(uint64(1))
+	vs.On("GetPower", privKey.PublicKey()).Return(uint64(1))
+	vs.On("GetPower", mock.Anything).Return(uint64(0))
 	vs.On("IsVoter", privKey.PublicKey()).Return(true)
 	vs.On("IsVoter", mock.Anything).Return(false)
 	vs.On("IsWorker", privKey.PublicKey()).Return(true)
@@ -118,3 +122,21 @@ func TestBlock_Vote(t *testing.T) {
 	assert.NoError(err)
 	vs.AssertExpectations(t)
 }
+
+func TestBlock_Sum(t *testing.T) {
+	asrt := assert.New(t)
+
+	proposer, err := NewPublicKey(bytes.Repeat([]byte{2}, 32))
+	asrt.NoError(err)
+	blk := NewBlock().
+		SetHeight(4).
+		SetParentHash([]byte{1}).
+		SetQuorumCert(NewQuorumCert().Build([]*Vote{{data: &pb.Vote{BlockHash: []byte{3}}}})).
+		SetExecHeight(2).
+		SetMerkleRoot([]byte{4}).
+		SetTimestamp(5).
+		SetProposer(proposer)
+
+	// the hash of stored blocks never changes
+	asrt.Equal("d514ebf41447d57e759b12f6e7b6f73e55aef59709a84e9d2453455c712dcef6", hex.EncodeToString(blk.Hash()))
+}
@@ -0,0 +1,249 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/sha3"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+// errors
+var (
+	ErrNilCheckpoint        = errors.New("nil checkpoint")
+	ErrUnmatchedCheckpoint  = errors.New("checkpoint votes not matched")
+	ErrInvalidCheckpointLen = errors.New("invalid checkpoint hash length")
+)
+
+// checkpointSum returns the message signed by validators for the state at the height
+func checkpointSum(cp *pb.Checkpoint) []byte {
+	h := sha3.New256()
+	h.Write([]byte("checkpoint"))
+	binary.Write(h, binary.BigEndian, cp.Height)
+	h.Write(cp.BlockHash)
+	h.Write(cp.MerkleRoot)
+	return h.Sum(nil)
+}
+
+func validateCheckpoint(cp *pb.Checkpoint) error {
+	if cp == nil {
+		return ErrNilCheckpoint
+	}
+	if len(cp.BlockHash) != 32 || len(cp.MerkleRoot) > 32 {
+		return ErrInvalidCheckpointLen
+	}
+	return nil
+}
+
+// CheckpointVote type, a validator's signature on the state merkle root after a committed block
+type CheckpointVote struct {
+	data  *pb.CheckpointVote
+	voter *PublicKey
+}
+
+func NewCheckpointVote() *CheckpointVote {
+	return &CheckpointVote{
+		data: new(pb.CheckpointVote),
+	}
+}
+
+// Validate checkpoint vote
+func (vote *CheckpointVote) Validate(vs ValidatorStore) error {
+	if vote.data == nil {
+		return ErrNilCheckpoint
+	}
+	if err := validateCheckpoint(vote.data.Checkpoint); err != nil {
+		return err
+	}
+	sig, err := newSignature(vote.data.Signature)
+	if err != nil {
+		return err
+	}
+	if !(vs.IsVoter(sig.PublicKey()) || vs.IsWorker(sig.PublicKey())) {
+		return ErrInvalidValidator
+	}
+	if !vs.SignScheme().verifyAt(sig, DomainCheckpoint, checkpointSum(vote.data.Checkpoint), vote.Height()) {
+		return ErrInvalidSig
+	}
+	return nil
+}
+
+func (vote *CheckpointVote) setData(data *pb.CheckpointVote) error {
+	if data == nil || data.Checkpoint == nil {
+		return ErrNilCheckpoint
+	}
+	vote.data = data
+	sig, err := newSignature(vote.data.Signature)
+	if err != nil {
+		return err
+	}
+	vote.voter = sig.pubKey
+	return nil
+}
+
+// Sign creates a signed checkpoint of the block and the state merkle root after it is executed
+func (vote *CheckpointVote) Sign(height uint64, blockHash, merkleRoot []byte, signer Signer) *CheckpointVote {
+	vote.TrySign(height, blockHash, merkleRoot, signer)
+	return vote
+}
+
+// TrySign creates a signed checkpoint, it fails if a remote signer refuses the checkpoint
+func (vote *CheckpointVote) TrySign(height uint64, blockHash, merkleRoot []byte,
+	signer Signer,
+) (*CheckpointVote, error) {
+	vote.data.Checkpoint = &pb.Checkpoint{
+		Height:     height,
+		BlockHash:  blockHash,
+		MerkleRoot: merkleRoot,
+	}
+	vote.voter = signer.PublicKey()
+	vote.data.Signature = &pb.Signature{PubKey: signer.PublicKey().Bytes()}
+	payload, err := proto.Marshal(vote.data.Checkpoint)
+	if err != nil {
+		return vote, err
+	}
+	sig, err := signRequest(signer, &SignRequest{
+		Domain:  DomainCheckpoint,
+		Hash:    checkpointSum(vote.data.Checkpoint),
+		Payload: payload,
+	}, height)
+	if err != nil {
+		return vote, err
+	}
+	vote.data.Signature = sig.data
+	return vote, nil
+}
+
+func (vote *CheckpointVote) Height() uint64     { return vote.data.Checkpoint.Height }
+func (vote *CheckpointVote) BlockHash() []byte  { return vote.data.Checkpoint.BlockHash }
+func (vote *CheckpointVote) MerkleRoot() []byte { return vote.data.Checkpoint.MerkleRoot }
+func (vote *CheckpointVote) Voter() *PublicKey  { return vote.voter }
+func (vote *CheckpointVote) Signature() *Signature {
+	return &Signature{vote.data.Signature, vote.voter}
+}
+
+// Sum returns the signed message of the checkpoint
+func (vote *CheckpointVote) Sum() []byte {
+	return checkpointSum(vote.data.Checkpoint)
+}
+
+// Marshal encodes checkpoint vote as bytes
+func (vote *CheckpointVote) Marshal() ([]byte, error) {
+	return proto.Marshal(vote.data)
+}
+
+// Unmarshal decodes checkpoint vote from bytes
+func (vote *CheckpointVote) Unmarshal(b []byte) error {
+	data := new(pb.CheckpointVote)
+	if err := proto.Unmarshal(b, data); err != nil {
+		return err
+	}
+	return vote.setData(data)
+}
+
+// CheckpointCert type, formed by checkpoint votes of the same checkpoint from majority validators
+type CheckpointCert struct {
+	data *pb.CheckpointCert
+	sigs sigList
+}
+
+func NewCheckpointCert() *CheckpointCert {
+	return &CheckpointCert{
+		data: new(pb.CheckpointCert),
+	}
+}
+
+// Validate checkpoint cert
+func (cert *CheckpointCert) Validate(vs ValidatorStore) error {
+	if cert.data == nil {
+		return ErrNilCheckpoint
+	}
+	if err := validateCheckpoint(cert.data.Checkpoint); err != nil {
+		return err
+	}
+	if cert.sigs.power(vs) < vs.QuorumPower() {
+		return ErrNotEnoughSig
+	}
+	if cert.sigs.hasDuplicate() {
+		return ErrDuplicateSig
+	}
+	if cert.sigs.hasInvalidValidator(vs) {
+		return ErrInvalidValidator
+	}
+	scheme := vs.SignScheme()
+	msg := scheme.message(scheme.Version(cert.Height()), DomainCheckpoint, checkpointSum(cert.data.Checkpoint))
+	if cert.sigs.hasInvalidSig(msg) {
+		return ErrInvalidSig
+	}
+	return nil
+}
+
+func (cert *CheckpointCert) setData(data *pb.CheckpointCert) error {
+	if data == nil || data.Checkpoint == nil {
+		return ErrNilCheckpoint
+	}
+	cert.data = data
+	sigs, err := newSigList(cert.data.Signatures)
+	if err != nil {
+		return err
+	}
+	cert.sigs = sigs
+	return nil
+}
+
+// Build creates checkpoint cert from votes of the same checkpoint
+func (cert *CheckpointCert) Build(votes []*CheckpointVote) (*CheckpointCert, error) {
+	if len(votes) == 0 {
+		return nil, ErrNilCheckpoint
+	}
+	cert.data.Checkpoint = votes[0].data.Checkpoint
+	cert.data.Signatures = make([]*pb.Signature, len(votes))
+	cert.sigs = make(sigList, len(votes))
+	sum := votes[0].Sum()
+	for i, vote := range votes {
+		if !bytes.Equal(sum, vote.Sum()) {
+			return nil, ErrUnmatchedCheckpoint
+		}
+		cert.data.Signatures[i] = vote.data.Signature
+		cert.sigs[i] = vote.Signature()
+	}
+	return cert, nil
+}
+
+func (cert *CheckpointCert) Height() uint64           { return cert.data.Checkpoint.Height }
+func (cert *CheckpointCert) BlockHash() []byte        { return cert.data.Checkpoint.BlockHash }
+func (cert *CheckpointCert) MerkleRoot() []byte       { return cert.data.Checkpoint.MerkleRoot }
+func (cert *CheckpointCert) Signatures() []*Signature { return cert.sigs }
+
+// Marshal encodes checkpoint cert as bytes
+func (cert *CheckpointCert) Marshal() ([]byte, error) {
+	return proto.Marshal(cert.data)
+}
+
+// Unmarshal decodes checkpoint cert from bytes
+func (cert *CheckpointCert) Unmarshal(b []byte) error {
+	data := new(pb.CheckpointCert)
+	if err := proto.Unmarshal(b, data); err != nil {
+		return err
+	}
+	return cert.setData(data)
+}
+
+func (cert *CheckpointCert) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(cert.data)
+}
+
+func (cert *CheckpointCert) UnmarshalJSON(b []byte) error {
+	data := new(pb.CheckpointCert)
+	if err := protojson.Unmarshal(b, data); err != nil {
+		return err
+	}
+	return cert.setData(data)
+}
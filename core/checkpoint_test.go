@@ -0,0 +1,131 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCheckpointVote(t *testing.T) {
+	asrt := assert.New(t)
+
+	validator := GenerateKey(nil)
+	blkHash, root := make([]byte, 32), make([]byte, 32)
+	root[0] = 1
+	vote := NewCheckpointVote().Sign(10, blkHash, root, validator)
+	b, err := vote.Marshal()
+	asrt.NoError(err)
+
+	vs := new(MockValidatorStore)
+	vs.On("IsVoter", validator.PublicKey()).Return(true)
+	vs.On("IsVoter", mock.Anything).Return(false)
+	vs.On("IsWorker", mock.Anything).Return(false)
+
+	vote = NewCheckpointVote()
+	asrt.NoError(vote.Unmarshal(b))
+	asrt.NoError(vote.Validate(vs))
+	asrt.EqualValues(10, vote.Height())
+	asrt.Equal(root, vote.MerkleRoot())
+	asrt.Equal(validator.PublicKey(), vote.Voter())
+
+	vote.data.Checkpoint.MerkleRoot = blkHash
+	asrt.Error(vote.Validate(vs), "signed root changed")
+
+	vote.data.Checkpoint.BlockHash = []byte{1}
+	asrt.Error(vote.Validate(vs), "invalid block hash")
+
+	other := NewCheckpointVote().Sign(10, blkHash, root, GenerateKey(nil))
+	asrt.Error(other.Validate(vs), "not a validator")
+
+	asrt.Error(NewCheckpointVote().Unmarshal(nil))
+}
+
+func TestCheckpointCert(t *testing.T) {
+	privKeys := make([]*PrivateKey, 5)
+
+	vs := new(MockValidatorStore)
+	vs.On("QuorumPower").Return(uint64(3))
+	for i := range privKeys {
+		privKeys[i] = GenerateKey(nil)
+		if i != 4 {
+			vs.On("GetPower", privKeys[i].pubKey).Return(uint64(1))
+			vs.On("IsVoter", privKeys[i].pubKey).Return(true)
+		}
+	}
+	vs.On("IsVoter", mock.Anything).Return(false)
+	vs.On("IsWorker", mock.Anything).Return(false)
+	vs.On("GetPower", mock.Anything).Return(uint64(0))
+
+	blkHash, root := make([]byte, 32), make([]byte, 32)
+	votes := make([]*CheckpointVote, len(privKeys))
+	for i, priv := range privKeys {
+		votes[i] = NewCheckpointVote().Sign(10, blkHash, root, priv)
+	}
+	otherRoot := NewCheckpointVote().Sign(10, blkHash, blkHash[:31], privKeys[3])
+
+	_, err := NewCheckpointCert().Build([]*CheckpointVote{votes[0], otherRoot})
+	assert.ErrorIs(t, err, ErrUnmatchedCheckpoint)
+
+	build := func(votes ...*CheckpointVote) []byte {
+		cert, err := NewCheckpointCert().Build(votes)
+		assert.NoError(t, err)
+		b, _ := cert.Marshal()
+		return b
+	}
+	certValid := build(votes[0], votes[1], votes[2])
+	certNotEnoughSig := build(votes[0], votes[1])
+	certDuplicateKey := build(votes[0], votes[1], votes[1])
+	certInvalidValidator := build(votes[0], votes[1], votes[4])
+
+	cert, _ := NewCheckpointCert().Build([]*CheckpointVote{votes[0], votes[1], votes[2]})
+	cert.data.Checkpoint = otherRoot.data.Checkpoint
+	certInvalidSig, _ := cert.Marshal()
+
+	tests := []struct {
+		name         string
+		b            []byte
+		unmarshalErr bool
+		validateErr  bool
+	}{
+		{"valid", certValid, false, false},
+		{"nil cert", nil, true, true},
+		{"not enough sig", certNotEnoughSig, false, true},
+		{"duplicate key", certDuplicateKey, false, true},
+		{"invalid validator", certInvalidValidator, false, true},
+		{"different checkpoint", certInvalidSig, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			cert := NewCheckpointCert()
+			err := cert.Unmarshal(tt.b)
+			if tt.unmarshalErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			err = cert.Validate(vs)
+			if tt.validateErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+
+	// json format served by api for offline verification
+	cert = NewCheckpointCert()
+	assert.NoError(t, cert.Unmarshal(certValid))
+	b, err := json.Marshal(cert)
+	assert.NoError(t, err)
+	cert = NewCheckpointCert()
+	assert.NoError(t, json.Unmarshal(b, cert))
+	assert.NoError(t, cert.Validate(vs))
+	assert.EqualValues(t, 10, cert.Height())
+}
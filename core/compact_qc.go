@@ -0,0 +1,86 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+// quorum cert encoding versions
+const (
+	QCVersionFull    uint32 = iota // signatures with public keys
+	QCVersionCompact               // signer bitmap with raw signatures, public keys are resolved from validator store
+)
+
+var ErrInvalidSignerBitmap = errors.New("invalid signer bitmap")
+
+func checkQCVersion(version uint32) error {
+	if version > QCVersionCompact {
+		return fmt.Errorf("unknown qc version %d", version)
+	}
+	return nil
+}
+
+// compactSigList returns the signer bitmap and the raw signatures ordered by the signer indexes,
+// indexOf returns -1 for a public key out of the indexed set
+func compactSigList(sigs sigList, count int, indexOf func(*PublicKey) int) ([]byte, [][]byte, error) {
+	indexes := make([]int, len(sigs))
+	byIndex := make(map[int]*Signature, len(sigs))
+	for i, sig := range sigs {
+		idx := indexOf(sig.PublicKey())
+		if idx < 0 || idx >= count {
+			return nil, nil, ErrInvalidValidator
+		}
+		if _, found := byIndex[idx]; found {
+			return nil, nil, ErrDuplicateSig
+		}
+		byIndex[idx] = sig
+		indexes[i] = idx
+	}
+	sort.Ints(indexes)
+	bitmap := make([]byte, (count+7)/8)
+	values := make([][]byte, len(indexes))
+	for i, idx := range indexes {
+		bitmap[idx/8] |= 0x80 >> (idx % 8)
+		values[i] = byIndex[idx].data.Value
+	}
+	return bitmap, values, nil
+}
+
+// resolveSigList restores the signatures of a compact qc with the public keys at the signer indexes
+func resolveSigList(bitmap []byte, values [][]byte, count int, keyAt func(int) *PublicKey) (sigList, error) {
+	if len(bitmap) != (count+7)/8 {
+		return nil, ErrInvalidSignerBitmap
+	}
+	sigs := make(sigList, 0, len(values))
+	for idx := 0; idx < len(bitmap)*8; idx++ {
+		if bitmap[idx/8]&(0x80>>(idx%8)) == 0 {
+			continue
+		}
+		if idx >= count || len(sigs) == len(values) {
+			return nil, ErrInvalidSignerBitmap
+		}
+		pubKey := keyAt(idx)
+		if pubKey == nil {
+			return nil, ErrInvalidSignerBitmap
+		}
+		sig, err := newSignature(&pb.Signature{
+			PubKey:  pubKey.Bytes(),
+			Value:   values[len(sigs)],
+			KeyType: uint32(pubKey.Type()),
+		})
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, sig)
+	}
+	if len(sigs) != len(values) {
+		return nil, ErrInvalidSignerBitmap
+	}
+	return sigs, nil
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+func newCompactTestKeys(n int) ([]*PrivateKey, []string) {
+	privs := make([]*PrivateKey, n)
+	keys := make([]string, n)
+	for i := range privs {
+		privs[i] = GenerateKey(nil)
+		keys[i] = privs[i].PublicKey().String()
+	}
+	return privs, keys
+}
+
+func TestQuorumCert_Compact(t *testing.T) {
+	asrt := assert.New(t)
+
+	privs, keys := newCompactTestKeys(4)
+	vs := NewValidatorStore(keys, keys)
+	blockHash := []byte{1}
+	votes := make([]*Vote, 3)
+	for i := range votes {
+		votes[i] = NewVote()
+		votes[i].setData(&pb.Vote{
+			BlockHash: blockHash,
+			Signature: privs[2-i].Sign(blockHash).data,
+		})
+	}
+
+	qc := NewQuorumCert().Build(votes)
+	full, _ := qc.Marshal()
+	asrt.NoError(qc.Compact(vs))
+	asrt.Equal(QCVersionCompact, qc.Version())
+	compact, err := qc.Marshal()
+	asrt.NoError(err)
+	asrt.Less(len(compact), len(full))
+
+	qc = NewQuorumCert()
+	asrt.NoError(qc.Unmarshal(compact))
+	asrt.Nil(qc.Signatures())
+	asrt.Equal(blockHash, qc.BlockHash())
+	asrt.NoError(qc.Validate(vs))
+
+	// old encoding is still accepted
+	qc = NewQuorumCert()
+	asrt.NoError(qc.Unmarshal(full))
+	asrt.Equal(QCVersionFull, qc.Version())
+	asrt.Len(qc.Signatures(), 3)
+	asrt.NoError(qc.Validate(vs))
+
+	// signer out of validator set
+	outsider := NewValidatorStore(keys[:2], keys[:2])
+	qc = NewQuorumCert().Build(votes)
+	asrt.ErrorIs(qc.Compact(outsider), ErrInvalidValidator)
+
+	// not enough signers
+	qc = NewQuorumCert().Build(votes[:2])
+	asrt.NoError(qc.Compact(vs))
+	asrt.ErrorIs(qc.Validate(vs), ErrNotEnoughSig)
+
+	// signer bitmap doesn't match the signatures
+	qc = NewQuorumCert().Build(votes)
+	asrt.NoError(qc.Compact(vs))
+	qc.data.Signers[0] |= 0x10
+	asrt.ErrorIs(qc.Validate(vs), ErrInvalidSignerBitmap)
+
+	qc = NewQuorumCert().Build(votes)
+	asrt.NoError(qc.Compact(vs))
+	qc.data.SigValues = append(qc.data.SigValues, qc.data.SigValues[0])
+	asrt.ErrorIs(qc.Validate(vs), ErrInvalidSignerBitmap)
+
+	qc = NewQuorumCert().Build(votes)
+	asrt.NoError(qc.Compact(vs))
+	qc.data.Signers = append(qc.data.Signers, 0)
+	asrt.ErrorIs(qc.Validate(vs), ErrInvalidSignerBitmap)
+
+	// signatures moved to other signers
+	qc = NewQuorumCert().Build(votes)
+	asrt.NoError(qc.Compact(vs))
+	qc.data.Signers[0] = 0x70
+	asrt.ErrorIs(qc.Validate(vs), ErrInvalidSig)
+
+	b, _ := (&QuorumCert{data: &pb.QuorumCert{BlockHash: blockHash, Version: 2}}).Marshal()
+	asrt.Error(NewQuorumCert().Unmarshal(b), "unknown version")
+}
+
+func TestBatchQuorumCert_Compact(t *testing.T) {
+	asrt := assert.New(t)
+
+	privs, keys := newCompactTestKeys(7)
+	vs := NewValidatorStore(keys[:1], keys)
+	batchHash := []byte{1}
+	signs := make([]*Signature, 5)
+	for i := range signs {
+		signs[i] = privs[len(privs)-1-i].Sign(batchHash)
+	}
+
+	qc := NewBatchQuorumCert().Build(batchHash, signs)
+	full, _ := qc.Marshal()
+	asrt.NoError(qc.Compact(vs))
+	compact, err := qc.Marshal()
+	asrt.NoError(err)
+	asrt.Less(len(compact), len(full))
+
+	qc = NewBatchQuorumCert()
+	asrt.NoError(qc.Unmarshal(compact))
+	asrt.Equal(QCVersionCompact, qc.Version())
+	asrt.NoError(qc.Validate(vs))
+
+	qc = NewBatchQuorumCert()
+	asrt.NoError(qc.Unmarshal(full))
+	asrt.NoError(qc.Validate(vs))
+
+	// validated with another voter set
+	other := NewValidatorStore(keys[:1], append([]string{keys[6]}, keys[:6]...))
+	qc = NewBatchQuorumCert()
+	asrt.NoError(qc.Unmarshal(compact))
+	asrt.ErrorIs(qc.Validate(other), ErrInvalidBatchSig)
+
+	qc = NewBatchQuorumCert().Build(batchHash, signs)
+	asrt.NoError(qc.Compact(vs))
+	qc.data.SigValues = qc.data.SigValues[1:]
+	asrt.ErrorIs(qc.Validate(vs), ErrInvalidSignerBitmap)
+}
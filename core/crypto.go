@@ -5,9 +5,11 @@
 package core
 
 import (
+	"bytes"
 	"crypto/ed25519"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 
 	"github.com/wooyang2018/ppov-blockchain/pb"
@@ -16,6 +18,7 @@ import (
 // errors
 var (
 	ErrInvalidKeySize = errors.New("invalid key size")
+	ErrInvalidKey     = errors.New("invalid key")
 )
 
 type Signer interface {
@@ -23,26 +26,115 @@ type Signer interface {
 	PublicKey() *PublicKey
 }
 
+// KeyType tags the signature scheme of a key
+type KeyType uint32
+
+// key types, ed25519 is the zero value so that the tag is omitted in the existing data
+const (
+	KeyEd25519 KeyType = iota
+	KeySecp256k1
+)
+
+// keyScheme implements the primitives of a key type
+type keyScheme interface {
+	name() string
+	signatureSize() int
+	checkPublicKey(b []byte) error
+	publicKey(priv []byte) ([]byte, error)
+	generate(rand io.Reader) ([]byte, error)
+	sign(priv, msg []byte) []byte
+	verify(pub, msg, sig []byte) bool
+}
+
+var keySchemes = map[KeyType]keyScheme{
+	KeyEd25519:   ed25519Scheme{},
+	KeySecp256k1: secp256k1Scheme{},
+}
+
+func (kt KeyType) scheme() (keyScheme, error) {
+	scheme, found := keySchemes[kt]
+	if !found {
+		return nil, fmt.Errorf("unknown key type %d", kt)
+	}
+	return scheme, nil
+}
+
+func (kt KeyType) String() string {
+	if scheme, err := kt.scheme(); err == nil {
+		return scheme.name()
+	}
+	return fmt.Sprintf("KeyType(%d)", uint32(kt))
+}
+
+type ed25519Scheme struct{}
+
+func (ed25519Scheme) name() string { return "ed25519" }
+
+func (ed25519Scheme) signatureSize() int { return ed25519.SignatureSize }
+
+func (ed25519Scheme) checkPublicKey(b []byte) error {
+	if len(b) != ed25519.PublicKeySize {
+		return ErrInvalidKeySize
+	}
+	return nil
+}
+
+func (ed25519Scheme) publicKey(priv []byte) ([]byte, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidKeySize
+	}
+	return ed25519.PrivateKey(priv).Public().(ed25519.PublicKey), nil
+}
+
+func (ed25519Scheme) generate(rand io.Reader) ([]byte, error) {
+	_, priv, err := ed25519.GenerateKey(rand)
+	return priv, err
+}
+
+func (ed25519Scheme) sign(priv, msg []byte) []byte {
+	return ed25519.Sign(priv, msg)
+}
+
+func (ed25519Scheme) verify(pub, msg, sig []byte) bool {
+	return ed25519.Verify(pub, msg, sig)
+}
+
 // PublicKey type
 type PublicKey struct {
-	key    ed25519.PublicKey
-	keyStr string
+	keyType KeyType
+	key     []byte
+	keyStr  string
 }
 
-// NewPublicKey creates PublicKey from bytes
+// NewPublicKey creates ed25519 PublicKey from bytes
 func NewPublicKey(b []byte) (*PublicKey, error) {
-	if len(b) != ed25519.PublicKeySize {
-		return nil, ErrInvalidKeySize
+	return NewTypedPublicKey(KeyEd25519, b)
+}
+
+// NewTypedPublicKey creates PublicKey of the key type from bytes
+func NewTypedPublicKey(keyType KeyType, b []byte) (*PublicKey, error) {
+	scheme, err := keyType.scheme()
+	if err != nil {
+		return nil, err
+	}
+	if err := scheme.checkPublicKey(b); err != nil {
+		return nil, err
 	}
 	return &PublicKey{
-		key:    b,
-		keyStr: base64.StdEncoding.EncodeToString(b),
+		keyType: keyType,
+		key:     b,
+		keyStr:  base64.StdEncoding.EncodeToString(b),
 	}, nil
 }
 
 // Equal checks whether pub and x has the same value
 func (pub *PublicKey) Equal(x *PublicKey) bool {
-	return pub.key.Equal(x.key)
+	return pub.keyType == x.keyType && bytes.Equal(pub.key, x.key)
+}
+
+// Type returns the key type
+func (pub *PublicKey) Type() KeyType {
+	return pub.keyType
 }
 
 // Bytes return raw bytes
@@ -56,21 +148,36 @@ func (pub *PublicKey) String() string {
 
 // PrivateKey type
 type PrivateKey struct {
-	key    ed25519.PrivateKey
+	key    []byte
 	pubKey *PublicKey
+	scheme keyScheme
 }
 
 var _ Signer = (*PrivateKey)(nil)
 
-// NewPrivateKey creates PrivateKey from bytes
+// NewPrivateKey creates ed25519 PrivateKey from bytes
 func NewPrivateKey(b []byte) (*PrivateKey, error) {
-	if len(b) != ed25519.PrivateKeySize {
-		return nil, ErrInvalidKeySize
+	return NewTypedPrivateKey(KeyEd25519, b)
+}
+
+// NewTypedPrivateKey creates PrivateKey of the key type from bytes
+func NewTypedPrivateKey(keyType KeyType, b []byte) (*PrivateKey, error) {
+	scheme, err := keyType.scheme()
+	if err != nil {
+		return nil, err
+	}
+	pub, err := scheme.publicKey(b)
+	if err != nil {
+		return nil, err
 	}
 	priv := &PrivateKey{
-		key: b,
+		key:    b,
+		scheme: scheme,
+	}
+	priv.pubKey, err = NewTypedPublicKey(keyType, pub)
+	if err != nil {
+		return nil, err
 	}
-	priv.pubKey, _ = NewPublicKey(priv.key.Public().(ed25519.PublicKey))
 	return priv, nil
 }
 
@@ -88,39 +195,58 @@ func (priv *PrivateKey) PublicKey() *PublicKey {
 func (priv *PrivateKey) Sign(msg []byte) *Signature {
 	return &Signature{
 		data: &pb.Signature{
-			Value:  ed25519.Sign(priv.key, msg),
-			PubKey: priv.pubKey.Bytes(),
+			Value:   priv.scheme.sign(priv.key, msg),
+			PubKey:  priv.pubKey.Bytes(),
+			KeyType: uint32(priv.pubKey.keyType),
 		},
 		pubKey: priv.pubKey,
 	}
 }
 
+// GenerateKey generates ed25519 PrivateKey
 func GenerateKey(rand io.Reader) *PrivateKey {
-	_, priv, _ := ed25519.GenerateKey(rand)
-	privKey, _ := NewPrivateKey(priv)
+	privKey, _ := GenerateTypedKey(KeyEd25519, rand)
 	return privKey
 }
 
+// GenerateTypedKey generates PrivateKey of the key type, rand is crypto/rand.Reader if nil
+func GenerateTypedKey(keyType KeyType, rand io.Reader) (*PrivateKey, error) {
+	scheme, err := keyType.scheme()
+	if err != nil {
+		return nil, err
+	}
+	b, err := scheme.generate(rand)
+	if err != nil {
+		return nil, err
+	}
+	return NewTypedPrivateKey(keyType, b)
+}
+
 // Signature type
 type Signature struct {
 	data   *pb.Signature
 	pubKey *PublicKey
 }
 
+// NewSignature creates Signature from the ed25519 public key and the signature bytes
+func NewSignature(pubKey, value []byte) (*Signature, error) {
+	return newSignature(&pb.Signature{PubKey: pubKey, Value: value})
+}
+
 func newSignature(data *pb.Signature) (*Signature, error) {
 	if data == nil {
 		return nil, ErrNilSig
 	}
-	pubKey, err := NewPublicKey(data.PubKey)
+	pubKey, err := NewTypedPublicKey(KeyType(data.KeyType), data.PubKey)
 	if err != nil {
 		return nil, err
 	}
 	return &Signature{data, pubKey}, nil
 }
 
-// Verify verifies the signature
+// Verify verifies the signature with the shared verifier
 func (sig *Signature) Verify(msg []byte) bool {
-	return ed25519.Verify(sig.pubKey.key, msg, sig.data.Value)
+	return GetSigVerifier().Verify(sig, msg)
 }
 
 // PublicKey returns corresponding public key
@@ -128,6 +254,11 @@ func (sig *Signature) PublicKey() *PublicKey {
 	return sig.pubKey
 }
 
+// Value returns the raw signature bytes
+func (sig *Signature) Value() []byte {
+	return sig.data.Value
+}
+
 type sigList []*Signature
 
 func newSigList(pbsigs []*pb.Signature) (sigList, error) {
@@ -154,6 +285,15 @@ func (sigs sigList) hasDuplicate() bool {
 	return false
 }
 
+// power returns the accumulated voting power of the signers
+func (sigs sigList) power(vs ValidatorStore) uint64 {
+	var power uint64
+	for _, sig := range sigs {
+		power += vs.GetPower(sig.PublicKey())
+	}
+	return power
+}
+
 func (sigs sigList) hasInvalidValidator(vs ValidatorStore) bool {
 	for _, sig := range sigs {
 		if !(vs.IsVoter(sig.PublicKey()) || vs.IsWorker(sig.PublicKey())) {
@@ -182,10 +322,18 @@ func (sigs sigList) hasInvalidWorker(vs ValidatorStore) bool {
 }
 
 func (sigs sigList) hasInvalidSig(msg []byte) bool {
-	for _, sig := range sigs {
-		if !sig.Verify(msg) {
-			return true
+	return !GetSigVerifier().VerifyAll(sigs, msg)
+}
+
+// hasInvalidSigIn reports whether a signature is valid for none of the messages
+func (sigs sigList) hasInvalidSigIn(msgs [][]byte) bool {
+	sv := GetSigVerifier()
+	return !sv.run(len(sigs), func(i int) bool {
+		for _, msg := range msgs {
+			if sv.Verify(sigs[i], msg) {
+				return true
+			}
 		}
-	}
-	return false
+		return false
+	})
 }
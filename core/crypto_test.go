@@ -5,9 +5,14 @@
 package core
 
 import (
+	"crypto/sha256"
 	"testing"
 
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
 )
 
 func TestSignVerify(t *testing.T) {
@@ -21,3 +26,58 @@ func TestSignVerify(t *testing.T) {
 	assert.False(sig.Verify([]byte("tampered message")))
 	assert.Equal(privKey.PublicKey(), sig.PublicKey())
 }
+
+func TestSignVerify_Secp256k1(t *testing.T) {
+	asrt := assert.New(t)
+	privKey, err := GenerateTypedKey(KeySecp256k1, nil)
+	asrt.NoError(err)
+	asrt.Equal(KeySecp256k1, privKey.PublicKey().Type())
+	asrt.Len(privKey.PublicKey().Bytes(), Secp256k1PublicKeySize)
+
+	// the message is signed as the digest, so it must be a hash
+	hash := sha256.Sum256([]byte("message to be signed"))
+	msg := hash[:]
+	sig := privKey.Sign(msg)
+	asrt.Len(sig.Value(), Secp256k1SignatureSize)
+	asrt.True(sig.Verify(msg))
+	asrt.False(sig.Verify(msg[1:]))
+	tampered := sha256.Sum256([]byte("tampered message"))
+	asrt.False(sig.Verify(tampered[:]))
+
+	priv2, err := NewTypedPrivateKey(KeySecp256k1, privKey.Bytes())
+	asrt.NoError(err)
+	asrt.True(priv2.PublicKey().Equal(privKey.PublicKey()))
+
+	// the signature with the higher s is rejected
+	var s secp256k1.ModNScalar
+	s.SetByteSlice(sig.Value()[32:])
+	s.Negate()
+	value := append([]byte{}, sig.Value()...)
+	s.PutBytesUnchecked(value[32:])
+	highS, err := newSignature(&pb.Signature{PubKey: sig.data.PubKey, Value: value, KeyType: sig.data.KeyType})
+	asrt.NoError(err)
+	asrt.False(highS.Verify(msg))
+
+	// the key type tag must match the key
+	_, err = newSignature(&pb.Signature{PubKey: sig.data.PubKey, Value: sig.data.Value})
+	asrt.ErrorIs(err, ErrInvalidKeySize)
+	_, err = newSignature(&pb.Signature{PubKey: sig.data.PubKey, Value: sig.data.Value, KeyType: 9})
+	asrt.Error(err)
+
+	_, err = NewTypedPublicKey(KeySecp256k1, make([]byte, Secp256k1PublicKeySize))
+	asrt.ErrorIs(err, ErrInvalidKey)
+	_, err = NewTypedPrivateKey(KeySecp256k1, make([]byte, Secp256k1PrivateKeySize))
+	asrt.ErrorIs(err, ErrInvalidKey)
+}
+
+func TestSignature_Ed25519Encoding(t *testing.T) {
+	asrt := assert.New(t)
+	sig := GenerateKey(nil).Sign([]byte("message to be signed"))
+	asrt.Equal(KeyEd25519, sig.PublicKey().Type())
+
+	b, err := proto.Marshal(sig.data)
+	asrt.NoError(err)
+	legacy, err := proto.Marshal(&pb.Signature{PubKey: sig.data.PubKey, Value: sig.data.Value})
+	asrt.NoError(err)
+	asrt.Equal(legacy, b, "ed25519 signatures are encoded without the key type")
+}
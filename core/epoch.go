@@ -0,0 +1,323 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// GovernanceAddr is the reserved code address of validator set governance transactions,
+// they are not executed by chaincodes but applied by consensus on commit
+var GovernanceAddr = bytes.Repeat([]byte{0xff}, 32)
+
+// errors
+var (
+	ErrEmptyValidatorSet   = errors.New("empty workers or voters")
+	ErrDuplicateValidator  = errors.New("duplicate validator")
+	ErrInvalidEpochStart   = errors.New("epoch start height must be increasing")
+	ErrUnexpectedEpochSeq  = errors.New("unexpected epoch sequence")
+	ErrInvalidValidatorKey = errors.New("invalid validator public key")
+	ErrInvalidPower        = errors.New("voting power must be positive")
+	ErrInvalidApproval     = errors.New("invalid epoch approval")
+	ErrNotEnoughApprovals  = errors.New("not enough epoch approvals")
+)
+
+// ValidatorSet is the input of a governance transaction,
+// Epoch is the sequence of the epoch it proposes
+type ValidatorSet struct {
+	Epoch   uint64            `json:"epoch"`
+	Workers []string          `json:"workers"`
+	Voters  []string          `json:"voters"`
+	Powers  map[string]uint64 `json:"powers,omitempty"`
+}
+
+// Validate checks the proposed keys without touching the fatal logger of StringToPubKey
+func (vset *ValidatorSet) Validate() error {
+	if len(vset.Workers) == 0 || len(vset.Voters) == 0 {
+		return ErrEmptyValidatorSet
+	}
+	for _, keys := range [][]string{vset.Workers, vset.Voters} {
+		set := make(map[string]struct{}, len(keys))
+		for _, v := range keys {
+			if _, ok := set[v]; ok {
+				return ErrDuplicateValidator
+			}
+			set[v] = struct{}{}
+			b, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return ErrInvalidValidatorKey
+			}
+			if _, err := NewPublicKey(b); err != nil {
+				return ErrInvalidValidatorKey
+			}
+		}
+	}
+	for k, power := range vset.Powers {
+		if power == 0 {
+			return ErrInvalidPower
+		}
+		if !containsKey(vset.Workers, k) && !containsKey(vset.Voters, k) {
+			return ErrInvalidValidatorKey
+		}
+	}
+	return nil
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash identifies the proposal that validators approve
+func (vset *ValidatorSet) Hash() []byte {
+	h := sha3.New256()
+	binary.Write(h, binary.BigEndian, vset.Epoch)
+	for _, v := range vset.Workers {
+		h.Write([]byte(v))
+	}
+	h.Write([]byte{0})
+	for _, v := range vset.Voters {
+		h.Write([]byte(v))
+	}
+	keys := make([]string, 0, len(vset.Powers))
+	for k := range vset.Powers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		h.Write([]byte(k))
+		binary.Write(h, binary.BigEndian, vset.Powers[k])
+	}
+	return h.Sum(nil)
+}
+
+// Epoch is a validator set taking effect from the start height
+type Epoch struct {
+	Start     uint64            `json:"start"`
+	Workers   []string          `json:"workers"`
+	Voters    []string          `json:"voters"`
+	Powers    map[string]uint64 `json:"powers,omitempty"`
+	Scheduled uint64            `json:"scheduled,omitempty"` // height of the block scheduling the epoch
+	Approvals [][]byte          `json:"approvals,omitempty"` // governance txs approving the epoch
+}
+
+// VerifyApprovals checks that the epoch is approved by a quorum of validators,
+// seq is the sequence of the epoch and vs the validators at the scheduled height
+func (ep *Epoch) VerifyApprovals(seq uint64, vs ValidatorStore) error {
+	vset := &ValidatorSet{Epoch: seq, Workers: ep.Workers, Voters: ep.Voters, Powers: ep.Powers}
+	hash := vset.Hash()
+	approved := make(map[string]struct{}, len(ep.Approvals))
+	var power uint64
+	for _, b := range ep.Approvals {
+		tx := NewTransaction()
+		if err := tx.Unmarshal(b); err != nil {
+			return err
+		}
+		if err := tx.validateAt(vs.SignScheme(), ep.Scheduled); err != nil {
+			return err
+		}
+		if !bytes.Equal(tx.CodeAddr(), GovernanceAddr) {
+			return ErrInvalidApproval
+		}
+		input := new(ValidatorSet)
+		if err := json.Unmarshal(tx.Input(), input); err != nil {
+			return ErrInvalidApproval
+		}
+		if !bytes.Equal(input.Hash(), hash) {
+			return ErrInvalidApproval
+		}
+		sender := tx.Sender().String()
+		if _, ok := approved[sender]; ok {
+			return ErrDuplicateValidator
+		}
+		approved[sender] = struct{}{}
+		power += vs.GetPower(tx.Sender())
+	}
+	if power < vs.QuorumPower() {
+		return ErrNotEnoughApprovals
+	}
+	return nil
+}
+
+// EpochStore is a height-aware validator store,
+// its ValidatorStore methods use the epoch of the next block to commit
+type EpochStore struct {
+	epochs []*Epoch
+	stores []ValidatorStore
+	height uint64 // committed height
+	scheme *SignScheme
+	mtx    sync.RWMutex
+}
+
+var _ ValidatorStore = (*EpochStore)(nil)
+
+func NewEpochStore(workers []string, voters []string, powers map[string]uint64) *EpochStore {
+	return &EpochStore{
+		epochs: []*Epoch{{Workers: workers, Voters: voters, Powers: powers}},
+		stores: []ValidatorStore{NewWeightedValidatorStore(workers, voters, powers)},
+	}
+}
+
+// AddEpoch schedules a new validator set, epochs must be added in order of start height
+func (es *EpochStore) AddEpoch(ep *Epoch) error {
+	es.mtx.Lock()
+	defer es.mtx.Unlock()
+	if ep.Start <= es.epochs[len(es.epochs)-1].Start {
+		return ErrInvalidEpochStart
+	}
+	es.epochs = append(es.epochs, ep)
+	store := NewWeightedValidatorStore(ep.Workers, ep.Voters, ep.Powers)
+	store.(*validatorStore).scheme = es.scheme
+	es.stores = append(es.stores, store)
+	return nil
+}
+
+// SetSignScheme sets the sign scheme of the chain for the validators of all epochs
+func (es *EpochStore) SetSignScheme(scheme *SignScheme) {
+	es.mtx.Lock()
+	defer es.mtx.Unlock()
+	es.scheme = scheme
+	for _, store := range es.stores {
+		store.(*validatorStore).scheme = scheme
+	}
+}
+
+func (es *EpochStore) SignScheme() *SignScheme {
+	es.mtx.RLock()
+	defer es.mtx.RUnlock()
+	return es.scheme
+}
+
+// SetHeight updates the committed height, returns true if the current epoch changed
+func (es *EpochStore) SetHeight(height uint64) bool {
+	es.mtx.Lock()
+	defer es.mtx.Unlock()
+	prev := es.epochIndex(es.height + 1)
+	es.height = height
+	return prev != es.epochIndex(es.height+1)
+}
+
+// Epochs returns all known epochs including the scheduled ones
+func (es *EpochStore) Epochs() []*Epoch {
+	es.mtx.RLock()
+	defer es.mtx.RUnlock()
+	return append([]*Epoch(nil), es.epochs...)
+}
+
+// CurrentEpoch returns the sequence of the epoch of the next block to commit
+func (es *EpochStore) CurrentEpoch() uint64 {
+	es.mtx.RLock()
+	defer es.mtx.RUnlock()
+	return uint64(es.epochIndex(es.height + 1))
+}
+
+func (es *EpochStore) epochIndex(height uint64) int {
+	idx := 0
+	for i, ep := range es.epochs {
+		if ep.Start <= height {
+			idx = i
+		}
+	}
+	return idx
+}
+
+func (es *EpochStore) AtHeight(height uint64) ValidatorStore {
+	es.mtx.RLock()
+	defer es.mtx.RUnlock()
+	return es.stores[es.epochIndex(height)]
+}
+
+func (es *EpochStore) current() ValidatorStore {
+	es.mtx.RLock()
+	defer es.mtx.RUnlock()
+	return es.stores[es.epochIndex(es.height+1)]
+}
+
+func (es *EpochStore) VoterCount() int {
+	return es.current().VoterCount()
+}
+
+func (es *EpochStore) MajorityVoterCount() int {
+	return es.current().MajorityVoterCount()
+}
+
+func (es *EpochStore) WorkerCount() int {
+	return es.current().WorkerCount()
+}
+
+func (es *EpochStore) EnoughWorkerCount() int {
+	return es.current().EnoughWorkerCount()
+}
+
+func (es *EpochStore) ValidatorCount() int {
+	return es.current().ValidatorCount()
+}
+
+func (es *EpochStore) MajorityValidatorCount() int {
+	return es.current().MajorityValidatorCount()
+}
+
+func (es *EpochStore) IsVoter(pubKey *PublicKey) bool {
+	return es.current().IsVoter(pubKey)
+}
+
+func (es *EpochStore) IsWorker(pubKey *PublicKey) bool {
+	return es.current().IsWorker(pubKey)
+}
+
+func (es *EpochStore) GetVoter(idx int) *PublicKey {
+	return es.current().GetVoter(idx)
+}
+
+func (es *EpochStore) GetWorker(idx int) *PublicKey {
+	return es.current().GetWorker(idx)
+}
+
+func (es *EpochStore) GetVoterIndex(pubKey *PublicKey) int {
+	return es.current().GetVoterIndex(pubKey)
+}
+
+func (es *EpochStore) GetWorkerIndex(pubKey *PublicKey) int {
+	return es.current().GetWorkerIndex(pubKey)
+}
+
+func (es *EpochStore) GetValidator(idx int) *PublicKey {
+	return es.current().GetValidator(idx)
+}
+
+func (es *EpochStore) GetValidatorIndex(pubKey *PublicKey) int {
+	return es.current().GetValidatorIndex(pubKey)
+}
+
+func (es *EpochStore) GetPower(pubKey *PublicKey) uint64 {
+	return es.current().GetPower(pubKey)
+}
+
+func (es *EpochStore) TotalPower() uint64 {
+	return es.current().TotalPower()
+}
+
+func (es *EpochStore) QuorumPower() uint64 {
+	return es.current().QuorumPower()
+}
+
+func (es *EpochStore) TotalVoterPower() uint64 {
+	return es.current().TotalVoterPower()
+}
+
+func (es *EpochStore) QuorumVoterPower() uint64 {
+	return es.current().QuorumVoterPower()
+}
@@ -0,0 +1,147 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatorSet_Validate(t *testing.T) {
+	k1 := GenerateKey(nil).PublicKey().String()
+	k2 := GenerateKey(nil).PublicKey().String()
+
+	tests := []struct {
+		name  string
+		vset  *ValidatorSet
+		valid bool
+	}{
+		{"valid", &ValidatorSet{Workers: []string{k1}, Voters: []string{k1, k2}}, true},
+		{"no workers", &ValidatorSet{Voters: []string{k1}}, false},
+		{"no voters", &ValidatorSet{Workers: []string{k1}}, false},
+		{"duplicate", &ValidatorSet{Workers: []string{k1, k1}, Voters: []string{k1}}, false},
+		{"invalid key", &ValidatorSet{Workers: []string{"key"}, Voters: []string{k1}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.valid {
+				assert.NoError(t, tt.vset.Validate())
+			} else {
+				assert.Error(t, tt.vset.Validate())
+			}
+		})
+	}
+}
+
+func TestEpochStore(t *testing.T) {
+	asrt := assert.New(t)
+
+	priv0, priv1, priv2 := GenerateKey(nil), GenerateKey(nil), GenerateKey(nil)
+	k0 := priv0.PublicKey().String()
+	k1 := priv1.PublicKey().String()
+	k2 := priv2.PublicKey().String()
+
+	es := NewEpochStore([]string{k0}, []string{k0, k1}, nil)
+	asrt.True(es.IsWorker(priv0.PublicKey()))
+	asrt.False(es.IsWorker(priv2.PublicKey()))
+
+	asrt.NoError(es.AddEpoch(&Epoch{Start: 10, Workers: []string{k2}, Voters: []string{k1, k2}}))
+	asrt.Error(es.AddEpoch(&Epoch{Start: 10, Workers: []string{k0}, Voters: []string{k0}}))
+	asrt.Len(es.Epochs(), 2)
+
+	// scheduled epoch does not affect the current validators
+	asrt.False(es.SetHeight(8))
+	asrt.EqualValues(0, es.CurrentEpoch())
+	asrt.True(es.IsWorker(priv0.PublicKey()))
+	asrt.True(es.AtHeight(10).IsWorker(priv2.PublicKey()))
+	asrt.False(es.AtHeight(9).IsWorker(priv2.PublicKey()))
+
+	// next block to commit is the epoch start
+	asrt.True(es.SetHeight(9))
+	asrt.EqualValues(1, es.CurrentEpoch())
+	asrt.False(es.IsWorker(priv0.PublicKey()))
+	asrt.True(es.IsWorker(priv2.PublicKey()))
+	asrt.Equal(0, es.GetWorkerIndex(priv2.PublicKey()))
+}
+
+func TestQuorumCert_ValidateAtBlockHeight(t *testing.T) {
+	asrt := assert.New(t)
+
+	priv0, priv1 := GenerateKey(nil), GenerateKey(nil)
+	k0 := priv0.PublicKey().String()
+	k1 := priv1.PublicKey().String()
+	es := NewEpochStore([]string{k0}, []string{k0}, nil)
+	asrt.NoError(es.AddEpoch(&Epoch{Start: 10, Workers: []string{k1}, Voters: []string{k1}}))
+	asrt.True(es.SetHeight(9))
+
+	// the certified block is the last one of epoch 0 although epoch 1 is current
+	blk := NewBlock().SetHeight(9).Sign(priv0)
+	qc := NewQuorumCert().Build([]*Vote{blk.ProposerVote()}).SetBlockHeight(blk.Height())
+	asrt.NoError(qc.Validate(es))
+
+	blk1 := NewBlock().SetHeight(10).Sign(priv1)
+	qc1 := NewQuorumCert().Build([]*Vote{blk1.ProposerVote()})
+	asrt.EqualValues(10, qc1.BlockHeight(), "height of the votes")
+	asrt.NoError(qc1.Validate(es))
+	asrt.Error(qc1.SetBlockHeight(9).Validate(es), "validated with the validators of epoch 0")
+	asrt.NoError(qc.Compact(es.AtHeight(qc.BlockHeight())))
+	asrt.NoError(qc.Validate(es))
+
+	next := NewBlock().SetHeight(10).SetParentHash(blk.Hash()).SetQuorumCert(qc).Sign(priv1)
+	asrt.NoError(next.Validate(es))
+	stale := NewBlock().SetHeight(9).SetQuorumCert(qc).Sign(priv0)
+	asrt.ErrorIs(stale.Validate(es), ErrInvalidQCHeight)
+}
+
+func TestEpoch_VerifyApprovals(t *testing.T) {
+	asrt := assert.New(t)
+
+	privs := []*PrivateKey{GenerateKey(nil), GenerateKey(nil), GenerateKey(nil), GenerateKey(nil)}
+	keys := make([]string, len(privs))
+	for i, priv := range privs {
+		keys[i] = priv.PublicKey().String()
+	}
+	vs := NewValidatorStore(keys, keys)
+	ep := &Epoch{Start: 10, Workers: keys[1:], Voters: keys[1:]}
+	approve := func(priv *PrivateKey, epoch uint64) []byte {
+		input, _ := json.Marshal(&ValidatorSet{Epoch: epoch, Workers: ep.Workers, Voters: ep.Voters})
+		b, _ := NewTransaction().SetCodeAddr(GovernanceAddr).SetInput(input).Sign(priv).Marshal()
+		return b
+	}
+
+	ep.Approvals = [][]byte{approve(privs[0], 1), approve(privs[1], 1)}
+	asrt.ErrorIs(ep.VerifyApprovals(1, vs), ErrNotEnoughApprovals)
+
+	ep.Approvals = append(ep.Approvals, approve(privs[1], 1))
+	asrt.ErrorIs(ep.VerifyApprovals(1, vs), ErrDuplicateValidator)
+
+	ep.Approvals[2] = approve(privs[2], 1)
+	asrt.NoError(ep.VerifyApprovals(1, vs))
+	asrt.ErrorIs(ep.VerifyApprovals(2, vs), ErrInvalidApproval, "approved for another epoch")
+
+	ep.Approvals[2] = approve(privs[2], 2)
+	asrt.ErrorIs(ep.VerifyApprovals(1, vs), ErrInvalidApproval)
+}
+
+func TestBlock_ValidateEpochBoundary(t *testing.T) {
+	asrt := assert.New(t)
+
+	priv0, priv1 := GenerateKey(nil), GenerateKey(nil)
+	k0 := priv0.PublicKey().String()
+	k1 := priv1.PublicKey().String()
+
+	es := NewEpochStore([]string{k0}, []string{k0}, nil)
+	asrt.NoError(es.AddEpoch(&Epoch{Start: 10, Workers: []string{k1}, Voters: []string{k1}}))
+
+	// block 9 is certified by the old set, block 10 is proposed by the new set
+	b9 := NewBlock().SetHeight(9).Sign(priv0)
+	qc := NewQuorumCert().Build([]*Vote{b9.ProposerVote()})
+	b10 := NewBlock().SetHeight(10).SetParentHash(b9.Hash()).SetQuorumCert(qc).Sign(priv1)
+	asrt.NoError(b10.Validate(es))
+
+	invalid := NewBlock().SetHeight(10).SetParentHash(b9.Hash()).SetQuorumCert(qc).Sign(priv0)
+	asrt.ErrorIs(invalid.Validate(es), ErrInvalidValidator)
+}
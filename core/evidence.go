@@ -0,0 +1,277 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/crypto/sha3"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+// errors
+var (
+	ErrNilEvidence        = errors.New("nil evidence")
+	ErrInvalidEvidence    = errors.New("invalid evidence")
+	ErrDifferentOffenders = errors.New("evidence messages from different signers")
+	ErrNoConflict         = errors.New("evidence messages are not conflicting")
+)
+
+// Evidence is the proof of an equivocation, it contains both signed messages
+type Evidence struct {
+	data       *pb.Evidence
+	offender   *PublicKey
+	blocks     []*Block
+	votes      []*Vote
+	batchVotes []*BatchVote
+}
+
+func NewEvidence() *Evidence {
+	return &Evidence{
+		data: new(pb.Evidence),
+	}
+}
+
+// Validate checks signatures of both messages and that they are conflicting
+func (ev *Evidence) Validate(vs ValidatorStore) error {
+	if ev.data == nil {
+		return ErrNilEvidence
+	}
+	switch ev.data.Type {
+	case pb.Evidence_DoubleProposal:
+		return ev.validateDoubleProposal(vs)
+	case pb.Evidence_DoubleVote:
+		return ev.validateDoubleVote(vs)
+	case pb.Evidence_DoubleBatchVote:
+		return ev.validateDoubleBatchVote(vs)
+	}
+	return ErrInvalidEvidence
+}
+
+func (ev *Evidence) validateDoubleProposal(vs ValidatorStore) error {
+	if len(ev.blocks) != 2 {
+		return ErrInvalidEvidence
+	}
+	if err := ev.validateBlocks(vs); err != nil {
+		return err
+	}
+	if !ev.blocks[0].Proposer().Equal(ev.blocks[1].Proposer()) {
+		return ErrDifferentOffenders
+	}
+	return nil
+}
+
+func (ev *Evidence) validateDoubleVote(vs ValidatorStore) error {
+	if len(ev.blocks) != 2 || len(ev.votes) != 2 {
+		return ErrInvalidEvidence
+	}
+	if err := ev.validateBlocks(vs); err != nil {
+		return err
+	}
+	for i, vote := range ev.votes {
+		if err := vote.Validate(vs); err != nil {
+			return err
+		}
+		if !bytes.Equal(vote.BlockHash(), ev.blocks[i].Hash()) {
+			return ErrInvalidEvidence
+		}
+	}
+	if !ev.votes[0].Voter().Equal(ev.votes[1].Voter()) {
+		return ErrDifferentOffenders
+	}
+	return nil
+}
+
+// validateBlocks checks both blocks are valid and different at the same height
+func (ev *Evidence) validateBlocks(vs ValidatorStore) error {
+	for _, blk := range ev.blocks {
+		if err := blk.Validate(vs); err != nil {
+			return err
+		}
+	}
+	if ev.blocks[0].Height() != ev.blocks[1].Height() ||
+		bytes.Equal(ev.blocks[0].Hash(), ev.blocks[1].Hash()) {
+		return ErrNoConflict
+	}
+	return nil
+}
+
+func (ev *Evidence) validateDoubleBatchVote(vs ValidatorStore) error {
+	if len(ev.batchVotes) != 2 {
+		return ErrInvalidEvidence
+	}
+	for _, vote := range ev.batchVotes {
+		if vote.Voter() == nil {
+			return ErrInvalidEvidence
+		}
+		if err := vote.Validate(vs); err != nil {
+			return err
+		}
+	}
+	if !ev.batchVotes[0].Voter().Equal(ev.batchVotes[1].Voter()) {
+		return ErrDifferentOffenders
+	}
+	h1, h2 := ConflictingBatchHeaders(ev.batchVotes[0], ev.batchVotes[1])
+	if h1 == nil {
+		return ErrNoConflict
+	}
+	if err := h1.Validate(vs); err != nil {
+		return err
+	}
+	return h2.Validate(vs)
+}
+
+// ConflictingBatchHeaders returns the first pair of different batch headers
+// with the same worker and timestamp voted by the given batch votes
+func ConflictingBatchHeaders(v1, v2 *BatchVote) (*BatchHeader, *BatchHeader) {
+	for _, h1 := range v1.BatchHeaders() {
+		for _, h2 := range v2.BatchHeaders() {
+			if h1.Timestamp() == h2.Timestamp() &&
+				h1.Proposer().Equal(h2.Proposer()) &&
+				!bytes.Equal(h1.Hash(), h2.Hash()) {
+				return h1, h2
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (ev *Evidence) setData(data *pb.Evidence) error {
+	if data == nil {
+		return ErrNilEvidence
+	}
+	if data.Type == pb.Evidence_Invalid {
+		return ErrInvalidEvidence
+	}
+	ev.data = data
+	ev.blocks = make([]*Block, len(data.Blocks))
+	for i, b := range data.Blocks {
+		ev.blocks[i] = NewBlock()
+		if err := ev.blocks[i].setData(b); err != nil {
+			return err
+		}
+	}
+	ev.votes = make([]*Vote, len(data.Votes))
+	for i, v := range data.Votes {
+		ev.votes[i] = NewVote()
+		if err := ev.votes[i].setData(v); err != nil {
+			return err
+		}
+	}
+	ev.batchVotes = make([]*BatchVote, len(data.BatchVotes))
+	for i, v := range data.BatchVotes {
+		ev.batchVotes[i] = NewBatchVote()
+		if err := ev.batchVotes[i].setData(v); err != nil {
+			return err
+		}
+	}
+	switch data.Type {
+	case pb.Evidence_DoubleProposal:
+		if len(ev.blocks) > 0 {
+			ev.offender = ev.blocks[0].Proposer()
+		}
+	case pb.Evidence_DoubleVote:
+		if len(ev.votes) > 0 {
+			ev.offender = ev.votes[0].Voter()
+		}
+	case pb.Evidence_DoubleBatchVote:
+		if len(ev.batchVotes) > 0 {
+			ev.offender = ev.batchVotes[0].Voter()
+		}
+	}
+	if ev.offender == nil {
+		return ErrInvalidEvidence
+	}
+	return nil
+}
+
+// BuildDoubleProposal creates evidence of two blocks at the same height by one proposer
+func (ev *Evidence) BuildDoubleProposal(b1, b2 *Block) (*Evidence, error) {
+	if err := ev.setData(&pb.Evidence{
+		Type:   pb.Evidence_DoubleProposal,
+		Blocks: []*pb.Block{b1.data, b2.data},
+	}); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// BuildDoubleVote creates evidence of one voter voting two blocks at the same height
+func (ev *Evidence) BuildDoubleVote(v1, v2 *Vote, b1, b2 *Block) (*Evidence, error) {
+	if err := ev.setData(&pb.Evidence{
+		Type:   pb.Evidence_DoubleVote,
+		Blocks: []*pb.Block{b1.data, b2.data},
+		Votes:  []*pb.Vote{v1.data, v2.data},
+	}); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// BuildDoubleBatchVote creates evidence of one voter voting conflicting batches
+func (ev *Evidence) BuildDoubleBatchVote(v1, v2 *BatchVote) (*Evidence, error) {
+	if err := ev.setData(&pb.Evidence{
+		Type:       pb.Evidence_DoubleBatchVote,
+		BatchVotes: []*pb.BatchVote{v1.data, v2.data},
+	}); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// Hash identifies the equivocation regardless of the order of messages
+func (ev *Evidence) Hash() []byte {
+	var m1, m2 []byte
+	switch ev.data.Type {
+	case pb.Evidence_DoubleProposal, pb.Evidence_DoubleVote:
+		if len(ev.blocks) == 2 {
+			m1, m2 = ev.blocks[0].Hash(), ev.blocks[1].Hash()
+		}
+	case pb.Evidence_DoubleBatchVote:
+		if len(ev.batchVotes) == 2 {
+			if h1, h2 := ConflictingBatchHeaders(ev.batchVotes[0], ev.batchVotes[1]); h1 != nil {
+				m1, m2 = h1.Hash(), h2.Hash()
+			}
+		}
+	}
+	if bytes.Compare(m1, m2) > 0 {
+		m1, m2 = m2, m1
+	}
+	h := sha3.New256()
+	h.Write([]byte{byte(ev.data.Type)})
+	if ev.offender != nil {
+		h.Write(ev.offender.Bytes())
+	}
+	h.Write(m1)
+	h.Write(m2)
+	return h.Sum(nil)
+}
+
+func (ev *Evidence) Type() pb.Evidence_Type   { return ev.data.Type }
+func (ev *Evidence) Offender() *PublicKey     { return ev.offender }
+func (ev *Evidence) Blocks() []*Block         { return ev.blocks }
+func (ev *Evidence) Votes() []*Vote           { return ev.votes }
+func (ev *Evidence) BatchVotes() []*BatchVote { return ev.batchVotes }
+
+// Marshal encodes evidence as bytes
+func (ev *Evidence) Marshal() ([]byte, error) {
+	return proto.Marshal(ev.data)
+}
+
+// Unmarshal decodes evidence from bytes
+func (ev *Evidence) Unmarshal(b []byte) error {
+	data := new(pb.Evidence)
+	if err := proto.Unmarshal(b, data); err != nil {
+		return err
+	}
+	return ev.setData(data)
+}
+
+func (ev *Evidence) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(ev.data)
+}
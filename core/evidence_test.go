@@ -0,0 +1,176 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+func setupEvidenceTest() (*MockValidatorStore, *PrivateKey, *QuorumCert) {
+	vs := new(MockValidatorStore)
+	vs.On("QuorumPower").Return(uint64(1))
+	vs.On("GetPower", mock.Anything).Return(uint64(1))
+	vs.On("IsVoter", mock.Anything).Return(true)
+	vs.On("IsWorker", mock.Anything).Return(true)
+
+	proposer := GenerateKey(nil)
+	parent := NewBlock().SetHeight(9).Sign(proposer)
+	qc := NewQuorumCert().Build([]*Vote{parent.ProposerVote()})
+	return vs, proposer, qc
+}
+
+func TestEvidence_DoubleProposal(t *testing.T) {
+	vs, proposer, qc := setupEvidenceTest()
+
+	b1 := NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(1).Sign(proposer)
+	b2 := NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(2).Sign(proposer)
+	b3 := NewBlock().SetHeight(11).SetQuorumCert(qc).Sign(proposer)
+	other := NewBlock().SetHeight(10).SetQuorumCert(qc).Sign(GenerateKey(nil))
+
+	tests := []struct {
+		name   string
+		b1, b2 *Block
+		valid  bool
+	}{
+		{"valid", b1, b2, true},
+		{"same block", b1, b1, false},
+		{"different height", b1, b3, false},
+		{"different proposer", b1, other, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ev, err := NewEvidence().BuildDoubleProposal(tt.b1, tt.b2)
+			assert.NoError(err)
+			b, err := ev.Marshal()
+			assert.NoError(err)
+
+			ev = NewEvidence()
+			assert.NoError(ev.Unmarshal(b))
+			assert.Equal(proposer.PublicKey(), ev.Offender())
+			if tt.valid {
+				assert.NoError(ev.Validate(vs))
+			} else {
+				assert.Error(ev.Validate(vs))
+			}
+		})
+	}
+}
+
+func TestEvidence_DoubleVote(t *testing.T) {
+	vs, proposer, qc := setupEvidenceTest()
+	voter := GenerateKey(nil)
+
+	b1 := NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(1).Sign(proposer)
+	b2 := NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(2).Sign(proposer)
+	b3 := NewBlock().SetHeight(11).SetQuorumCert(qc).Sign(proposer)
+
+	tests := []struct {
+		name   string
+		v1, v2 *Vote
+		b1, b2 *Block
+		valid  bool
+	}{
+		{"valid", b1.Vote(voter), b2.Vote(voter), b1, b2, true},
+		{"same block", b1.Vote(voter), b1.Vote(voter), b1, b1, false},
+		{"different height", b1.Vote(voter), b3.Vote(voter), b1, b3, false},
+		{"different voter", b1.Vote(voter), b2.Vote(GenerateKey(nil)), b1, b2, false},
+		{"vote not match block", b1.Vote(voter), b3.Vote(voter), b1, b2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ev, err := NewEvidence().BuildDoubleVote(tt.v1, tt.v2, tt.b1, tt.b2)
+			assert.NoError(err)
+			b, err := ev.Marshal()
+			assert.NoError(err)
+
+			ev = NewEvidence()
+			assert.NoError(ev.Unmarshal(b))
+			assert.Equal(voter.PublicKey(), ev.Offender())
+			if tt.valid {
+				assert.NoError(ev.Validate(vs))
+			} else {
+				assert.Error(ev.Validate(vs))
+			}
+		})
+	}
+}
+
+func TestEvidence_DoubleBatchVote(t *testing.T) {
+	vs, worker, _ := setupEvidenceTest()
+	voter := GenerateKey(nil)
+
+	h1 := NewBatchHeader().SetTimestamp(1).SetTransactions([][]byte{{1}}).Sign(worker)
+	h2 := NewBatchHeader().SetTimestamp(1).SetTransactions([][]byte{{2}}).Sign(worker)
+	h3 := NewBatchHeader().SetTimestamp(2).SetTransactions([][]byte{{3}}).Sign(worker)
+
+	tests := []struct {
+		name   string
+		v1, v2 *BatchVote
+		valid  bool
+	}{
+		{"valid", NewBatchVote().Build([]*BatchHeader{h3, h1}, voter),
+			NewBatchVote().Build([]*BatchHeader{h2}, voter), true},
+		{"same batch", NewBatchVote().Build([]*BatchHeader{h1}, voter),
+			NewBatchVote().Build([]*BatchHeader{h1}, voter), false},
+		{"different timestamp", NewBatchVote().Build([]*BatchHeader{h1}, voter),
+			NewBatchVote().Build([]*BatchHeader{h3}, voter), false},
+		{"different voter", NewBatchVote().Build([]*BatchHeader{h1}, voter),
+			NewBatchVote().Build([]*BatchHeader{h2}, GenerateKey(nil)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ev, err := NewEvidence().BuildDoubleBatchVote(tt.v1, tt.v2)
+			assert.NoError(err)
+			b, err := ev.Marshal()
+			assert.NoError(err)
+
+			ev = NewEvidence()
+			assert.NoError(ev.Unmarshal(b))
+			assert.Equal(voter.PublicKey(), ev.Offender())
+			if tt.valid {
+				assert.NoError(ev.Validate(vs))
+			} else {
+				assert.Error(ev.Validate(vs))
+			}
+		})
+	}
+}
+
+func TestEvidence_Hash(t *testing.T) {
+	_, proposer, qc := setupEvidenceTest()
+
+	b1 := NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(1).Sign(proposer)
+	b2 := NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(2).Sign(proposer)
+
+	ev1, _ := NewEvidence().BuildDoubleProposal(b1, b2)
+	ev2, _ := NewEvidence().BuildDoubleProposal(b2, b1)
+
+	assert.Equal(t, ev1.Hash(), ev2.Hash(), "should not depend on message order")
+}
+
+func TestEvidence_InvalidType(t *testing.T) {
+	assert := assert.New(t)
+	_, proposer, qc := setupEvidenceTest()
+
+	b1 := NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(1).Sign(proposer)
+	b2 := NewBlock().SetHeight(10).SetQuorumCert(qc).SetTimestamp(2).Sign(proposer)
+	b, err := proto.Marshal(&pb.Evidence{Blocks: []*pb.Block{b1.data, b2.data}})
+	assert.NoError(err)
+	assert.ErrorIs(NewEvidence().Unmarshal(b), ErrInvalidEvidence, "untyped evidence")
+
+	_, err = NewEvidence().BuildDoubleProposal(NewBlock(), b2)
+	assert.Error(err, "unsigned block")
+}
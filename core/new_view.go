@@ -0,0 +1,109 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/sha3"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+// errors
+var (
+	ErrNilNewView = errors.New("nil new view")
+)
+
+// newViewSum returns the message signed by the sender of the new view
+func newViewSum(view uint64, qcHigh *QuorumCert) []byte {
+	h := sha3.New256()
+	h.Write([]byte("newview"))
+	binary.Write(h, binary.BigEndian, view)
+	h.Write(qcHigh.BlockHash())
+	return h.Sum(nil)
+}
+
+// NewView type, sent to the leader of a view with the sender's highest qc
+type NewView struct {
+	data   *pb.NewView
+	sender *PublicKey
+	qcHigh *QuorumCert
+}
+
+func NewNewView() *NewView {
+	return &NewView{
+		data: new(pb.NewView),
+	}
+}
+
+// Validate new view
+func (nv *NewView) Validate(vs ValidatorStore) error {
+	if nv.data == nil {
+		return ErrNilNewView
+	}
+	if nv.qcHigh == nil {
+		return ErrNilQC
+	}
+	sig, err := newSignature(nv.data.Signature)
+	if err != nil {
+		return err
+	}
+	if !(vs.IsVoter(sig.PublicKey()) || vs.IsWorker(sig.PublicKey())) {
+		return ErrInvalidValidator
+	}
+	scheme := vs.SignScheme()
+	if !scheme.verifyAround(sig, DomainNewView, newViewSum(nv.data.View, nv.qcHigh), scheme.nextHeight()) {
+		return ErrInvalidSig
+	}
+	return nv.qcHigh.Validate(vs)
+}
+
+func (nv *NewView) setData(data *pb.NewView) error {
+	if data == nil {
+		return ErrNilNewView
+	}
+	nv.data = data
+	sig, err := newSignature(nv.data.Signature)
+	if err != nil {
+		return err
+	}
+	nv.sender = sig.pubKey
+	if nv.data.QcHigh == nil {
+		return ErrNilQC
+	}
+	nv.qcHigh = NewQuorumCert()
+	return nv.qcHigh.setData(nv.data.QcHigh)
+}
+
+// Sign creates a signed new view of the view with the sender's highest qc
+func (nv *NewView) Sign(view uint64, qcHigh *QuorumCert, signer Signer) *NewView {
+	nv.data.View = view
+	nv.data.QcHigh = qcHigh.data
+	nv.qcHigh = qcHigh
+	sig := signHash(signer, DomainNewView, newViewSum(view, qcHigh))
+	nv.data.Signature = sig.data
+	nv.sender = sig.pubKey
+	return nv
+}
+
+func (nv *NewView) View() uint64        { return nv.data.View }
+func (nv *NewView) QCHigh() *QuorumCert { return nv.qcHigh }
+func (nv *NewView) Sender() *PublicKey  { return nv.sender }
+
+// Marshal encodes new view as bytes
+func (nv *NewView) Marshal() ([]byte, error) {
+	return proto.Marshal(nv.data)
+}
+
+// Unmarshal decodes new view from bytes
+func (nv *NewView) Unmarshal(b []byte) error {
+	data := new(pb.NewView)
+	if err := proto.Unmarshal(b, data); err != nil {
+		return err
+	}
+	return nv.setData(data)
+}
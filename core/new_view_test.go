@@ -0,0 +1,74 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+func TestNewView(t *testing.T) {
+	validator := GenerateKey(nil)
+	b0 := NewBlock().Sign(validator)
+	qc := NewQuorumCert().Build([]*Vote{b0.ProposerVote()})
+
+	nv := NewNewView().Sign(1, qc, validator)
+	nvOk, _ := nv.Marshal()
+
+	nv.data.View = 2
+	nvInvalid, _ := nv.Marshal()
+
+	nvNilQC, _ := (&NewView{data: &pb.NewView{
+		View: 1, Signature: validator.Sign(nil).data,
+	}}).Marshal()
+
+	other := NewQuorumCert().Build([]*Vote{NewBlock().SetHeight(1).Sign(validator).ProposerVote()})
+	nv = NewNewView().Sign(1, qc, validator)
+	nv.data.QcHigh = other.data
+	nvOtherQC, _ := nv.Marshal()
+
+	tests := []struct {
+		name         string
+		b            []byte
+		unmarshalErr bool
+		validateErr  bool
+	}{
+		{"valid", nvOk, false, false},
+		{"nil new view", nil, true, true},
+		{"nil qc", nvNilQC, true, true},
+		{"invalid view", nvInvalid, false, true},
+		{"replaced qc", nvOtherQC, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			nv := NewNewView()
+			err := nv.Unmarshal(tt.b)
+			if tt.unmarshalErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(validator.PublicKey(), nv.Sender())
+
+			vs := new(MockValidatorStore)
+			vs.On("IsVoter", mock.Anything).Return(true)
+			vs.On("IsWorker", mock.Anything).Return(true)
+			vs.On("GetPower", mock.Anything).Return(uint64(1))
+			vs.On("QuorumPower").Return(uint64(1))
+
+			err = nv.Validate(vs)
+			if tt.validateErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
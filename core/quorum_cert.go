@@ -7,6 +7,7 @@ package core
 import (
 	"errors"
 
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/wooyang2018/ppov-blockchain/pb"
@@ -34,20 +35,31 @@ func NewQuorumCert() *QuorumCert {
 	}
 }
 
+// Validate checks the signatures with the validators at the height of the certified block
 func (qc *QuorumCert) Validate(vs ValidatorStore) error {
 	if qc.data == nil {
 		return ErrNilQC
 	}
-	if len(qc.sigs) < vs.MajorityValidatorCount() {
+	vs = vs.AtHeight(qc.data.BlockHeight)
+	sigs := qc.sigs
+	if qc.data.Version == QCVersionCompact {
+		var err error
+		sigs, err = resolveSigList(qc.data.Signers, qc.data.SigValues, vs.ValidatorCount(), vs.GetValidator)
+		if err != nil {
+			return err
+		}
+	}
+	if sigs.power(vs) < vs.QuorumPower() {
 		return ErrNotEnoughSig
 	}
-	if qc.sigs.hasDuplicate() {
+	if sigs.hasDuplicate() {
 		return ErrDuplicateSig
 	}
-	if qc.sigs.hasInvalidValidator(vs) {
+	if sigs.hasInvalidValidator(vs) {
 		return ErrInvalidValidator
 	}
-	if qc.sigs.hasInvalidSig(qc.data.BlockHash) {
+	scheme := vs.SignScheme()
+	if sigs.hasInvalidSig(scheme.message(scheme.Version(qc.data.BlockHeight), DomainBlock, qc.data.BlockHash)) {
 		return ErrInvalidSig
 	}
 	return nil
@@ -55,6 +67,13 @@ func (qc *QuorumCert) Validate(vs ValidatorStore) error {
 
 func (qc *QuorumCert) setData(data *pb.QuorumCert) error {
 	qc.data = data
+	if err := checkQCVersion(data.Version); err != nil {
+		return err
+	}
+	if data.Version == QCVersionCompact {
+		qc.sigs = nil // resolved with the validator store on validation
+		return nil
+	}
 	sigs, err := newSigList(qc.data.Signatures)
 	if err != nil {
 		return err
@@ -69,6 +88,7 @@ func (qc *QuorumCert) Build(votes []*Vote) *QuorumCert {
 	for i, vote := range votes {
 		if qc.data.BlockHash == nil {
 			qc.data.BlockHash = vote.data.BlockHash
+			qc.data.BlockHeight = vote.data.BlockHeight
 		}
 		qc.data.Signatures[i] = vote.data.Signature
 		qc.sigs[i] = &Signature{
@@ -79,7 +99,39 @@ func (qc *QuorumCert) Build(votes []*Vote) *QuorumCert {
 	return qc
 }
 
-func (qc *QuorumCert) BlockHash() []byte        { return qc.data.BlockHash }
+// SetBlockHeight sets the height of the certified block
+func (qc *QuorumCert) SetBlockHeight(height uint64) *QuorumCert {
+	qc.data.BlockHeight = height
+	return qc
+}
+
+// Compact encodes the signers as a bitmap of the validator indexes of vs,
+// the qc must be validated with the same validator set
+func (qc *QuorumCert) Compact(vs ValidatorStore) error {
+	if qc.data.Version == QCVersionCompact {
+		return nil
+	}
+	bitmap, values, err := compactSigList(qc.sigs, vs.ValidatorCount(), vs.GetValidatorIndex)
+	if err != nil {
+		return err
+	}
+	qc.data = &pb.QuorumCert{
+		BlockHash:   qc.data.BlockHash,
+		Version:     QCVersionCompact,
+		Signers:     bitmap,
+		SigValues:   values,
+		BlockHeight: qc.data.BlockHeight,
+	}
+	return nil
+}
+
+func (qc *QuorumCert) BlockHash() []byte { return qc.data.BlockHash }
+func (qc *QuorumCert) Version() uint32   { return qc.data.Version }
+
+// BlockHeight returns the height of the certified block, which is not signed by the voters
+func (qc *QuorumCert) BlockHeight() uint64 { return qc.data.BlockHeight }
+
+// Signatures returns nil for a received compact qc
 func (qc *QuorumCert) Signatures() []*Signature { return qc.sigs }
 
 // Marshal encodes quorum cert as bytes
@@ -95,3 +147,15 @@ func (qc *QuorumCert) Unmarshal(b []byte) error {
 	}
 	return qc.setData(data)
 }
+
+func (qc *QuorumCert) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(qc.data)
+}
+
+func (qc *QuorumCert) UnmarshalJSON(b []byte) error {
+	data := new(pb.QuorumCert)
+	if err := protojson.Unmarshal(b, data); err != nil {
+		return err
+	}
+	return qc.setData(data)
+}
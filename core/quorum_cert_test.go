@@ -19,11 +19,12 @@ func TestQuorumCert(t *testing.T) {
 	vs := new(MockValidatorStore)
 	vs.On("VoterCount").Return(4)
 	vs.On("WorkerCount").Return(3)
-	vs.On("MajorityValidatorCount").Return(3)
+	vs.On("QuorumPower").Return(uint64(3))
 
 	for i := range privKeys {
 		privKeys[i] = GenerateKey(nil)
 		if i != 4 {
+			vs.On("GetPower", privKeys[i].pubKey).Return(uint64(1))
 			vs.On("IsVoter", privKeys[i].pubKey).Return(true)
 		}
 		if i != 3 && i != 4 {
@@ -32,6 +33,7 @@ func TestQuorumCert(t *testing.T) {
 	}
 	vs.On("IsVoter", mock.Anything).Return(false)
 	vs.On("IsWorker", mock.Anything).Return(false)
+	vs.On("GetPower", mock.Anything).Return(uint64(0))
 
 	blockHash := []byte{1}
 	votes := make([]*Vote, len(privKeys))
@@ -114,3 +116,33 @@ func TestQuorumCert(t *testing.T) {
 		})
 	}
 }
+
+func TestQuorumCert_WeightedPower(t *testing.T) {
+	asrt := assert.New(t)
+
+	privs := []*PrivateKey{GenerateKey(nil), GenerateKey(nil), GenerateKey(nil), GenerateKey(nil)}
+	keys := make([]string, len(privs))
+	for i, priv := range privs {
+		keys[i] = priv.PublicKey().String()
+	}
+	// total power 9, quorum power 7
+	vs := NewWeightedValidatorStore(keys, keys, map[string]uint64{keys[0]: 5, keys[3]: 2})
+
+	blockHash := []byte{1}
+	newQC := func(signers ...int) *QuorumCert {
+		votes := make([]*Vote, len(signers))
+		for i, idx := range signers {
+			votes[i] = NewVote()
+			votes[i].setData(&pb.Vote{
+				BlockHash: blockHash,
+				Signature: privs[idx].Sign(blockHash).data,
+			})
+		}
+		return NewQuorumCert().Build(votes)
+	}
+
+	asrt.ErrorIs(newQC(0).Validate(vs), ErrNotEnoughSig)
+	asrt.ErrorIs(newQC(1, 2, 3).Validate(vs), ErrNotEnoughSig, "majority count but minority power")
+	asrt.NoError(newQC(0, 3).Validate(vs))
+	asrt.NoError(newQC(0, 1, 2).Validate(vs))
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// secp256k1 sizes, public keys are compressed and signatures are r || s
+const (
+	Secp256k1PublicKeySize  = secp256k1.PubKeyBytesLenCompressed
+	Secp256k1PrivateKeySize = secp256k1.PrivKeyBytesLen
+	Secp256k1SignatureSize  = 64
+)
+
+// secp256k1Scheme signs the 32 bytes message as the ecdsa digest without hashing it again,
+// the messages from signMsg are already hashes, so that wallets and HSMs can sign them directly
+type secp256k1Scheme struct{}
+
+func (secp256k1Scheme) name() string { return "secp256k1" }
+
+func (secp256k1Scheme) signatureSize() int { return Secp256k1SignatureSize }
+
+func (secp256k1Scheme) checkPublicKey(b []byte) error {
+	if len(b) != Secp256k1PublicKeySize {
+		return ErrInvalidKeySize
+	}
+	if _, err := secp256k1.ParsePubKey(b); err != nil {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+func (secp256k1Scheme) publicKey(priv []byte) ([]byte, error) {
+	if len(priv) != Secp256k1PrivateKeySize {
+		return nil, ErrInvalidKeySize
+	}
+	var key secp256k1.ModNScalar
+	if overflow := key.SetByteSlice(priv); overflow || key.IsZero() {
+		return nil, ErrInvalidKey
+	}
+	return secp256k1.NewPrivateKey(&key).PubKey().SerializeCompressed(), nil
+}
+
+func (secp256k1Scheme) generate(r io.Reader) ([]byte, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	priv, err := secp256k1.GeneratePrivateKeyFromRand(r)
+	if err != nil {
+		return nil, err
+	}
+	return priv.Serialize(), nil
+}
+
+func (secp256k1Scheme) sign(priv, msg []byte) []byte {
+	// the compact signature is prefixed with the recovery code, which is not needed with the public key
+	return ecdsa.SignCompact(secp256k1.PrivKeyFromBytes(priv), msg, true)[1:]
+}
+
+// verify only accepts the lower s of the two valid signatures, the same as the signer produces
+func (secp256k1Scheme) verify(pub, msg, sig []byte) bool {
+	if len(msg) != 32 {
+		return false
+	}
+	pubKey, err := secp256k1.ParsePubKey(pub)
+	if err != nil {
+		return false
+	}
+	var r, s secp256k1.ModNScalar
+	if r.SetByteSlice(sig[:32]) || r.IsZero() {
+		return false
+	}
+	if s.SetByteSlice(sig[32:]) || s.IsZero() || s.IsOverHalfOrder() {
+		return false
+	}
+	return ecdsa.NewSignature(&r, &s).Verify(msg, pubKey)
+}
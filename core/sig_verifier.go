@@ -0,0 +1,168 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultSigCacheSize is the number of verified signatures kept by the default verifier
+const DefaultSigCacheSize = 100000
+
+// signature sets smaller than this are verified in the calling goroutine
+const parallelVerifyThreshold = 4
+
+var verifier atomic.Pointer[SigVerifier]
+
+func init() {
+	SetSigVerifier(NewSigVerifier(runtime.NumCPU(), DefaultSigCacheSize))
+}
+
+// SetSigVerifier replaces the verifier shared by all signature checks in core
+func SetSigVerifier(sv *SigVerifier) {
+	verifier.Store(sv)
+}
+
+// GetSigVerifier returns the shared verifier
+func GetSigVerifier() *SigVerifier {
+	return verifier.Load()
+}
+
+// SigVerifier verifies signature sets in parallel and keeps a bounded lru cache
+// of the verified (public key, message, signature) tuples
+type SigVerifier struct {
+	workers   int
+	cacheSize int
+
+	mtx   sync.Mutex
+	cache map[[sha256.Size]byte]*list.Element
+	lru   *list.List
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewSigVerifier creates a verifier, cacheSize 0 disables the cache
+func NewSigVerifier(workers, cacheSize int) *SigVerifier {
+	if workers < 1 {
+		workers = 1
+	}
+	return &SigVerifier{
+		workers:   workers,
+		cacheSize: cacheSize,
+		cache:     make(map[[sha256.Size]byte]*list.Element, cacheSize),
+		lru:       list.New(),
+	}
+}
+
+// Verify verifies a single signature, the result is cached when it's valid
+func (sv *SigVerifier) Verify(sig *Signature, msg []byte) bool {
+	scheme, err := sig.pubKey.keyType.scheme()
+	if err != nil || len(sig.data.Value) != scheme.signatureSize() {
+		return false
+	}
+	if sv.cacheSize == 0 {
+		return scheme.verify(sig.pubKey.key, msg, sig.data.Value)
+	}
+	key := sigCacheKey(sig, msg)
+	if sv.lookup(key) {
+		sv.hits.Add(1)
+		return true
+	}
+	sv.misses.Add(1)
+	if !scheme.verify(sig.pubKey.key, msg, sig.data.Value) {
+		return false
+	}
+	sv.insert(key)
+	return true
+}
+
+// VerifyAll verifies the signatures of the same message across the workers
+func (sv *SigVerifier) VerifyAll(sigs []*Signature, msg []byte) bool {
+	return sv.run(len(sigs), func(i int) bool {
+		return sv.Verify(sigs[i], msg)
+	})
+}
+
+// run calls fn for indexes [0, n) across the workers and
+// returns false once any call fails, the remaining calls are skipped
+func (sv *SigVerifier) run(n int, fn func(i int) bool) bool {
+	if n < parallelVerifyThreshold || sv.workers == 1 {
+		for i := 0; i < n; i++ {
+			if !fn(i) {
+				return false
+			}
+		}
+		return true
+	}
+	workers := sv.workers
+	if workers > n {
+		workers = n
+	}
+	var next atomic.Int64
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				if !fn(i) {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return !failed.Load()
+}
+
+// CacheStats returns the cache hits and misses since the verifier is created
+func (sv *SigVerifier) CacheStats() (hits, misses uint64) {
+	return sv.hits.Load(), sv.misses.Load()
+}
+
+func (sv *SigVerifier) lookup(key [sha256.Size]byte) bool {
+	sv.mtx.Lock()
+	defer sv.mtx.Unlock()
+	elem, found := sv.cache[key]
+	if found {
+		sv.lru.MoveToFront(elem)
+	}
+	return found
+}
+
+func (sv *SigVerifier) insert(key [sha256.Size]byte) {
+	sv.mtx.Lock()
+	defer sv.mtx.Unlock()
+	if _, found := sv.cache[key]; found {
+		return
+	}
+	sv.cache[key] = sv.lru.PushFront(key)
+	if sv.lru.Len() > sv.cacheSize {
+		oldest := sv.lru.Back()
+		sv.lru.Remove(oldest)
+		delete(sv.cache, oldest.Value.([sha256.Size]byte))
+	}
+}
+
+// public key and signature have fixed sizes for a key type, so the tuple is encoded without ambiguity
+func sigCacheKey(sig *Signature, msg []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte{byte(sig.pubKey.keyType)})
+	h.Write(sig.pubKey.key)
+	h.Write(sig.data.Value)
+	h.Write(msg)
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+	return key
+}
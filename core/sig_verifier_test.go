@@ -0,0 +1,85 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSigs(n int, msg []byte) []*Signature {
+	sigs := make([]*Signature, n)
+	for i := range sigs {
+		sigs[i] = GenerateKey(nil).Sign(msg)
+	}
+	return sigs
+}
+
+func TestSigVerifier_Verify(t *testing.T) {
+	asrt := assert.New(t)
+
+	sv := NewSigVerifier(1, 2)
+	msg := []byte("hello")
+	sigs := newTestSigs(3, msg)
+
+	asrt.True(sv.Verify(sigs[0], msg))
+	asrt.True(sv.Verify(sigs[0], msg))
+	hits, misses := sv.CacheStats()
+	asrt.EqualValues(1, hits)
+	asrt.EqualValues(1, misses)
+
+	asrt.False(sv.Verify(sigs[0], []byte("world")))
+	asrt.False(sv.Verify(sigs[1], []byte("world")), "invalid signature is not cached")
+	asrt.False(sv.Verify(sigs[1], []byte("world")))
+	asrt.Equal(1, sv.lru.Len())
+
+	asrt.True(sv.Verify(sigs[1], msg))
+	asrt.True(sv.Verify(sigs[2], msg))
+	asrt.Equal(2, sv.lru.Len(), "cache is bounded")
+	_, found := sv.cache[sigCacheKey(sigs[0], msg)]
+	asrt.False(found, "least recently used is evicted")
+
+	// signature with extra bytes must not match the cached one
+	long := GenerateKey(nil).Sign(msg)
+	long.pubKey = sigs[1].pubKey
+	long.data.Value = append(append([]byte{}, sigs[1].data.Value...), msg[0])
+	asrt.False(sv.Verify(long, msg[1:]))
+
+	asrt.True(NewSigVerifier(1, 0).Verify(sigs[0], msg), "cache disabled")
+}
+
+func TestSigVerifier_VerifyAll(t *testing.T) {
+	asrt := assert.New(t)
+
+	msg := []byte("hello")
+	sigs := newTestSigs(20, msg)
+	for _, sv := range []*SigVerifier{NewSigVerifier(1, 100), NewSigVerifier(4, 100)} {
+		asrt.True(sv.VerifyAll(sigs, msg))
+		asrt.True(sv.VerifyAll(sigs, msg))
+		hits, _ := sv.CacheStats()
+		asrt.EqualValues(len(sigs), hits)
+
+		invalid := append(newTestSigs(19, msg), GenerateKey(nil).Sign([]byte("world")))
+		asrt.False(sv.VerifyAll(invalid, msg))
+		asrt.True(sv.VerifyAll(nil, msg))
+	}
+}
+
+func benchmarkVerifyAll(b *testing.B, workers, cacheSize int) {
+	msg := []byte("block hash")
+	sigs := newTestSigs(64, msg)
+	sv := NewSigVerifier(workers, cacheSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sv.VerifyAll(sigs, msg)
+	}
+}
+
+func BenchmarkSigVerifier_Sequential(b *testing.B) { benchmarkVerifyAll(b, 1, 0) }
+
+func BenchmarkSigVerifier_Parallel(b *testing.B) { benchmarkVerifyAll(b, runtime.GOMAXPROCS(0), 0) }
+
+func BenchmarkSigVerifier_Cached(b *testing.B) { benchmarkVerifyAll(b, runtime.GOMAXPROCS(0), 1000) }
@@ -0,0 +1,194 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// SignVersion selects how the signed message is derived from the hash of a payload
+type SignVersion uint32
+
+const (
+	// SignLegacy signs the raw payload hash, kept for chains created before domain separation
+	SignLegacy SignVersion = iota
+	// SignDomain signs the payload hash prefixed with the message type and the chain id hash
+	SignDomain
+)
+
+// NeverSignDomain is the activation height of a chain which keeps signing with SignLegacy
+const NeverSignDomain uint64 = math.MaxUint64
+
+// heights around the activation where messages without a height are accepted in both versions,
+// batches, transactions and timeouts are signed before it is known which block includes them
+const signSwitchWindow = 100
+
+// SignDomainTag identifies the kind of a signed message
+type SignDomainTag byte
+
+// domain tags, a block signature is also the vote of its proposer
+const (
+	DomainBlock SignDomainTag = iota + 1 // blocks and votes
+	DomainBatch                          // batch headers and batch votes
+	DomainTx
+	DomainTimeout
+	DomainNewView
+	DomainCheckpoint
+)
+
+// SignScheme derives the signed messages of one chain, it switches from SignLegacy
+// to SignDomain at the activation height. A nil scheme always signs with SignLegacy.
+type SignScheme struct {
+	chainHash    []byte
+	domainHeight uint64        // first block height signed with SignDomain
+	height       atomic.Uint64 // committed height, the context of messages without a height
+}
+
+// NewSignScheme creates the sign scheme of a chain, domainHeight 0 signs every message with SignDomain
+func NewSignScheme(chainID int64, domainHeight uint64) *SignScheme {
+	h := sha3.New256()
+	h.Write([]byte("ppov-chain"))
+	binary.Write(h, binary.BigEndian, chainID)
+	return &SignScheme{
+		chainHash:    h.Sum(nil),
+		domainHeight: domainHeight,
+	}
+}
+
+// NewSignSchemeOf creates the sign scheme of a chain created with the version,
+// a legacy chain switches to SignDomain at domainHeight unless it is 0
+func NewSignSchemeOf(version SignVersion, chainID int64, domainHeight uint64) (*SignScheme, error) {
+	switch version {
+	case SignDomain:
+		return NewSignScheme(chainID, 0), nil
+	case SignLegacy:
+		if domainHeight == 0 {
+			domainHeight = NeverSignDomain
+		}
+		return NewSignScheme(chainID, domainHeight), nil
+	default:
+		return nil, fmt.Errorf("unknown sign version %d", version)
+	}
+}
+
+// SetHeight sets the committed height of the chain
+func (s *SignScheme) SetHeight(height uint64) {
+	if s != nil {
+		s.height.Store(height)
+	}
+}
+
+// DomainHeight returns the first block height signed with SignDomain
+func (s *SignScheme) DomainHeight() uint64 {
+	if s == nil {
+		return NeverSignDomain
+	}
+	return s.domainHeight
+}
+
+// Version returns the version of the messages of the block at height
+func (s *SignScheme) Version(height uint64) SignVersion {
+	if s == nil || height < s.domainHeight {
+		return SignLegacy
+	}
+	return SignDomain
+}
+
+// CurrentVersion returns the version of the messages without a height, they are signed for the next block
+func (s *SignScheme) CurrentVersion() SignVersion {
+	return s.Version(s.nextHeight())
+}
+
+func (s *SignScheme) nextHeight() uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.height.Load() + 1
+}
+
+// versionsAround returns the versions accepted for the messages without a height at height
+func (s *SignScheme) versionsAround(height uint64) []SignVersion {
+	if s == nil {
+		return []SignVersion{SignLegacy}
+	}
+	if s.domainHeight == 0 {
+		return []SignVersion{SignDomain} // created with SignDomain, no legacy messages
+	}
+	ret := make([]SignVersion, 0, 2)
+	if height < s.domainHeight || height-s.domainHeight < signSwitchWindow {
+		ret = append(ret, SignLegacy)
+	}
+	if height >= s.domainHeight || s.domainHeight-height <= signSwitchWindow {
+		ret = append(ret, SignDomain)
+	}
+	return ret
+}
+
+// message returns the message signed by validators and clients for the payload hash
+func (s *SignScheme) message(version SignVersion, tag SignDomainTag, hash []byte) []byte {
+	if s == nil || version == SignLegacy {
+		return hash
+	}
+	h := sha3.New256()
+	h.Write([]byte{byte(version), byte(tag)})
+	h.Write(s.chainHash)
+	h.Write(hash)
+	return h.Sum(nil)
+}
+
+// verifyAt checks the signature of a message belonging to the block at height
+func (s *SignScheme) verifyAt(sig *Signature, tag SignDomainTag, hash []byte, height uint64) bool {
+	return sig.Verify(s.message(s.Version(height), tag, hash))
+}
+
+// verifyAround checks the signature of a message without a height in the context of height
+func (s *SignScheme) verifyAround(sig *Signature, tag SignDomainTag, hash []byte, height uint64) bool {
+	for _, version := range s.versionsAround(height) {
+		if sig.Verify(s.message(version, tag, hash)) {
+			return true
+		}
+	}
+	return false
+}
+
+// messagesAround returns the messages of the versions accepted at height
+func (s *SignScheme) messagesAround(tag SignDomainTag, hash []byte, height uint64) [][]byte {
+	versions := s.versionsAround(height)
+	msgs := make([][]byte, len(versions))
+	for i, version := range versions {
+		msgs[i] = s.message(version, tag, hash)
+	}
+	return msgs
+}
+
+// ChainSigner signs the messages of a chain with its sign scheme
+type ChainSigner struct {
+	signer Signer
+	scheme *SignScheme
+}
+
+var _ Signer = (*ChainSigner)(nil)
+
+// NewChainSigner binds the signer to the sign scheme of a chain
+func NewChainSigner(signer Signer, scheme *SignScheme) *ChainSigner {
+	return &ChainSigner{signer: signer, scheme: scheme}
+}
+
+// Sign signs a raw message with the underlying signer
+func (cs *ChainSigner) Sign(msg []byte) *Signature { return cs.signer.Sign(msg) }
+func (cs *ChainSigner) PublicKey() *PublicKey      { return cs.signer.PublicKey() }
+func (cs *ChainSigner) SignScheme() *SignScheme    { return cs.scheme }
+
+// signerScheme returns the underlying signer and its scheme, a bare signer has the nil scheme
+func signerScheme(signer Signer) (Signer, *SignScheme) {
+	if cs, ok := signer.(*ChainSigner); ok {
+		return cs.signer, cs.scheme
+	}
+	return signer, nil
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+func TestSignScheme(t *testing.T) {
+	asrt := assert.New(t)
+
+	priv := GenerateKey(nil)
+	vs := new(MockValidatorStore)
+	vs.On("IsVoter", mock.Anything).Return(true)
+	vs.On("IsWorker", mock.Anything).Return(true)
+
+	// a tx signature reused as a vote on the same hash
+	replayVote := func(tx *Transaction) *Vote {
+		vote := NewVote()
+		vote.setData(&pb.Vote{
+			BlockHash: tx.Hash(),
+			Signature: &pb.Signature{PubKey: priv.PublicKey().Bytes(), Value: tx.data.Signature},
+		})
+		return vote
+	}
+
+	_, err := NewSignSchemeOf(SignDomain+1, 1, 0)
+	asrt.Error(err)
+
+	legacy, err := NewSignSchemeOf(SignLegacy, 1, 0)
+	asrt.NoError(err)
+	asrt.Equal(NeverSignDomain, legacy.DomainHeight())
+	legacyTx := NewTransaction().SetNonce(1).Sign(NewChainSigner(priv, legacy))
+	asrt.NoError(legacyTx.Validate(legacy))
+	vs.scheme = legacy
+	asrt.NoError(replayVote(legacyTx).Validate(vs), "legacy signatures are not separated")
+
+	// two chains in the same process
+	chain1, err := NewSignSchemeOf(SignDomain, 1, 0)
+	asrt.NoError(err)
+	chain2 := NewSignScheme(2, 0)
+	asrt.Equal(SignDomain, chain1.CurrentVersion())
+	asrt.Error(legacyTx.Validate(chain1), "legacy signature on domain chain")
+	tx := NewTransaction().SetNonce(1).Sign(NewChainSigner(priv, chain1))
+	asrt.NoError(tx.Validate(chain1))
+	asrt.Error(tx.Validate(chain2), "signature bound to chain id")
+	vs.scheme = chain1
+	asrt.Error(replayVote(tx).Validate(vs), "signature bound to message type")
+
+	blk := NewBlock().SetHeight(0).Sign(NewChainSigner(priv, chain1))
+	asrt.NoError(blk.Validate(vs))
+	asrt.NoError(blk.ProposerVote().Validate(vs), "block signature is the proposer vote")
+	vs.scheme = chain2
+	asrt.Error(blk.Validate(vs))
+}
+
+func TestSignScheme_switchAtHeight(t *testing.T) {
+	asrt := assert.New(t)
+
+	priv := GenerateKey(nil)
+	scheme := NewSignScheme(1, 1000)
+	signer := NewChainSigner(priv, scheme)
+	vs := new(MockValidatorStore)
+	vs.On("IsVoter", mock.Anything).Return(true)
+	vs.On("IsWorker", mock.Anything).Return(true)
+	vs.scheme = scheme
+
+	asrt.Equal(SignLegacy, scheme.Version(999))
+	asrt.Equal(SignDomain, scheme.Version(1000))
+
+	// blocks and votes are signed with the version at their own height
+	b1 := NewBlock().SetHeight(999).Sign(signer)
+	b2 := NewBlock().SetHeight(1000).Sign(signer)
+	asrt.Equal(priv.Sign(b1.Hash()).Value(), b1.data.Signature)
+	asrt.NotEqual(priv.Sign(b2.Hash()).Value(), b2.data.Signature)
+	asrt.NoError(b1.Vote(signer).Validate(vs))
+	asrt.NoError(b2.Vote(signer).Validate(vs))
+	asrt.Error(NewBlock().SetHeight(1000).Sign(priv).ProposerVote().Validate(vs), "legacy vote after the switch")
+
+	// messages without a height follow the committed height
+	scheme.SetHeight(998)
+	legacyTx := NewTransaction().SetNonce(1).Sign(signer)
+	asrt.NoError(legacyTx.Validate(scheme))
+	scheme.SetHeight(999)
+	domainTx := NewTransaction().SetNonce(1).Sign(signer)
+	asrt.NotEqual(legacyTx.data.Signature, domainTx.data.Signature)
+	asrt.NoError(legacyTx.Validate(scheme), "accepted around the switch")
+	asrt.NoError(domainTx.Validate(scheme))
+
+	scheme.SetHeight(999 + signSwitchWindow)
+	asrt.Error(legacyTx.Validate(scheme), "rejected after the window")
+	asrt.NoError(domainTx.Validate(scheme))
+}
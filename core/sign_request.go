@@ -0,0 +1,158 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+// errors
+var (
+	ErrUnguardedDomain = errors.New("domain is not guarded against double signing")
+	ErrUnmatchedSign   = errors.New("sign request payload not matched with hash")
+	ErrNotProposer     = errors.New("proposal signer is not the block proposer")
+)
+
+// SignRequest is what a signer is asked to sign. A remote signer derives the signed message
+// from it instead of signing an opaque message, so it can apply its own signing rules.
+type SignRequest struct {
+	Domain   SignDomainTag
+	Hash     []byte // payload hash of the domain
+	Payload  []byte // hashed fields of the block or checkpoint for the guarded domains
+	Version  SignVersion
+	Scheme   *SignScheme // sign scheme of the chain, nil for SignLegacy
+	Proposal bool        // the block is signed by its proposer, otherwise it is voted
+}
+
+// RequestSigner is a Signer which may refuse a request
+type RequestSigner interface {
+	Signer
+	SignRequest(req *SignRequest) (*Signature, error)
+}
+
+// Message returns the message signed for the request
+func (req *SignRequest) Message() []byte {
+	return req.Scheme.message(req.Version, req.Domain, req.Hash)
+}
+
+// Guarded reports whether the signatures of the domain count as votes at a height,
+// a block signature is also the vote of its proposer
+func (req *SignRequest) Guarded() bool {
+	return req.Domain == DomainBlock || req.Domain == DomainCheckpoint
+}
+
+// PayloadHeight checks the hash is derived from the payload of a guarded request
+// and returns the height of the block or checkpoint
+func (req *SignRequest) PayloadHeight() (uint64, error) {
+	var height uint64
+	var hash []byte
+	switch req.Domain {
+	case DomainBlock:
+		data := new(pb.Block)
+		if err := proto.Unmarshal(req.Payload, data); err != nil {
+			return 0, err
+		}
+		height, hash = data.Height, (&Block{data: data}).Sum()
+
+	case DomainCheckpoint:
+		cp := new(pb.Checkpoint)
+		if err := proto.Unmarshal(req.Payload, cp); err != nil {
+			return 0, err
+		}
+		if err := validateCheckpoint(cp); err != nil {
+			return 0, err
+		}
+		height, hash = cp.Height, checkpointSum(cp)
+
+	default:
+		return 0, ErrUnguardedDomain
+	}
+	if !bytes.Equal(hash, req.Hash) {
+		return 0, ErrUnmatchedSign
+	}
+	return height, nil
+}
+
+// CheckProposer checks the block of a proposal request is proposed by the signer
+func (req *SignRequest) CheckProposer(pubKey *PublicKey) error {
+	data := new(pb.Block)
+	if err := proto.Unmarshal(req.Payload, data); err != nil {
+		return err
+	}
+	if !bytes.Equal(data.Proposer, pubKey.Bytes()) {
+		return ErrNotProposer
+	}
+	return nil
+}
+
+// signRequest signs with the request if the signer supports it, otherwise signs the message,
+// the version is the one of the block at height
+func signRequest(signer Signer, req *SignRequest, height uint64) (*Signature, error) {
+	signer, req.Scheme = signerScheme(signer)
+	req.Version = req.Scheme.Version(height)
+	rs, ok := signer.(RequestSigner)
+	if !ok {
+		return signer.Sign(req.Message()), nil
+	}
+	sig, err := rs.SignRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if !sig.PublicKey().Equal(signer.PublicKey()) || !sig.Verify(req.Message()) {
+		return nil, fmt.Errorf("signer returned %w", ErrInvalidSig)
+	}
+	return sig, nil
+}
+
+// signHash signs the payload hash of an unguarded domain,
+// a refused request results in an empty signature which never passes validation
+func signHash(signer Signer, tag SignDomainTag, hash []byte) *Signature {
+	_, scheme := signerScheme(signer)
+	sig, err := signRequest(signer, &SignRequest{Domain: tag, Hash: hash}, scheme.nextHeight())
+	if err != nil {
+		return &Signature{
+			data:   &pb.Signature{PubKey: signer.PublicKey().Bytes()},
+			pubKey: signer.PublicKey(),
+		}
+	}
+	return sig
+}
+
+// blockPayload keeps only the fields of the block hash
+func blockPayload(data *pb.Block) ([]byte, error) {
+	headers := make([]*pb.BatchHeader, len(data.BatchHeaders))
+	for i, header := range data.BatchHeaders {
+		headers[i] = &pb.BatchHeader{Hash: header.Hash}
+	}
+	var qc *pb.QuorumCert
+	if data.QuorumCert != nil {
+		qc = &pb.QuorumCert{BlockHash: data.QuorumCert.BlockHash}
+	}
+	return proto.Marshal(&pb.Block{
+		Height:       data.Height,
+		ParentHash:   data.ParentHash,
+		Proposer:     data.Proposer,
+		QuorumCert:   qc,
+		ExecHeight:   data.ExecHeight,
+		MerkleRoot:   data.MerkleRoot,
+		Timestamp:    data.Timestamp,
+		BatchHeaders: headers,
+	})
+}
+
+func signBlockHash(signer Signer, data *pb.Block, proposal bool) (*Signature, error) {
+	payload, err := blockPayload(data)
+	if err != nil {
+		return nil, err
+	}
+	return signRequest(signer, &SignRequest{
+		Domain: DomainBlock, Hash: data.Hash, Payload: payload, Proposal: proposal,
+	}, data.Height)
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRequestSigner struct {
+	*PrivateKey
+	reqs   []*SignRequest
+	refuse bool
+}
+
+func (s *testRequestSigner) SignRequest(req *SignRequest) (*Signature, error) {
+	s.reqs = append(s.reqs, req)
+	if s.refuse {
+		return nil, errors.New("refused")
+	}
+	return s.PrivateKey.Sign(req.Message()), nil
+}
+
+func TestSignRequest(t *testing.T) {
+	asrt := assert.New(t)
+
+	signer := &testRequestSigner{PrivateKey: GenerateKey(nil)}
+	blk := NewBlock().SetHeight(3).SetQuorumCert(NewQuorumCert()).SetTransactions([][]byte{{1}})
+	_, err := blk.TrySign(signer)
+	asrt.NoError(err)
+	asrt.NoError(blk.ProposerVote().Validate(NewValidatorStore(
+		[]string{signer.PublicKey().String()}, []string{signer.PublicKey().String()})))
+
+	req := signer.reqs[0]
+	asrt.True(req.Guarded())
+	asrt.True(req.Proposal)
+	asrt.NoError(req.CheckProposer(signer.PublicKey()))
+	asrt.ErrorIs(req.CheckProposer(GenerateKey(nil).PublicKey()), ErrNotProposer)
+	height, err := req.PayloadHeight()
+	asrt.NoError(err)
+	asrt.EqualValues(3, height)
+	b, _ := blk.Marshal()
+	asrt.Less(len(req.Payload), len(b), "only the hashed fields are sent")
+
+	req.Hash = []byte{1}
+	_, err = req.PayloadHeight()
+	asrt.ErrorIs(err, ErrUnmatchedSign)
+
+	tx := NewTransaction().Sign(signer)
+	asrt.NoError(tx.Validate(nil))
+	req = signer.reqs[len(signer.reqs)-1]
+	asrt.False(req.Guarded())
+	_, err = req.PayloadHeight()
+	asrt.ErrorIs(err, ErrUnguardedDomain)
+
+	signer.refuse = true
+	_, err = blk.TryVote(signer)
+	asrt.Error(err)
+	asrt.False(signer.reqs[len(signer.reqs)-1].Proposal)
+	_, err = NewCheckpointVote().TrySign(10, make([]byte, 32), nil, signer)
+	asrt.Error(err)
+	asrt.ErrorIs(NewTransaction().Sign(signer).Validate(nil), ErrInvalidSig)
+}
@@ -0,0 +1,189 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/sha3"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+// errors
+var (
+	ErrNilTimeout = errors.New("nil timeout")
+	ErrNilTC      = errors.New("nil timeout cert")
+)
+
+// timeoutSum returns the message signed by validators who timeout at the view
+func timeoutSum(view uint64) []byte {
+	h := sha3.New256()
+	h.Write([]byte("timeout"))
+	binary.Write(h, binary.BigEndian, view)
+	return h.Sum(nil)
+}
+
+// Timeout type
+type Timeout struct {
+	data   *pb.Timeout
+	sender *PublicKey
+	qcHigh *QuorumCert
+}
+
+func NewTimeout() *Timeout {
+	return &Timeout{
+		data: new(pb.Timeout),
+	}
+}
+
+// Validate timeout
+func (to *Timeout) Validate(vs ValidatorStore) error {
+	if to.data == nil {
+		return ErrNilTimeout
+	}
+	sig, err := newSignature(to.data.Signature)
+	if err != nil {
+		return err
+	}
+	if !(vs.IsVoter(sig.PublicKey()) || vs.IsWorker(sig.PublicKey())) {
+		return ErrInvalidValidator
+	}
+	scheme := vs.SignScheme()
+	if !scheme.verifyAround(sig, DomainTimeout, timeoutSum(to.data.View), scheme.nextHeight()) {
+		return ErrInvalidSig
+	}
+	if to.qcHigh != nil {
+		return to.qcHigh.Validate(vs)
+	}
+	return nil
+}
+
+func (to *Timeout) setData(data *pb.Timeout) error {
+	if data == nil {
+		return ErrNilTimeout
+	}
+	to.data = data
+	sig, err := newSignature(to.data.Signature)
+	if err != nil {
+		return err
+	}
+	to.sender = sig.pubKey
+	to.qcHigh = nil
+	if to.data.QcHigh != nil {
+		to.qcHigh = NewQuorumCert()
+		if err := to.qcHigh.setData(to.data.QcHigh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sign creates a signed timeout of the view with the sender's highest qc
+func (to *Timeout) Sign(view uint64, qcHigh *QuorumCert, signer Signer) *Timeout {
+	to.data.View = view
+	to.qcHigh = qcHigh
+	if qcHigh != nil {
+		to.data.QcHigh = qcHigh.data
+	}
+	sig := signHash(signer, DomainTimeout, timeoutSum(view))
+	to.data.Signature = sig.data
+	to.sender = sig.pubKey
+	return to
+}
+
+func (to *Timeout) View() uint64          { return to.data.View }
+func (to *Timeout) QCHigh() *QuorumCert   { return to.qcHigh }
+func (to *Timeout) Sender() *PublicKey    { return to.sender }
+func (to *Timeout) Signature() *Signature { return &Signature{to.data.Signature, to.sender} }
+
+// Marshal encodes timeout as bytes
+func (to *Timeout) Marshal() ([]byte, error) {
+	return proto.Marshal(to.data)
+}
+
+// Unmarshal decodes timeout from bytes
+func (to *Timeout) Unmarshal(b []byte) error {
+	data := new(pb.Timeout)
+	if err := proto.Unmarshal(b, data); err != nil {
+		return err
+	}
+	return to.setData(data)
+}
+
+// TimeoutCert type, formed by timeouts of the same view from majority validators
+type TimeoutCert struct {
+	data *pb.TimeoutCert
+	sigs sigList
+}
+
+func NewTimeoutCert() *TimeoutCert {
+	return &TimeoutCert{
+		data: new(pb.TimeoutCert),
+	}
+}
+
+func (tc *TimeoutCert) Validate(vs ValidatorStore) error {
+	if tc.data == nil {
+		return ErrNilTC
+	}
+	if tc.sigs.power(vs) < vs.QuorumPower() {
+		return ErrNotEnoughSig
+	}
+	if tc.sigs.hasDuplicate() {
+		return ErrDuplicateSig
+	}
+	if tc.sigs.hasInvalidValidator(vs) {
+		return ErrInvalidValidator
+	}
+	scheme := vs.SignScheme()
+	if tc.sigs.hasInvalidSigIn(scheme.messagesAround(DomainTimeout, timeoutSum(tc.data.View), scheme.nextHeight())) {
+		return ErrInvalidSig
+	}
+	return nil
+}
+
+func (tc *TimeoutCert) setData(data *pb.TimeoutCert) error {
+	if data == nil {
+		return ErrNilTC
+	}
+	tc.data = data
+	sigs, err := newSigList(tc.data.Signatures)
+	if err != nil {
+		return err
+	}
+	tc.sigs = sigs
+	return nil
+}
+
+// Build creates timeout cert from timeouts of the same view
+func (tc *TimeoutCert) Build(timeouts []*Timeout) *TimeoutCert {
+	tc.data.Signatures = make([]*pb.Signature, len(timeouts))
+	tc.sigs = make(sigList, len(timeouts))
+	for i, to := range timeouts {
+		tc.data.View = to.data.View
+		tc.data.Signatures[i] = to.data.Signature
+		tc.sigs[i] = to.Signature()
+	}
+	return tc
+}
+
+func (tc *TimeoutCert) View() uint64             { return tc.data.View }
+func (tc *TimeoutCert) Signatures() []*Signature { return tc.sigs }
+
+// Marshal encodes timeout cert as bytes
+func (tc *TimeoutCert) Marshal() ([]byte, error) {
+	return proto.Marshal(tc.data)
+}
+
+// Unmarshal decodes timeout cert from bytes
+func (tc *TimeoutCert) Unmarshal(b []byte) error {
+	data := new(pb.TimeoutCert)
+	if err := proto.Unmarshal(b, data); err != nil {
+		return err
+	}
+	return tc.setData(data)
+}
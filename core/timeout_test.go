@@ -0,0 +1,137 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/wooyang2018/ppov-blockchain/pb"
+)
+
+func TestTimeout(t *testing.T) {
+	to := &Timeout{
+		data: &pb.Timeout{View: 1},
+	}
+	toNilSig, err := to.Marshal()
+	assert.NoError(t, err)
+
+	validator := GenerateKey(nil)
+	to = NewTimeout().Sign(1, nil, validator)
+	toOk, _ := to.Marshal()
+
+	to.data.View = 2
+	toInvalid, _ := to.Marshal()
+
+	tests := []struct {
+		name         string
+		b            []byte
+		unmarshalErr bool
+		validateErr  bool
+	}{
+		{"valid", toOk, false, false},
+		{"nil timeout", nil, true, true},
+		{"nil signature", toNilSig, true, true},
+		{"invalid view", toInvalid, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			to := NewTimeout()
+			err := to.Unmarshal(tt.b)
+			if tt.unmarshalErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(validator.PublicKey(), to.Sender())
+
+			vs := new(MockValidatorStore)
+			vs.On("IsVoter", mock.Anything).Return(true)
+
+			err = to.Validate(vs)
+			if tt.validateErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestTimeoutCert(t *testing.T) {
+	privKeys := make([]*PrivateKey, 5)
+
+	vs := new(MockValidatorStore)
+	vs.On("QuorumPower").Return(uint64(3))
+	for i := range privKeys {
+		privKeys[i] = GenerateKey(nil)
+		if i != 4 {
+			vs.On("GetPower", privKeys[i].pubKey).Return(uint64(1))
+			vs.On("IsVoter", privKeys[i].pubKey).Return(true)
+		}
+	}
+	vs.On("IsVoter", mock.Anything).Return(false)
+	vs.On("IsWorker", mock.Anything).Return(false)
+	vs.On("GetPower", mock.Anything).Return(uint64(0))
+
+	timeouts := make([]*Timeout, len(privKeys))
+	for i, priv := range privKeys {
+		timeouts[i] = NewTimeout().Sign(5, nil, priv)
+	}
+	otherView := NewTimeout().Sign(4, nil, privKeys[3])
+
+	tc := NewTimeoutCert().Build([]*Timeout{timeouts[0], timeouts[1], timeouts[2]})
+	tcValid, err := tc.Marshal()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, tc.View())
+
+	tc = NewTimeoutCert().Build([]*Timeout{timeouts[0], timeouts[1]})
+	tcNotEnoughSig, _ := tc.Marshal()
+
+	tc = NewTimeoutCert().Build([]*Timeout{timeouts[0], timeouts[1], timeouts[1]})
+	tcDuplicateKey, _ := tc.Marshal()
+
+	tc = NewTimeoutCert().Build([]*Timeout{timeouts[0], timeouts[1], timeouts[4]})
+	tcInvalidValidator, _ := tc.Marshal()
+
+	tc = NewTimeoutCert().Build([]*Timeout{timeouts[0], timeouts[1], otherView})
+	tcInvalidSig, _ := tc.Marshal()
+
+	tests := []struct {
+		name         string
+		b            []byte
+		unmarshalErr bool
+		validateErr  bool
+	}{
+		{"valid", tcValid, false, false},
+		{"nil tc", nil, false, true},
+		{"not enough sig", tcNotEnoughSig, false, true},
+		{"duplicate key", tcDuplicateKey, false, true},
+		{"invalid validator", tcInvalidValidator, false, true},
+		{"different view", tcInvalidSig, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			tc := NewTimeoutCert()
+			err := tc.Unmarshal(tt.b)
+			if tt.unmarshalErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			err = tc.Validate(vs)
+			if tt.validateErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
@@ -46,11 +46,20 @@ func (tx *Transaction) Sum() []byte {
 	h.Write(tx.data.CodeAddr)
 	h.Write(tx.data.Input)
 	binary.Write(h, binary.BigEndian, tx.data.Expiry)
+	// the tag of ed25519 is not hashed, to keep the hashes of existing transactions
+	if tx.data.SenderKeyType != uint32(KeyEd25519) {
+		binary.Write(h, binary.BigEndian, tx.data.SenderKeyType)
+	}
 	return h.Sum(nil)
 }
 
-// Validate transaction
-func (tx *Transaction) Validate() error {
+// Validate transaction with the sign scheme of the chain
+func (tx *Transaction) Validate(scheme *SignScheme) error {
+	return tx.validateAt(scheme, scheme.nextHeight())
+}
+
+// validateAt validates the transaction in the context of the block at height
+func (tx *Transaction) validateAt(scheme *SignScheme, height uint64) error {
 	if tx.data == nil {
 		return ErrNilTx
 	}
@@ -58,13 +67,14 @@ func (tx *Transaction) Validate() error {
 		return ErrInvalidTxHash
 	}
 	sig, err := newSignature(&pb.Signature{
-		PubKey: tx.data.Sender,
-		Value:  tx.data.Signature,
+		PubKey:  tx.data.Sender,
+		Value:   tx.data.Signature,
+		KeyType: tx.data.SenderKeyType,
 	})
 	if err != nil {
 		return err
 	}
-	if !sig.Verify(tx.data.Hash) {
+	if !scheme.verifyAround(sig, DomainTx, tx.data.Hash, height) {
 		return ErrInvalidSig
 	}
 	return nil
@@ -73,7 +83,7 @@ func (tx *Transaction) Validate() error {
 func (tx *Transaction) setData(data *pb.Transaction) error {
 	tx.data = data
 	var err error
-	tx.sender, err = NewPublicKey(tx.data.Sender)
+	tx.sender, err = NewTypedPublicKey(KeyType(tx.data.SenderKeyType), tx.data.Sender)
 	return err
 }
 
@@ -100,8 +110,9 @@ func (tx *Transaction) SetExpiry(val uint64) *Transaction {
 func (tx *Transaction) Sign(signer Signer) *Transaction {
 	tx.sender = signer.PublicKey()
 	tx.data.Sender = signer.PublicKey().key
+	tx.data.SenderKeyType = uint32(signer.PublicKey().keyType)
 	tx.data.Hash = tx.Sum()
-	tx.data.Signature = signer.Sign(tx.data.Hash).data.Value
+	tx.data.Signature = signHash(signer, DomainTx, tx.data.Hash).data.Value
 	return tx
 }
 
@@ -117,6 +128,11 @@ func (tx *Transaction) Marshal() ([]byte, error) {
 	return proto.Marshal(tx.data)
 }
 
+// Size returns the encoded size in bytes
+func (tx *Transaction) Size() int {
+	return proto.Size(tx.data)
+}
+
 // Unmarshal decodes transaction from bytes
 func (tx *Transaction) Unmarshal(b []byte) error {
 	data := new(pb.Transaction)
@@ -31,7 +31,7 @@ func TestTransaction(t *testing.T) {
 	assert.Equal(privKey.PublicKey(), tx.Sender())
 	assert.Equal(privKey.PublicKey().Bytes(), tx.data.Sender)
 
-	assert.NoError(tx.Validate())
+	assert.NoError(tx.Validate(nil))
 
 	b, err := tx.Marshal()
 	assert.NoError(err)
@@ -40,7 +40,7 @@ func TestTransaction(t *testing.T) {
 	err = tx.Unmarshal(b)
 	assert.NoError(err)
 
-	assert.NoError(tx.Validate())
+	assert.NoError(tx.Validate(nil))
 
 	b, err = json.Marshal(tx)
 	assert.NoError(err)
@@ -49,7 +49,36 @@ func TestTransaction(t *testing.T) {
 	err = json.Unmarshal(b, tx)
 	assert.NoError(err)
 
-	assert.NoError(tx.Validate())
+	assert.NoError(tx.Validate(nil))
+}
+
+func TestTransaction_Secp256k1(t *testing.T) {
+	asrt := assert.New(t)
+	privKey, err := GenerateTypedKey(KeySecp256k1, nil)
+	asrt.NoError(err)
+
+	tx := NewTransaction().SetNonce(1).SetInput([]byte{2}).Sign(privKey)
+	asrt.True(privKey.PublicKey().Equal(tx.Sender()))
+	asrt.EqualValues(KeySecp256k1, tx.data.SenderKeyType)
+	asrt.NoError(tx.Validate(nil))
+
+	b, err := tx.Marshal()
+	asrt.NoError(err)
+	tx = NewTransaction()
+	asrt.NoError(tx.Unmarshal(b))
+	asrt.NoError(tx.Validate(nil))
+	asrt.Equal(KeySecp256k1, tx.Sender().Type())
+
+	b, err = json.Marshal(tx)
+	asrt.NoError(err)
+	tx = NewTransaction()
+	asrt.NoError(json.Unmarshal(b, tx))
+	asrt.NoError(tx.Validate(nil))
+
+	// the key type is hashed, so it can't be changed without the sender's signature
+	hash := tx.Sum()
+	tx.data.SenderKeyType = uint32(KeyEd25519)
+	asrt.NotEqual(hash, tx.Sum())
 }
 
 func TestTxList(t *testing.T) {
@@ -13,18 +13,27 @@ import (
 
 // ValidatorStore godoc
 type ValidatorStore interface {
-	VoterCount() int                      //返回投票节点数量
-	MajorityVoterCount() int              //返回大多数投票节点数量
-	WorkerCount() int                     //返回记账节点数量
-	EnoughWorkerCount() int               //返回足够记账节点数量
-	ValidatorCount() int                  //返回验证节点数量
-	MajorityValidatorCount() int          //返回大多数验证节点数量
-	IsVoter(pubKey *PublicKey) bool       //返回指定公钥是否投票节点
-	IsWorker(pubKey *PublicKey) bool      //返回指定公钥是否记账节点
-	GetVoter(idx int) *PublicKey          //获取指定索引的投票节点
-	GetWorker(idx int) *PublicKey         //获取指定索引的记账节点
-	GetVoterIndex(pubKey *PublicKey) int  //获取指定公钥的投票节点的索引
-	GetWorkerIndex(pubKey *PublicKey) int //获取指定公钥的记账节点的索引
+	VoterCount() int                         //返回投票节点数量
+	MajorityVoterCount() int                 //返回大多数投票节点数量
+	WorkerCount() int                        //返回记账节点数量
+	EnoughWorkerCount() int                  //返回足够记账节点数量
+	ValidatorCount() int                     //返回验证节点数量
+	MajorityValidatorCount() int             //返回大多数验证节点数量
+	IsVoter(pubKey *PublicKey) bool          //返回指定公钥是否投票节点
+	IsWorker(pubKey *PublicKey) bool         //返回指定公钥是否记账节点
+	GetVoter(idx int) *PublicKey             //获取指定索引的投票节点
+	GetWorker(idx int) *PublicKey            //获取指定索引的记账节点
+	GetVoterIndex(pubKey *PublicKey) int     //获取指定公钥的投票节点的索引
+	GetWorkerIndex(pubKey *PublicKey) int    //获取指定公钥的记账节点的索引
+	GetValidator(idx int) *PublicKey         //获取指定索引的验证节点, 记账节点在前, 其余投票节点在后
+	GetValidatorIndex(pubKey *PublicKey) int //获取指定公钥的验证节点的索引, 非验证节点为-1
+	AtHeight(height uint64) ValidatorStore   //返回指定区块高度生效的验证节点集合
+	GetPower(pubKey *PublicKey) uint64       //返回指定节点的投票权重，非验证节点为0
+	TotalPower() uint64                      //返回验证节点的总投票权重
+	QuorumPower() uint64                     //返回验证节点达成共识所需的投票权重
+	TotalVoterPower() uint64                 //返回投票节点的总投票权重
+	QuorumVoterPower() uint64                //返回投票节点达成共识所需的投票权重
+	SignScheme() *SignScheme                 //返回所在链的签名方案, nil表示签名原始哈希
 }
 
 type validatorStore struct {
@@ -35,7 +44,14 @@ type validatorStore struct {
 	voterMap  map[string]int
 	workerMap map[string]int
 
-	validators []*PublicKey //投票节点和记账节点的集合
+	validators   []*PublicKey //投票节点和记账节点的集合
+	validatorMap map[string]int
+
+	powers     map[string]uint64 //验证节点的投票权重
+	totalPower uint64
+	voterPower uint64
+
+	scheme *SignScheme
 }
 
 var _ ValidatorStore = (*validatorStore)(nil)
@@ -50,6 +66,12 @@ func StringToPubKey(v string) *PublicKey {
 }
 
 func NewValidatorStore(workers []string, voters []string) ValidatorStore {
+	return NewWeightedValidatorStore(workers, voters, nil)
+}
+
+// NewWeightedValidatorStore creates a validator store with voting powers,
+// validators not in powers have the power of 1
+func NewWeightedValidatorStore(workers []string, voters []string, powers map[string]uint64) ValidatorStore {
 	set := make(map[string]*PublicKey)
 	for _, v := range workers {
 		set[v] = StringToPubKey(v)
@@ -61,9 +83,16 @@ func NewValidatorStore(workers []string, voters []string) ValidatorStore {
 	}
 
 	store := &validatorStore{}
+	// validators are ordered to index the signers of compact quorum certs
 	store.validators = make([]*PublicKey, 0, len(set))
-	for _, v := range set {
-		store.validators = append(store.validators, v)
+	store.validatorMap = make(map[string]int, len(set))
+	for _, keys := range [][]string{workers, voters} {
+		for _, v := range keys {
+			if _, ok := store.validatorMap[v]; !ok {
+				store.validatorMap[v] = len(store.validators)
+				store.validators = append(store.validators, set[v])
+			}
+		}
 	}
 
 	store.voters = make([]*PublicKey, len(voters))
@@ -86,9 +115,34 @@ func NewValidatorStore(workers []string, voters []string) ValidatorStore {
 		store.workerMap[v.String()] = i
 	}
 
+	store.powers = make(map[string]uint64, len(set))
+	for k := range set {
+		power, ok := powers[k]
+		if !ok {
+			power = 1
+		}
+		if power == 0 {
+			logger.I().Fatalw("invalid voting power", "validator", k)
+		}
+		store.powers[k] = power
+		store.totalPower += power
+	}
+	for _, v := range voters {
+		store.voterPower += store.powers[v]
+	}
+
 	return store
 }
 
+// AtHeight returns itself, the validator set never changes
+func (store *validatorStore) AtHeight(height uint64) ValidatorStore {
+	return store
+}
+
+func (store *validatorStore) SignScheme() *SignScheme {
+	return store.scheme
+}
+
 func (store *validatorStore) VoterCount() int {
 	return len(store.voters)
 }
@@ -157,6 +211,52 @@ func (store *validatorStore) GetWorkerIndex(pubKey *PublicKey) int {
 	return store.workerMap[pubKey.String()]
 }
 
+func (store *validatorStore) GetValidator(idx int) *PublicKey {
+	if idx >= len(store.validators) || idx < 0 {
+		return nil
+	}
+	return store.validators[idx]
+}
+
+func (store *validatorStore) GetValidatorIndex(pubKey *PublicKey) int {
+	if pubKey == nil {
+		return -1
+	}
+	if idx, ok := store.validatorMap[pubKey.String()]; ok {
+		return idx
+	}
+	return -1
+}
+
+func (store *validatorStore) GetPower(pubKey *PublicKey) uint64 {
+	if pubKey == nil {
+		return 0
+	}
+	return store.powers[pubKey.String()]
+}
+
+func (store *validatorStore) TotalPower() uint64 {
+	return store.totalPower
+}
+
+func (store *validatorStore) QuorumPower() uint64 {
+	return MajorityPower(store.totalPower)
+}
+
+func (store *validatorStore) TotalVoterPower() uint64 {
+	return store.voterPower
+}
+
+func (store *validatorStore) QuorumVoterPower() uint64 {
+	return MajorityPower(store.voterPower)
+}
+
+// MajorityPower returns the minimum power more than two thirds of the total,
+// it equals to MajorityCount when every validator has the power of 1
+func MajorityPower(totalPower uint64) uint64 {
+	return totalPower*2/3 + 1
+}
+
 // MajorityCount returns 2f + 1 members
 func MajorityCount(validatorCount int) int {
 	// n=3f+1 -> f=floor((n-1)3) -> m=n-f -> m=ceil((2n+1)/3)
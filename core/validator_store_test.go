@@ -13,6 +13,7 @@ import (
 
 type MockValidatorStore struct {
 	mock.Mock
+	scheme *SignScheme
 }
 
 var _ ValidatorStore = (*MockValidatorStore)(nil)
@@ -85,6 +86,53 @@ func (m *MockValidatorStore) GetWorkerIndex(pubKey *PublicKey) int {
 	return args.Int(0)
 }
 
+func (m *MockValidatorStore) GetValidator(idx int) *PublicKey {
+	args := m.Called(idx)
+	val := args.Get(0)
+	if val == nil {
+		return nil
+	}
+	return val.(*PublicKey)
+}
+
+func (m *MockValidatorStore) GetValidatorIndex(pubKey *PublicKey) int {
+	args := m.Called(pubKey)
+	return args.Int(0)
+}
+
+func (m *MockValidatorStore) GetPower(pubKey *PublicKey) uint64 {
+	args := m.Called(pubKey)
+	return args.Get(0).(uint64)
+}
+
+func (m *MockValidatorStore) TotalPower() uint64 {
+	args := m.Called()
+	return args.Get(0).(uint64)
+}
+
+func (m *MockValidatorStore) QuorumPower() uint64 {
+	args := m.Called()
+	return args.Get(0).(uint64)
+}
+
+func (m *MockValidatorStore) TotalVoterPower() uint64 {
+	args := m.Called()
+	return args.Get(0).(uint64)
+}
+
+func (m *MockValidatorStore) QuorumVoterPower() uint64 {
+	args := m.Called()
+	return args.Get(0).(uint64)
+}
+
+func (m *MockValidatorStore) AtHeight(height uint64) ValidatorStore {
+	return m
+}
+
+func (m *MockValidatorStore) SignScheme() *SignScheme {
+	return m.scheme
+}
+
 func TestMajorityCount(t *testing.T) {
 	type args struct {
 		validatorCount int
@@ -107,3 +155,39 @@ func TestMajorityCount(t *testing.T) {
 		})
 	}
 }
+
+func TestMajorityPower(t *testing.T) {
+	// unweighted chains must keep the same quorum
+	for n := 1; n <= 30; n++ {
+		assert.EqualValues(t, MajorityCount(n), MajorityPower(uint64(n)), "count %d", n)
+	}
+}
+
+func TestWeightedValidatorStore(t *testing.T) {
+	asrt := assert.New(t)
+
+	privs := []*PrivateKey{GenerateKey(nil), GenerateKey(nil), GenerateKey(nil), GenerateKey(nil)}
+	keys := make([]string, len(privs))
+	for i, priv := range privs {
+		keys[i] = priv.PublicKey().String()
+	}
+	powers := map[string]uint64{keys[0]: 5, keys[3]: 2}
+	vs := NewWeightedValidatorStore(keys[:2], keys[1:], powers)
+
+	asrt.EqualValues(5, vs.GetPower(privs[0].PublicKey()))
+	asrt.EqualValues(1, vs.GetPower(privs[1].PublicKey()))
+	asrt.EqualValues(0, vs.GetPower(GenerateKey(nil).PublicKey()))
+	asrt.EqualValues(0, vs.GetPower(nil))
+	asrt.EqualValues(9, vs.TotalPower())
+	asrt.EqualValues(7, vs.QuorumPower())
+	asrt.EqualValues(4, vs.TotalVoterPower())
+	asrt.EqualValues(3, vs.QuorumVoterPower())
+
+	// workers come first in the validator order
+	for i, priv := range privs {
+		asrt.Equal(i, vs.GetValidatorIndex(priv.PublicKey()))
+		asrt.Equal(priv.PublicKey(), vs.GetValidator(i))
+	}
+	asrt.Equal(-1, vs.GetValidatorIndex(GenerateKey(nil).PublicKey()))
+	asrt.Nil(vs.GetValidator(len(privs)))
+}
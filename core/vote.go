@@ -7,6 +7,7 @@ package core
 import (
 	"errors"
 
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/wooyang2018/ppov-blockchain/pb"
@@ -41,7 +42,7 @@ func (vote *Vote) Validate(vs ValidatorStore) error {
 	if !(vs.IsVoter(sig.PublicKey()) || vs.IsWorker(sig.PublicKey())) {
 		return ErrInvalidValidator
 	}
-	if !sig.Verify(vote.data.BlockHash) {
+	if !vs.SignScheme().verifyAt(sig, DomainBlock, vote.data.BlockHash, vote.data.BlockHeight) {
 		return ErrInvalidSig
 	}
 	return nil
@@ -57,8 +58,9 @@ func (vote *Vote) setData(data *pb.Vote) error {
 	return nil
 }
 
-func (vote *Vote) BlockHash() []byte { return vote.data.BlockHash }
-func (vote *Vote) Voter() *PublicKey { return vote.voter }
+func (vote *Vote) BlockHash() []byte   { return vote.data.BlockHash }
+func (vote *Vote) BlockHeight() uint64 { return vote.data.BlockHeight }
+func (vote *Vote) Voter() *PublicKey   { return vote.voter }
 
 // Marshal encodes vote as bytes
 func (vote *Vote) Marshal() ([]byte, error) {
@@ -73,3 +75,15 @@ func (vote *Vote) Unmarshal(b []byte) error {
 	}
 	return vote.setData(data)
 }
+
+func (vote *Vote) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(vote.data)
+}
+
+func (vote *Vote) UnmarshalJSON(b []byte) error {
+	data := new(pb.Vote)
+	if err := protojson.Unmarshal(b, data); err != nil {
+		return err
+	}
+	return vote.setData(data)
+}
@@ -33,7 +33,7 @@ type Execution struct {
 }
 
 type StateStore interface {
-	VerifyState(key []byte) []byte
+	VerifyState(key []byte) ([]byte, error)
 	GetState(key []byte) []byte
 }
 
@@ -6,7 +6,8 @@ package execution
 
 // stateVerifier is used for state query calls
 // it calls the VerifyState of state store instead of GetState
-// to verify the state value with the merkle root
+// to verify the state value with the merkle root,
+// a failed verification panics and the query returns it as an error
 type stateVerifier struct {
 	store     StateStore
 	keyPrefix []byte
@@ -21,5 +22,9 @@ func newStateVerifier(store StateStore, prefix []byte) *stateVerifier {
 
 func (sv *stateVerifier) GetState(key []byte) []byte {
 	key = concatBytes(sv.keyPrefix, key)
-	return sv.store.VerifyState(key)
+	value, err := sv.store.VerifyState(key)
+	if err != nil {
+		panic(err)
+	}
+	return value
 }
@@ -5,6 +5,7 @@
 package execution
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -71,6 +72,9 @@ func (txe *txExecutor) executeChaincode() (err error) {
 	if len(txe.tx.CodeAddr()) == 0 {
 		return txe.executeDeployment()
 	}
+	if bytes.Equal(txe.tx.CodeAddr(), core.GovernanceAddr) {
+		return nil // applied by consensus on commit
+	}
 	return txe.executeInvoke()
 }
 
@@ -16,31 +16,80 @@ import (
 // Hotstuff consensus engine
 type Hotstuff struct {
 	*state
-	driver Driver
-	tester *tester
+	driver  Driver
+	tester  *tester
+	variant Variant
 }
 
-func New(driver Driver, file *os.File, b0 Block, q0 QC) *Hotstuff {
-	return &Hotstuff{
-		state:  newState(b0, q0),
-		driver: driver,
-		tester: newTester(file),
+// Option configures hotstuff on creation
+type Option func(hs *Hotstuff)
+
+// WithVariant sets the commit rule, two-phase is used by default
+func WithVariant(variant Variant) Option {
+	return func(hs *Hotstuff) {
+		hs.variant = variant
+	}
+}
+
+func New(driver Driver, file *os.File, b0 Block, q0 QC, opts ...Option) *Hotstuff {
+	hs := &Hotstuff{
+		driver:  driver,
+		tester:  newTester(file),
+		variant: TwoPhase,
+	}
+	for _, opt := range opts {
+		opt(hs)
+	}
+	hs.state = newState(b0, q0, hs.variant)
+	return hs
+}
+
+// Variant returns the commit rule of the hotstuff instance
+func (hs *Hotstuff) Variant() Variant {
+	return hs.variant
+}
+
+// Restore recovers voting state saved before restart, it never moves the state backward
+func (hs *Hotstuff) Restore(bVote, bLock Block, qcHigh QC) {
+	if CmpBlockHeight(bVote, hs.GetBVote()) == 1 {
+		hs.setBVote(bVote)
+	}
+	if CmpBlockHeight(bLock, hs.GetBLock()) == 1 {
+		hs.setBLock(bLock)
 	}
+	hs.UpdateQCHigh(qcHigh)
 }
 
 // OnPropose is called to propose a new block
 func (hs *Hotstuff) OnPropose() Block {
-	bLeaf := hs.GetBLeaf()
+	bLeaf := hs.proposalParent()
 	bNew := hs.driver.CreateLeaf(bLeaf, hs.GetQCHigh(), bLeaf.Height()+1)
 	if bNew == nil {
 		return nil
 	}
 	hs.setBLeaf(bNew)
+	hs.setBVote(bNew) // the proposer votes its block by signing it
 	hs.startProposal(bNew)
 	hs.driver.BroadcastProposal(bNew)
 	return bNew
 }
 
+// proposalParent returns the voted block if it extends b_Leaf, otherwise b_Leaf.
+// After a proposal failed, the next leader may hold a lower b_Leaf than the block it voted,
+// extending the voted block keeps it from signing two blocks at the same height.
+func (hs *Hotstuff) proposalParent() Block {
+	bLeaf, bVote := hs.GetBLeaf(), hs.GetBVote()
+	if CmpBlockHeight(bVote, bLeaf) != 1 {
+		return bLeaf
+	}
+	for b := bVote; CmpBlockHeight(b, bLeaf) != -1; b = b.Parent() {
+		if bLeaf.Equal(b) {
+			return bVote
+		}
+	}
+	return bLeaf
+}
+
 // OnReceiveVote is called when received a vote
 func (hs *Hotstuff) OnReceiveVote(v Vote) {
 	err := hs.addVote(v)
@@ -48,13 +97,28 @@ func (hs *Hotstuff) OnReceiveVote(v Vote) {
 		return
 	}
 	logger.I().Debugw("hotstuff received vote", "height", v.Block().Height())
-	if hs.GetVoteCount() >= hs.driver.MajorityValidatorCount() {
+	if hs.votePower() >= hs.driver.QuorumPower(v.Block()) {
 		votes := hs.GetVotes()
 		hs.endProposal()
 		hs.UpdateQCHigh(hs.driver.CreateQC(votes))
 	}
 }
 
+// CollectVotes starts collecting votes for a block proposed by another leader,
+// it is used when voters forward votes to the leader of the next block
+func (hs *Hotstuff) CollectVotes(b Block) bool {
+	return hs.startCollecting(b)
+}
+
+// votePower accumulates the voting power of the collected votes
+func (hs *Hotstuff) votePower() uint64 {
+	var power uint64
+	for _, v := range hs.GetVotes() {
+		power += hs.driver.VotePower(v)
+	}
+	return power
+}
+
 // OnReceiveProposal is called when a new proposal is received
 func (hs *Hotstuff) OnReceiveProposal(bNew Block) {
 	if hs.CanVote(bNew) {
@@ -90,7 +154,7 @@ func (hs *Hotstuff) CheckLivenessRule(bNew Block) bool {
 
 // Update perform two/three chain consensus phases
 func (hs *Hotstuff) Update(bNew Block) {
-	if TwoPhaseFlag {
+	if hs.variant == TwoPhase {
 		_, b, b1 := GetJustifyBlocks(bNew)
 		hs.UpdateQCHigh(bNew.Justify()) // prepare phase for b1
 		if CmpBlockHeight(b1, hs.GetBLock()) == 1 {
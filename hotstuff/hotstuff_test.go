@@ -12,9 +12,6 @@ import (
 )
 
 func TestHotstuff_UpdateQCHigh(t *testing.T) {
-	if TwoPhaseFlag {
-		t.Skip("skipping execution of TestHotstuff_UpdateQCHigh because TwoPhaseFlag is set to true")
-	}
 	q0 := newMockQC(nil)
 	b0 := newMockBlock(10, nil, q0)
 
@@ -65,7 +62,7 @@ func TestHotstuff_UpdateQCHigh(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			hs := new(Hotstuff)
-			hs.state = newState(tt.fields.bLeaf, tt.fields.qcHigh)
+			hs.state = newState(tt.fields.bLeaf, tt.fields.qcHigh, ThreePhase)
 
 			hs.UpdateQCHigh(tt.args.qc)
 
@@ -76,15 +73,43 @@ func TestHotstuff_UpdateQCHigh(t *testing.T) {
 	}
 }
 
+func TestHotstuff_Restore(t *testing.T) {
+	q0 := newMockQC(nil)
+	b0 := newMockBlock(10, nil, q0)
+
+	b1 := newMockBlock(11, b0, q0)
+	q1 := newMockQC(b1)
+
+	b2 := newMockBlock(12, b1, q1)
+	q2 := newMockQC(b2)
+
+	b3 := newMockBlock(13, b2, q2)
+
+	assert := assert.New(t)
+
+	hs := new(Hotstuff)
+	hs.state = newState(b0, q0, ThreePhase)
+	hs.Restore(b3, b1, q2)
+
+	assert.Equal(b3, hs.GetBVote())
+	assert.Equal(b1, hs.GetBLock())
+	assert.Equal(q2, hs.GetQCHigh())
+	assert.Equal(b2, hs.GetBLeaf())
+	assert.False(hs.CanVote(b3), "should not vote again at restored height")
+
+	hs.Restore(b1, b0, q1)
+
+	assert.Equal(b3, hs.GetBVote(), "should not move backward")
+	assert.Equal(b1, hs.GetBLock())
+	assert.Equal(q2, hs.GetQCHigh())
+}
+
 func TestHotstuff_SuccessfulPropose(t *testing.T) {
-	if TwoPhaseFlag {
-		t.Skip("skipping execution of TestHotstuff_SuccessfulPropose because TwoPhaseFlag is set to true")
-	}
 	q0 := newMockQC(nil)
 	b0 := newMockBlock(10, nil, q0)
 
 	driver := new(MockDriver)
-	hs := New(driver, nil, b0, q0)
+	hs := New(driver, nil, b0, q0, WithVariant(ThreePhase))
 
 	b1 := newMockBlock(11, b0, q0)
 
@@ -99,7 +124,8 @@ func TestHotstuff_SuccessfulPropose(t *testing.T) {
 	assert.Equal(b1, hs.GetBLeaf())
 	assert.True(hs.IsProposing())
 
-	driver.On("MajorityValidatorCount").Return(3)
+	driver.On("QuorumPower", mock.Anything).Return(uint64(3))
+	driver.On("VotePower", mock.Anything).Return(uint64(1))
 
 	v1 := newMockVote(b1, "r1")
 	hs.OnReceiveVote(v1)
@@ -107,15 +133,46 @@ func TestHotstuff_SuccessfulPropose(t *testing.T) {
 	assert.Equal([]Vote{v1}, hs.GetVotes())
 }
 
+func TestHotstuff_OnProposeAboveVote(t *testing.T) {
+	q0 := newMockQC(nil)
+	b0 := newMockBlock(10, nil, q0)
+	b1 := newMockBlock(11, b0, q0) // voted, but its proposal failed
+	b2 := newMockBlock(12, b1, q0)
+	fork := newMockBlock(11, newMockBlock(10, nil, q0), q0)
+	b3 := newMockBlock(11, b0, q0)
+
+	assert := assert.New(t)
+
+	driver := new(MockDriver)
+	hs := New(driver, nil, b0, q0, WithVariant(ThreePhase))
+	hs.setBVote(b1)
+	driver.On("CreateLeaf", b1, q0, b1.Height()+1).Once().Return(b2)
+	driver.On("BroadcastProposal", b2).Once()
+
+	hs.OnPropose()
+
+	driver.AssertExpectations(t)
+	assert.Equal(b2, hs.GetBLeaf())
+	assert.Equal(b2, hs.GetBVote(), "proposer voted its block")
+
+	driver = new(MockDriver)
+	hs = New(driver, nil, b0, q0, WithVariant(ThreePhase))
+	hs.setBVote(fork)
+	driver.On("CreateLeaf", b0, q0, b0.Height()+1).Once().Return(b3)
+	driver.On("BroadcastProposal", b3).Once()
+
+	hs.OnPropose()
+
+	driver.AssertExpectations(t)
+	assert.Equal(b3, hs.GetBLeaf(), "voted block not extending b_Leaf")
+}
+
 func TestHotstuff_FailedPropose(t *testing.T) {
-	if TwoPhaseFlag {
-		t.Skip("skipping execution of TestHotstuff_FailedPropose because TwoPhaseFlag is set to true")
-	}
 	q0 := newMockQC(nil)
 	b0 := newMockBlock(10, nil, q0)
 
 	driver := new(MockDriver)
-	hs := New(driver, nil, b0, q0)
+	hs := New(driver, nil, b0, q0, WithVariant(ThreePhase))
 
 	driver.On("CreateLeaf", b0, q0, b0.Height()+1).Once().Return(nil)
 
@@ -130,9 +187,6 @@ func TestHotstuff_FailedPropose(t *testing.T) {
 }
 
 func TestHotstuff_OnReceiveVote(t *testing.T) {
-	if TwoPhaseFlag {
-		t.Skip("skipping execution of TestHotstuff_OnReceiveVote because TwoPhaseFlag is set to true")
-	}
 	q0 := newMockQC(nil)
 	b0 := newMockBlock(10, nil, q0)
 	b1 := newMockBlock(11, b0, q0)
@@ -141,12 +195,13 @@ func TestHotstuff_OnReceiveVote(t *testing.T) {
 	assert := assert.New(t)
 
 	driver := new(MockDriver)
-	hs := New(driver, nil, b0, q0)
+	hs := New(driver, nil, b0, q0, WithVariant(ThreePhase))
 
 	driver.On("CreateLeaf", b0, q0, b0.Height()+1).Once().Return(b1)
 	driver.On("BroadcastProposal", b1).Once()
 	hs.OnPropose()
-	driver.On("MajorityValidatorCount").Return(2)
+	driver.On("QuorumPower", mock.Anything).Return(uint64(2))
+	driver.On("VotePower", mock.Anything).Return(uint64(1))
 
 	v1 := newMockVote(b1, "r1")
 	hs.OnReceiveVote(v1)
@@ -171,6 +226,42 @@ func TestHotstuff_OnReceiveVote(t *testing.T) {
 	assert.Equal(q1, hs.GetQCHigh())
 }
 
+func TestHotstuff_OnReceiveWeightedVote(t *testing.T) {
+	q0 := newMockQC(nil)
+	b0 := newMockBlock(10, nil, q0)
+	b1 := newMockBlock(11, b0, q0)
+	q1 := newMockQC(b1)
+
+	assert := assert.New(t)
+
+	driver := new(MockDriver)
+	hs := New(driver, nil, b0, q0, WithVariant(ThreePhase))
+
+	driver.On("CreateLeaf", b0, q0, b0.Height()+1).Once().Return(b1)
+	driver.On("BroadcastProposal", b1).Once()
+	hs.OnPropose()
+
+	v1 := newMockVote(b1, "r1")
+	v2 := newMockVote(b1, "r2")
+	v3 := newMockVote(b1, "r3")
+	driver.On("QuorumPower", mock.Anything).Return(uint64(5))
+	driver.On("VotePower", v1).Return(uint64(1))
+	driver.On("VotePower", v2).Return(uint64(1))
+	driver.On("VotePower", v3).Return(uint64(3))
+
+	hs.OnReceiveVote(v1)
+	hs.OnReceiveVote(v2)
+	driver.AssertNotCalled(t, "CreateQC")
+	assert.Equal(2, hs.GetVoteCount(), "majority count but not enough power")
+
+	driver.On("CreateQC", mock.Anything).Return(q1)
+	hs.OnReceiveVote(v3)
+
+	driver.AssertExpectations(t)
+	assert.False(hs.IsProposing())
+	assert.Equal(q1, hs.GetQCHigh())
+}
+
 func TestHotstuff_CanVote(t *testing.T) {
 	q0 := newMockQC(nil)
 	b0 := newMockBlock(10, nil, q0) // bLock
@@ -223,9 +314,14 @@ func TestHotstuff_CanVote(t *testing.T) {
 }
 
 func TestHotstuff_Update(t *testing.T) {
-	if TwoPhaseFlag {
-		t.Skip("skipping execution of TestHotstuff_Update because TwoPhaseFlag is set to true")
+	for _, variant := range []Variant{TwoPhase, ThreePhase} {
+		t.Run(variant.String(), func(t *testing.T) {
+			testHotstuffUpdate(t, variant)
+		})
 	}
+}
+
+func testHotstuffUpdate(t *testing.T, variant Variant) {
 	q0 := newMockQC(nil)
 	b0 := newMockBlock(10, nil, q0) // bLock
 
@@ -260,21 +356,27 @@ func TestHotstuff_Update(t *testing.T) {
 	bb6 := newMockBlock(16, bb5, qq5)
 	_ = bb6
 
-	hs0 := new(Hotstuff)
-	hs0.state = newState(b0, q0)
+	// the genesis qc certifies b0 in two-phase mode
+	qg := q0
+	if variant == TwoPhase {
+		qg = newMockQC(b0)
+	}
+
+	hs0 := &Hotstuff{variant: variant}
+	hs0.state = newState(b0, qg, variant)
 	hs0.tester = newTester(nil)
 
-	hs1 := new(Hotstuff)
-	hs1.state = newState(b0, q1)
+	hs1 := &Hotstuff{variant: variant}
+	hs1.state = newState(b0, q1, variant)
 	hs1.tester = newTester(nil)
 
-	hs2 := new(Hotstuff)
-	hs2.state = newState(b0, q2)
+	hs2 := &Hotstuff{variant: variant}
+	hs2.state = newState(b0, q2, variant)
 	hs2.tester = newTester(nil)
 	hs2.setBLock(b1)
 
-	hs3 := new(Hotstuff)
-	hs3.state = newState(b2, q2)
+	hs3 := &Hotstuff{variant: variant}
+	hs3.state = newState(b2, q2, variant)
 	hs3.tester = newTester(nil)
 
 	type testCase struct {
@@ -288,15 +390,16 @@ func TestHotstuff_Update(t *testing.T) {
 	}
 	var tests []testCase
 
-	if TwoPhaseFlag {
+	if variant == TwoPhase {
 		tests = []testCase{
-			{"proposal 1", hs0, b1, 0, q0, b1, b0},
-			{"proposal dup", hs0, bf0, 0, q0, b1, b0},
-			{"proposal 2", hs0, b2, 1, q1, b2, b1},
-			{"proposal 3", hs1, b3, 2, q2, b3, b2},
-			{"proposal 4", hs2, b4, 3, q3, b4, b3},
-			{"not one chain", hs0, bb3, 0, q1, bb3, bb1},
-			{"one chain but invalid commit phase", hs3, b2, 0, q2, b2, b2},
+			{"proposal 1", hs0, b1, 0, qg, b0, b0},
+			{"proposal dup", hs0, bf0, 0, qg, b0, b0},
+			{"proposal 2", hs0, b2, 0, q1, b1, b0},
+			{"proposal 3", hs1, b3, 1, q2, b2, b1},
+			{"proposal 4", hs2, b4, 2, q3, b3, b2},
+			{"not two chain", hs0, bb4, 0, qq3, bb3, b0},
+			{"exec debts", hs0, bb5, 3, qq4, bb4, bb3},
+			{"two chain but invalid commit phase", hs3, b3, 0, q2, b2, b2},
 		}
 	} else {
 		tests = []testCase{
@@ -323,13 +426,44 @@ func TestHotstuff_Update(t *testing.T) {
 			assert := assert.New(t)
 			driver.AssertExpectations(t)
 			assert.Equal(tt.qcHigh, tt.hs.GetQCHigh())
-			if TwoPhaseFlag {
-				assert.Equal(tt.bLock, tt.hs.GetBLock())
-				assert.Equal(tt.bExec, tt.hs.GetBExec())
-			} else {
-				assert.Equal(tt.bLock, tt.hs.GetBLock())
-				assert.Equal(tt.bExec, tt.hs.GetBExec())
-			}
+			assert.Equal(tt.bLock, tt.hs.GetBLock())
+			assert.Equal(tt.bExec, tt.hs.GetBExec())
 		})
 	}
 }
+
+func TestHotstuff_CollectVotes(t *testing.T) {
+	q0 := newMockQC(nil)
+	b0 := newMockBlock(10, nil, q0)
+	b1 := newMockBlock(11, b0, q0)
+	b2 := newMockBlock(12, b1, q0)
+	q2 := newMockQC(b2)
+
+	assert := assert.New(t)
+
+	driver := new(MockDriver)
+	hs := New(driver, nil, b0, q0, WithVariant(ThreePhase))
+
+	driver.On("CreateLeaf", b0, q0, b0.Height()+1).Once().Return(b2)
+	driver.On("BroadcastProposal", b2).Once()
+	hs.OnPropose()
+
+	assert.False(hs.CollectVotes(b1), "lower than own proposal")
+	hs.OnReceiveVote(newMockVote(b1, "r1"))
+	assert.Equal(0, hs.GetVoteCount())
+
+	// next leader collects the forwarded votes of the block proposed by the previous leader
+	hs = New(driver, nil, b0, q0, WithVariant(ThreePhase))
+	assert.False(hs.IsCollecting(b2))
+	assert.True(hs.CollectVotes(b2))
+	assert.True(hs.IsCollecting(b2))
+	assert.False(hs.IsCollecting(b1))
+	driver.On("QuorumPower", mock.Anything).Return(uint64(2))
+	driver.On("VotePower", mock.Anything).Return(uint64(1))
+	driver.On("CreateQC", mock.Anything).Return(q2)
+	hs.OnReceiveVote(newMockVote(b2, "r1"))
+	hs.OnReceiveVote(newMockVote(b2, "r2"))
+
+	assert.False(hs.IsProposing())
+	assert.Equal(q2, hs.GetQCHigh())
+}
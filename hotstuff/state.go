@@ -26,10 +26,10 @@ type state struct {
 	qcHighEmitter *emitter.Emitter
 }
 
-func newState(b0 Block, q0 QC) *state {
+func newState(b0 Block, q0 QC, variant Variant) *state {
 	s := new(state)
 	s.qcHighEmitter = emitter.New()
-	if TwoPhaseFlag {
+	if variant == TwoPhase {
 		s.setBVote(q0.Block())
 		s.setBLock(q0.Block())
 		s.setBLeaf(q0.Block())
@@ -88,6 +88,27 @@ func (s *state) startProposal(b Block) {
 	s.votes = make(map[string]Vote)
 }
 
+// startCollecting replaces the proposal in progress only with a higher block
+func (s *state) startCollecting(b Block) bool {
+	s.pMtx.Lock()
+	defer s.pMtx.Unlock()
+
+	if s.proposal != nil && CmpBlockHeight(b, s.proposal) != 1 {
+		return false
+	}
+	s.proposal = b
+	s.votes = make(map[string]Vote)
+	return true
+}
+
+// IsCollecting returns true if the votes for the block are being collected
+func (s *state) IsCollecting(b Block) bool {
+	s.pMtx.RLock()
+	defer s.pMtx.RUnlock()
+
+	return s.proposal != nil && s.proposal.Equal(b)
+}
+
 func (s *state) endProposal() {
 	s.pMtx.Lock()
 	defer s.pMtx.Unlock()
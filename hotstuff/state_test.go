@@ -11,15 +11,11 @@ import (
 )
 
 func Test_state_init(t *testing.T) {
-	if TwoPhaseFlag {
-		t.Skip("skipping execution of Test_state_init because TwoPhaseFlag is set to true")
-	}
-
 	b0 := new(MockBlock)
 	q0 := new(MockQC)
 
 	b0.On("Height").Return(10)
-	s := newState(b0, q0)
+	s := newState(b0, q0, ThreePhase)
 
 	assert := assert.New(t)
 	assert.Equal(b0, s.GetBExec())
@@ -29,6 +25,22 @@ func Test_state_init(t *testing.T) {
 	assert.Equal(q0, s.GetQCHigh())
 }
 
+func Test_state_initTwoPhase(t *testing.T) {
+	b0 := new(MockBlock)
+	b1 := new(MockBlock)
+	q0 := new(MockQC)
+	q0.On("Block").Return(b1)
+
+	s := newState(b0, q0, TwoPhase)
+
+	assert := assert.New(t)
+	assert.Equal(b0, s.GetBExec())
+	assert.Equal(b1, s.GetBLock())
+	assert.Equal(b1, s.GetBLeaf())
+	assert.Equal(b1, s.GetBVote())
+	assert.Equal(q0, s.GetQCHigh())
+}
+
 func Test_state_GetVotes(t *testing.T) {
 	s := &state{}
 
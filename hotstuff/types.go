@@ -4,7 +4,37 @@
 
 package hotstuff
 
-const TwoPhaseFlag = true //true: 二阶段提交 false: 三阶段提交
+import "fmt"
+
+// Variant is the commit rule of hotstuff
+type Variant uint8
+
+// hotstuff variants
+const (
+	TwoPhase   Variant = 2 // commit a block on two-chain
+	ThreePhase Variant = 3 // commit a block on three-chain
+)
+
+// ParseVariant parses variant name, empty name is treated as two-phase
+func ParseVariant(name string) (Variant, error) {
+	switch name {
+	case "", TwoPhase.String():
+		return TwoPhase, nil
+	case ThreePhase.String():
+		return ThreePhase, nil
+	}
+	return 0, fmt.Errorf("unknown hotstuff variant %q", name)
+}
+
+func (v Variant) String() string {
+	switch v {
+	case TwoPhase:
+		return "two-phase"
+	case ThreePhase:
+		return "three-phase"
+	}
+	return fmt.Sprintf("variant(%d)", uint8(v))
+}
 
 // Block type
 type Block interface {
@@ -29,7 +59,8 @@ type Vote interface {
 
 // Driver godoc
 type Driver interface {
-	MajorityValidatorCount() int
+	QuorumPower(b Block) uint64 // quorum of the validators of the voted block
+	VotePower(v Vote) uint64
 	CreateLeaf(parent Block, qc QC, height uint64) Block
 	CreateQC(votes []Vote) QC
 	BroadcastProposal(blk Block)
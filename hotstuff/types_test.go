@@ -146,9 +146,14 @@ type MockDriver struct {
 
 var _ Driver = (*MockDriver)(nil)
 
-func (m *MockDriver) MajorityValidatorCount() int {
-	args := m.Called()
-	return args.Int(0)
+func (m *MockDriver) QuorumPower(b Block) uint64 {
+	args := m.Called(b)
+	return args.Get(0).(uint64)
+}
+
+func (m *MockDriver) VotePower(v Vote) uint64 {
+	args := m.Called(v)
+	return args.Get(0).(uint64)
 }
 
 func (m *MockDriver) CreateLeaf(parent Block, qc QC, height uint64) Block {
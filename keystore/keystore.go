@@ -0,0 +1,183 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package keystore
+
+import (
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+const (
+	Version = 1
+	KDF     = "scrypt"
+	Cipher  = "xchacha20-poly1305"
+)
+
+// errors
+var (
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted key file")
+	ErrNotKeyFile      = errors.New("not an encrypted key file")
+	ErrEmptyPassphrase = errors.New("empty passphrase")
+	ErrScryptParams    = errors.New("invalid scrypt params")
+)
+
+// ScryptParams are the cost parameters of the key derivation
+type ScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+var (
+	// StandardScrypt takes about 1s and 256MB memory
+	StandardScrypt = ScryptParams{N: 1 << 18, R: 8, P: 1}
+
+	// LightScrypt is for test clusters whose passphrase is stored beside the key
+	LightScrypt = ScryptParams{N: 1 << 12, R: 8, P: 1}
+)
+
+// Validate checks the params before deriving a key, the params of a key file are untrusted
+// and must not cost more than StandardScrypt
+func (p ScryptParams) Validate() error {
+	if p.N < 2 || p.N&(p.N-1) != 0 {
+		return fmt.Errorf("%w, n %d is not a power of two", ErrScryptParams, p.N)
+	}
+	if p.N > StandardScrypt.N || p.R < 1 || p.R > StandardScrypt.R ||
+		p.P < 1 || p.P > StandardScrypt.P {
+		return fmt.Errorf("%w, n %d, r %d, p %d exceed the standard params", ErrScryptParams, p.N, p.R, p.P)
+	}
+	return nil
+}
+
+// KeyFile is the json format of an encrypted private key
+type KeyFile struct {
+	Version    int          `json:"version"`
+	PubKey     string       `json:"pubKey"` // base64 public key, also the additional data of the cipher
+	KDF        string       `json:"kdf"`
+	KDFParams  ScryptParams `json:"kdfParams"`
+	Salt       []byte       `json:"salt"`
+	Cipher     string       `json:"cipher"`
+	Nonce      []byte       `json:"nonce"`
+	Ciphertext []byte       `json:"ciphertext"`
+}
+
+// Encrypt seals the private key with a key derived from the passphrase
+func Encrypt(priv *core.PrivateKey, passphrase []byte, params ScryptParams) (*KeyFile, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrEmptyPassphrase
+	}
+	kf := &KeyFile{
+		Version:   Version,
+		PubKey:    priv.PublicKey().String(),
+		KDF:       KDF,
+		KDFParams: params,
+		Salt:      make([]byte, 32),
+		Cipher:    Cipher,
+		Nonce:     make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if _, err := rand.Read(kf.Salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(kf.Nonce); err != nil {
+		return nil, err
+	}
+	aead, err := kf.newAEAD(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	kf.Ciphertext = aead.Seal(nil, kf.Nonce, priv.Bytes(), []byte(kf.PubKey))
+	return kf, nil
+}
+
+// Decrypt opens the private key and checks it against the public key
+func Decrypt(kf *KeyFile, passphrase []byte) (*core.PrivateKey, error) {
+	if kf.Version != Version || kf.KDF != KDF || kf.Cipher != Cipher {
+		return nil, fmt.Errorf("unsupported key file version %d, kdf %s, cipher %s",
+			kf.Version, kf.KDF, kf.Cipher)
+	}
+	if len(kf.Nonce) != chacha20poly1305.NonceSizeX {
+		return nil, ErrWrongPassphrase
+	}
+	aead, err := kf.newAEAD(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	b, err := aead.Open(nil, kf.Nonce, kf.Ciphertext, []byte(kf.PubKey))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	priv, err := core.NewPrivateKey(b)
+	if err != nil {
+		return nil, err
+	}
+	if priv.PublicKey().String() != kf.PubKey {
+		return nil, ErrWrongPassphrase
+	}
+	return priv, nil
+}
+
+func (kf *KeyFile) newAEAD(passphrase []byte) (cipher.AEAD, error) {
+	p := kf.KDFParams
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(passphrase, kf.Salt, p.N, p.R, p.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("derive key failed, %w", err)
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+// IsKeyFile reports whether b is an encrypted key file rather than raw private key bytes
+func IsKeyFile(b []byte) bool {
+	return len(b) != ed25519.PrivateKeySize && json.Valid(b)
+}
+
+// ParseKeyFile decodes the json key file
+func ParseKeyFile(b []byte) (*KeyFile, error) {
+	if !IsKeyFile(b) {
+		return nil, ErrNotKeyFile
+	}
+	kf := new(KeyFile)
+	if err := json.Unmarshal(b, kf); err != nil {
+		return nil, fmt.Errorf("cannot parse key file, %w", err)
+	}
+	return kf, nil
+}
+
+// ReadKeyFile reads and decrypts the key file
+func ReadKeyFile(name string, passphrase []byte) (*core.PrivateKey, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	kf, err := ParseKeyFile(b)
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(kf, passphrase)
+}
+
+// WriteKeyFile encrypts the private key and writes it readable only by the owner
+func WriteKeyFile(name string, priv *core.PrivateKey, passphrase []byte, params ScryptParams) error {
+	kf, err := Encrypt(priv, passphrase, params)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, b, 0600)
+}
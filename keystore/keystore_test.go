@@ -0,0 +1,126 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package keystore
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	asrt := assert.New(t)
+
+	priv := core.GenerateKey(nil)
+	pass := []byte("secret")
+	kf, err := Encrypt(priv, pass, LightScrypt)
+	asrt.NoError(err)
+	asrt.Equal(priv.PublicKey().String(), kf.PubKey)
+	asrt.NotContains(string(kf.Ciphertext), string(priv.Bytes()))
+
+	_, err = Encrypt(priv, nil, LightScrypt)
+	asrt.ErrorIs(err, ErrEmptyPassphrase)
+
+	res, err := Decrypt(kf, pass)
+	asrt.NoError(err)
+	asrt.Equal(priv.Bytes(), res.Bytes())
+
+	_, err = Decrypt(kf, []byte("wrong"))
+	asrt.ErrorIs(err, ErrWrongPassphrase)
+
+	kf2 := *kf
+	kf2.PubKey = core.GenerateKey(nil).PublicKey().String()
+	_, err = Decrypt(&kf2, pass)
+	asrt.ErrorIs(err, ErrWrongPassphrase, "public key is authenticated")
+
+	kf2 = *kf
+	kf2.Version = 2
+	_, err = Decrypt(&kf2, pass)
+	asrt.Error(err)
+}
+
+func TestScryptParams_Validate(t *testing.T) {
+	asrt := assert.New(t)
+
+	asrt.NoError(StandardScrypt.Validate())
+	asrt.NoError(LightScrypt.Validate())
+
+	for _, p := range []ScryptParams{
+		{N: 1 << 19, R: 8, P: 1},
+		{N: 3 << 10, R: 8, P: 1},
+		{N: 0, R: 8, P: 1},
+		{N: 1 << 12, R: 1 << 20, P: 1},
+		{N: 1 << 12, R: 8, P: 1 << 20},
+		{N: 1 << 12, R: 0, P: 1},
+	} {
+		asrt.ErrorIs(p.Validate(), ErrScryptParams, "%+v", p)
+	}
+
+	// the params of a key file are checked before the key is derived
+	priv := core.GenerateKey(nil)
+	kf, err := Encrypt(priv, []byte("secret"), LightScrypt)
+	asrt.NoError(err)
+	kf.KDFParams.N = 1 << 30
+	_, err = Decrypt(kf, []byte("secret"))
+	asrt.ErrorIs(err, ErrScryptParams)
+
+	_, err = Encrypt(priv, []byte("secret"), ScryptParams{N: 1 << 20, R: 8, P: 1})
+	asrt.ErrorIs(err, ErrScryptParams)
+}
+
+func TestReadWriteKeyFile(t *testing.T) {
+	asrt := assert.New(t)
+
+	name := path.Join(t.TempDir(), "nodekey")
+	priv := core.GenerateKey(nil)
+	asrt.NoError(WriteKeyFile(name, priv, []byte("secret"), LightScrypt))
+
+	info, err := os.Stat(name)
+	asrt.NoError(err)
+	asrt.EqualValues(0600, info.Mode().Perm())
+
+	b, _ := os.ReadFile(name)
+	asrt.True(IsKeyFile(b))
+	asrt.False(IsKeyFile(priv.Bytes()))
+	var raw map[string]interface{}
+	asrt.NoError(json.Unmarshal(b, &raw))
+	asrt.Equal("scrypt", raw["kdf"])
+
+	res, err := ReadKeyFile(name, []byte("secret"))
+	asrt.NoError(err)
+	asrt.Equal(priv.Bytes(), res.Bytes())
+
+	plain := path.Join(t.TempDir(), "plain")
+	os.WriteFile(plain, priv.Bytes(), 0600)
+	_, err = ReadKeyFile(plain, []byte("secret"))
+	asrt.ErrorIs(err, ErrNotKeyFile)
+}
+
+func TestReadPassphrase(t *testing.T) {
+	asrt := assert.New(t)
+
+	file := path.Join(t.TempDir(), "pass")
+	os.WriteFile(file, []byte("from file\n"), 0600)
+	t.Setenv(PassphraseEnv, "from env")
+
+	pass, err := ReadPassphrase(file, "", false)
+	asrt.NoError(err)
+	asrt.Equal("from file", string(pass))
+
+	pass, err = ReadPassphrase("", "", false)
+	asrt.NoError(err)
+	asrt.Equal("from env", string(pass))
+
+	t.Setenv(PassphraseEnv, "")
+	_, err = ReadPassphrase("", "", false)
+	asrt.ErrorIs(err, ErrEmptyPassphrase)
+
+	_, err = ReadPassphrase(path.Join(t.TempDir(), "missing"), "", false)
+	asrt.Error(err)
+}
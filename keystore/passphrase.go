@@ -0,0 +1,65 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// PassphraseEnv is the environment variable read when no passphrase file is given
+const PassphraseEnv = "PPOV_PASSPHRASE"
+
+// ReadPassphrase reads the passphrase from the file, then PassphraseEnv, then the terminal prompt,
+// confirm asks to type it twice when prompting for a new passphrase
+func ReadPassphrase(file string, prompt string, confirm bool) ([]byte, error) {
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read passphrase file, %w", err)
+		}
+		return checkPassphrase(bytes.TrimRight(b, "\r\n"))
+	}
+	if env, ok := os.LookupEnv(PassphraseEnv); ok {
+		return checkPassphrase([]byte(env))
+	}
+	return PromptPassphrase(prompt, confirm)
+}
+
+// PromptPassphrase reads the passphrase from the terminal without echo
+func PromptPassphrase(prompt string, confirm bool) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("no passphrase file or %s, and stdin is not a terminal", PassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		repeat, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(pass, repeat) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return checkPassphrase(pass)
+}
+
+func checkPassphrase(pass []byte) ([]byte, error) {
+	if len(pass) == 0 {
+		return nil, ErrEmptyPassphrase
+	}
+	return pass, nil
+}
@@ -0,0 +1,199 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package lightclient
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"sync"
+
+	_ "golang.org/x/crypto/sha3"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/storage"
+)
+
+// errors
+var (
+	ErrUnexpectedHeight   = errors.New("unexpected block height")
+	ErrParentMismatch     = errors.New("parent hash does not match the last verified block")
+	ErrUnknownQCBlock     = errors.New("qc references an unknown block")
+	ErrStaleEpoch         = errors.New("epoch starts at a verified height")
+	ErrCheckpointMismatch = errors.New("checkpoint does not match the verified block")
+	ErrUntrustedRoot      = errors.New("state root is not certified")
+	ErrInvalidStateProof  = errors.New("invalid state proof")
+)
+
+type Config struct {
+	MerkleBranchFactor uint8
+	KeyedLeafHeight    uint64 // first executed height whose state leaves hash their keys
+	HistoryLimit       int    // number of recent block hashes and state roots kept for verification
+}
+
+var DefaultConfig = Config{
+	MerkleBranchFactor: storage.DefaultConfig.MerkleBranchFactor,
+	HistoryLimit:       1000,
+}
+
+// Client verifies the headers and the state of a chain from a trusted genesis block,
+// a block is verified by its parent hash and quorum cert, and its state root is trusted
+// once a later block carries a quorum cert of it or one of its descendants
+type Client struct {
+	config Config
+	epochs *core.EpochStore
+
+	last      *core.Block
+	certified uint64            // height of the highest block referenced by a verified qc
+	hashes    map[uint64][]byte // recent verified block hashes by height
+	heights   map[string]uint64 // recent verified block heights by hash
+	pending   []*core.Block     // verified blocks not certified yet
+	roots     map[string]uint64 // certified state roots to the executed height
+	rootQueue [][]byte
+
+	mtx sync.RWMutex
+}
+
+// New creates a light client trusting the genesis block and the validators of epochs
+func New(b0 *core.Block, epochs *core.EpochStore, config Config) *Client {
+	if config.HistoryLimit <= 0 {
+		config.HistoryLimit = DefaultConfig.HistoryLimit
+	}
+	c := &Client{
+		config:  config,
+		epochs:  epochs,
+		hashes:  make(map[uint64][]byte),
+		heights: make(map[string]uint64),
+		roots:   make(map[string]uint64),
+	}
+	c.addBlock(b0)
+	return c
+}
+
+// Height returns the height of the last verified block
+func (c *Client) Height() uint64 {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.last.Height()
+}
+
+// CertifiedHeight returns the height of the last block certified by a verified qc
+func (c *Client) CertifiedHeight() uint64 {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.certified
+}
+
+// EpochCount returns the number of known epochs including genesis
+func (c *Client) EpochCount() int {
+	return len(c.epochs.Epochs())
+}
+
+// AddEpoch verifies the approvals of the next epoch and tracks its validators
+func (c *Client) AddEpoch(ep *core.Epoch) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if ep.Start <= c.last.Height() {
+		return ErrStaleEpoch
+	}
+	seq := uint64(len(c.epochs.Epochs()))
+	if err := ep.VerifyApprovals(seq, c.epochs.AtHeight(ep.Scheduled)); err != nil {
+		return err
+	}
+	return c.epochs.AddEpoch(ep)
+}
+
+// VerifyBlock verifies the block following the last verified block
+func (c *Client) VerifyBlock(blk *core.Block) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if blk.Height() != c.last.Height()+1 {
+		return ErrUnexpectedHeight
+	}
+	if !bytes.Equal(blk.ParentHash(), c.last.Hash()) {
+		return ErrParentMismatch
+	}
+	if err := blk.Validate(c.epochs); err != nil {
+		return err
+	}
+	qcHeight, ok := c.heights[string(blk.QuorumCert().BlockHash())]
+	if !ok {
+		return ErrUnknownQCBlock
+	}
+	c.addBlock(blk)
+	if qcHeight > c.certified {
+		c.certify(qcHeight)
+	}
+	return nil
+}
+
+// VerifyCheckpoint verifies the checkpoint cert and trusts its state root
+func (c *Client) VerifyCheckpoint(cert *core.CheckpointCert) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if err := cert.Validate(c.epochs.AtHeight(cert.Height())); err != nil {
+		return err
+	}
+	if hash, ok := c.hashes[cert.Height()]; ok && !bytes.Equal(hash, cert.BlockHash()) {
+		return ErrCheckpointMismatch
+	}
+	c.addRoot(cert.MerkleRoot(), cert.Height())
+	return nil
+}
+
+// VerifyState verifies the state key and value of the proof against a certified state root
+func (c *Client) VerifyState(proof *storage.StateProof) error {
+	c.mtx.RLock()
+	height, ok := c.roots[string(proof.MerkleRoot)]
+	c.mtx.RUnlock()
+
+	if !ok {
+		return ErrUntrustedRoot
+	}
+	// the leaf format follows the certified height of the root rather than the height of the proof
+	keyed := height >= c.config.KeyedLeafHeight
+	if !proof.Verify(crypto.SHA3_256, c.config.MerkleBranchFactor, keyed, proof.MerkleRoot) {
+		return ErrInvalidStateProof
+	}
+	return nil
+}
+
+func (c *Client) addBlock(blk *core.Block) {
+	c.last = blk
+	c.pending = append(c.pending, blk)
+	c.hashes[blk.Height()] = blk.Hash()
+	c.heights[string(blk.Hash())] = blk.Height()
+	if blk.Height() >= uint64(c.config.HistoryLimit) {
+		old := blk.Height() - uint64(c.config.HistoryLimit)
+		delete(c.heights, string(c.hashes[old]))
+		delete(c.hashes, old)
+	}
+}
+
+// certify trusts the state roots of the pending blocks up to the certified height
+func (c *Client) certify(height uint64) {
+	c.certified = height
+	i := 0
+	for ; i < len(c.pending) && c.pending[i].Height() <= height; i++ {
+		if root := c.pending[i].MerkleRoot(); len(root) > 0 {
+			c.addRoot(root, c.pending[i].ExecHeight())
+		}
+	}
+	c.pending = c.pending[i:]
+}
+
+func (c *Client) addRoot(root []byte, height uint64) {
+	if _, ok := c.roots[string(root)]; ok {
+		return
+	}
+	c.roots[string(root)] = height
+	c.rootQueue = append(c.rootQueue, root)
+	if len(c.rootQueue) > c.config.HistoryLimit {
+		delete(c.roots, string(c.rootQueue[0]))
+		c.rootQueue = c.rootQueue[1:]
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package lightclient
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/storage"
+)
+
+type testChain struct {
+	privs []*core.PrivateKey
+	keys  []string
+}
+
+func newTestChain() *testChain {
+	tc := new(testChain)
+	for i := 0; i < 4; i++ {
+		tc.privs = append(tc.privs, core.GenerateKey(nil))
+		tc.keys = append(tc.keys, tc.privs[i].PublicKey().String())
+	}
+	return tc
+}
+
+func (tc *testChain) nextBlock(parent *core.Block, execHeight uint64, root []byte) *core.Block {
+	votes := make([]*core.Vote, 3)
+	for i := range votes {
+		votes[i] = parent.Vote(tc.privs[i])
+	}
+	return core.NewBlock().
+		SetHeight(parent.Height() + 1).
+		SetParentHash(parent.Hash()).
+		SetQuorumCert(core.NewQuorumCert().Build(votes)).
+		SetExecHeight(execHeight).
+		SetMerkleRoot(root).
+		Sign(tc.privs[0])
+}
+
+func newTestStorage(t *testing.T, b0 *core.Block) *storage.Storage {
+	dir, _ := os.MkdirTemp("", "db")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	rawDB, _ := storage.NewLevelDB(dir)
+	strg := storage.New(rawDB, storage.DefaultConfig)
+	err := strg.Commit(&storage.CommitData{
+		Block: b0,
+		QC:    core.NewQuorumCert(),
+		BlockCommit: core.NewBlockCommit().
+			SetHash(b0.Hash()).
+			SetStateChanges([]*core.StateChange{
+				core.NewStateChange().SetKey([]byte{1}).SetValue([]byte{10}),
+				core.NewStateChange().SetKey([]byte{2}).SetValue([]byte{20}),
+			}),
+	})
+	assert.NoError(t, err)
+	return strg
+}
+
+func TestClient_VerifyBlock(t *testing.T) {
+	asrt := assert.New(t)
+
+	tc := newTestChain()
+	b0 := core.NewBlock().SetHeight(0).Sign(tc.privs[0])
+	strg := newTestStorage(t, b0)
+	root := strg.GetMerkleRoot()
+	client := New(b0, core.NewEpochStore(tc.keys, tc.keys, nil), DefaultConfig)
+
+	b1 := tc.nextBlock(b0, 0, root)
+	asrt.ErrorIs(client.VerifyBlock(tc.nextBlock(b1, 0, root)), ErrUnexpectedHeight)
+	asrt.NoError(client.VerifyBlock(b1))
+	asrt.EqualValues(1, client.Height())
+	asrt.EqualValues(0, client.CertifiedHeight())
+
+	proof, err := strg.GetStateProof([]byte{2})
+	asrt.NoError(err)
+	asrt.ErrorIs(client.VerifyState(proof), ErrUntrustedRoot, "b1 is not certified yet")
+
+	fork := tc.nextBlock(b0, 0, root).SetTimestamp(1).Sign(tc.privs[0])
+	asrt.ErrorIs(client.VerifyBlock(tc.nextBlock(fork, 0, root)), ErrParentMismatch)
+
+	outsider := core.GenerateKey(nil)
+	b2 := tc.nextBlock(b1, 0, root)
+	asrt.Error(client.VerifyBlock(b2.Sign(outsider)), "proposer is not a worker")
+
+	b2 = tc.nextBlock(b1, 0, root)
+	asrt.NoError(client.VerifyBlock(b2))
+	asrt.EqualValues(1, client.CertifiedHeight())
+	asrt.NoError(client.VerifyState(proof))
+
+	proof.Value = []byte{21}
+	asrt.ErrorIs(client.VerifyState(proof), ErrInvalidStateProof)
+
+	// the value of another key at the same leaf
+	proof, err = strg.GetStateProof([]byte{2})
+	asrt.NoError(err)
+	proof.Key = []byte{1}
+	asrt.ErrorIs(client.VerifyState(proof), ErrInvalidStateProof)
+}
+
+func TestClient_VerifyCheckpoint(t *testing.T) {
+	asrt := assert.New(t)
+
+	tc := newTestChain()
+	b0 := core.NewBlock().SetHeight(0).Sign(tc.privs[0])
+	strg := newTestStorage(t, b0)
+	client := New(b0, core.NewEpochStore(tc.keys, tc.keys, nil), DefaultConfig)
+
+	certify := func(height uint64, hash []byte, n int) *core.CheckpointCert {
+		votes := make([]*core.CheckpointVote, n)
+		for i := range votes {
+			votes[i] = core.NewCheckpointVote().Sign(height, hash, strg.GetMerkleRoot(), tc.privs[i])
+		}
+		cert, _ := core.NewCheckpointCert().Build(votes)
+		return cert
+	}
+	asrt.Error(client.VerifyCheckpoint(certify(0, b0.Hash(), 2)), "not enough votes")
+	asrt.ErrorIs(client.VerifyCheckpoint(certify(0, make([]byte, 32), 3)), ErrCheckpointMismatch)
+	asrt.NoError(client.VerifyCheckpoint(certify(0, b0.Hash(), 3)))
+
+	proof, err := strg.GetStateProof([]byte{1})
+	asrt.NoError(err)
+	asrt.NoError(client.VerifyState(proof))
+
+	// a legacy chain hashes only the values at the certified height
+	config := DefaultConfig
+	config.KeyedLeafHeight = storage.NeverKeyedLeaf
+	legacy := New(b0, core.NewEpochStore(tc.keys, tc.keys, nil), config)
+	asrt.NoError(legacy.VerifyCheckpoint(certify(0, b0.Hash(), 3)))
+	asrt.ErrorIs(legacy.VerifyState(proof), ErrInvalidStateProof)
+}
+
+func TestClient_AddEpoch(t *testing.T) {
+	asrt := assert.New(t)
+
+	tc := newTestChain()
+	b0 := core.NewBlock().SetHeight(0).Sign(tc.privs[0])
+	client := New(b0, core.NewEpochStore(tc.keys, tc.keys, nil), DefaultConfig)
+	b1 := tc.nextBlock(b0, 0, nil)
+	asrt.NoError(client.VerifyBlock(b1))
+
+	newPriv := core.GenerateKey(nil)
+	workers := []string{newPriv.PublicKey().String()}
+	ep := &core.Epoch{Start: 3, Workers: workers, Voters: tc.keys[:3], Scheduled: 1}
+	input, _ := json.Marshal(&core.ValidatorSet{Epoch: 1, Workers: ep.Workers, Voters: ep.Voters})
+	for _, priv := range tc.privs[:3] {
+		b, _ := core.NewTransaction().SetCodeAddr(core.GovernanceAddr).SetInput(input).Sign(priv).Marshal()
+		ep.Approvals = append(ep.Approvals, b)
+	}
+	asrt.ErrorIs(client.AddEpoch(&core.Epoch{Start: 1, Workers: workers, Voters: tc.keys}), ErrStaleEpoch)
+	asrt.ErrorIs(client.AddEpoch(&core.Epoch{Start: 3, Workers: workers, Voters: tc.keys[:3]}),
+		core.ErrNotEnoughApprovals)
+	asrt.NoError(client.AddEpoch(ep))
+	asrt.Equal(2, client.EpochCount())
+
+	// the proposer of the first block of the epoch must be a new worker
+	b2 := tc.nextBlock(b1, 0, nil)
+	asrt.NoError(client.VerifyBlock(b2))
+	asrt.Error(client.VerifyBlock(tc.nextBlock(b2, 0, nil)))
+
+	votes := make([]*core.Vote, 3)
+	for i := range votes {
+		votes[i] = b2.Vote(tc.privs[i])
+	}
+	b3 := core.NewBlock().
+		SetHeight(3).
+		SetParentHash(b2.Hash()).
+		SetQuorumCert(core.NewQuorumCert().Build(votes)).
+		Sign(newPriv)
+	asrt.NoError(client.VerifyBlock(b3))
+}
@@ -0,0 +1,181 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package lightclient
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wooyang2018/ppov-blockchain/logger"
+	"github.com/wooyang2018/ppov-blockchain/storage"
+)
+
+// a state root is certified by a later block, the proof is kept until then
+const stateRootWindow = 20
+
+// Inconsistency is a response of the full node that fails verification
+type Inconsistency struct {
+	Kind   string // epoch, block, checkpoint or state
+	Height uint64
+	Err    error
+}
+
+func (inc *Inconsistency) Error() string {
+	return fmt.Sprintf("%s at height %d, %v", inc.Kind, inc.Height, inc.Err)
+}
+
+// Follower follows a full node with the light client and reports the inconsistencies
+type Follower struct {
+	client   *Client
+	remote   *Remote
+	keys     [][]byte
+	interval time.Duration
+
+	checkpoint uint64 // height of the last verified checkpoint
+	proofs     map[string]*storage.StateProof
+
+	stopCh chan struct{}
+}
+
+func NewFollower(client *Client, remote *Remote, keys [][]byte, interval time.Duration) *Follower {
+	return &Follower{
+		client:   client,
+		remote:   remote,
+		keys:     keys,
+		interval: interval,
+		proofs:   make(map[string]*storage.StateProof),
+	}
+}
+
+func (f *Follower) Start() {
+	f.stopCh = make(chan struct{})
+	go f.run()
+}
+
+func (f *Follower) Stop() {
+	select {
+	case <-f.stopCh:
+		return
+	default:
+	}
+	close(f.stopCh)
+}
+
+func (f *Follower) run() {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		incs, err := f.Sync()
+		for _, inc := range incs {
+			logger.I().Warnw("inconsistency found", "kind", inc.Kind, "height", inc.Height, "error", inc.Err)
+		}
+		if err != nil {
+			logger.I().Debugw("light client sync failed", "error", err)
+		}
+		logger.I().Infow("light client synced", "height", f.client.Height(),
+			"certified", f.client.CertifiedHeight(), "inconsistencies", len(incs))
+
+		select {
+		case <-f.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync verifies the new epochs, blocks, the latest checkpoint and the states of the keys,
+// the returned error is a failed request to the full node
+func (f *Follower) Sync() ([]*Inconsistency, error) {
+	// blocks after the height may start an epoch not fetched yet
+	height, err := f.remote.GetCommittedHeight()
+	if err != nil {
+		return nil, err
+	}
+	incs := make([]*Inconsistency, 0)
+	if err := f.syncEpochs(&incs); err != nil {
+		return incs, err
+	}
+	if err := f.syncBlocks(height, &incs); err != nil {
+		return incs, err
+	}
+	if err := f.syncCheckpoint(&incs); err != nil {
+		return incs, err
+	}
+	return incs, f.syncStates(&incs)
+}
+
+func (f *Follower) syncEpochs(incs *[]*Inconsistency) error {
+	epochs, err := f.remote.GetEpochs()
+	if err != nil {
+		return err
+	}
+	for _, ep := range epochs[min(f.client.EpochCount(), len(epochs)):] {
+		if err := f.client.AddEpoch(ep); err != nil {
+			*incs = append(*incs, &Inconsistency{"epoch", ep.Start, err})
+			return nil
+		}
+	}
+	return nil
+}
+
+func (f *Follower) syncBlocks(height uint64, incs *[]*Inconsistency) error {
+	for h := f.client.Height() + 1; h <= height; h++ {
+		blk, err := f.remote.GetBlockByHeight(h)
+		if err != nil {
+			return err
+		}
+		if err := f.client.VerifyBlock(blk); err != nil {
+			*incs = append(*incs, &Inconsistency{"block", h, err})
+			return nil
+		}
+	}
+	return nil
+}
+
+func (f *Follower) syncCheckpoint(incs *[]*Inconsistency) error {
+	cert, err := f.remote.GetLastCheckpoint()
+	if err != nil {
+		return nil // no checkpoint yet or disabled
+	}
+	if cert.Height() <= f.checkpoint {
+		return nil
+	}
+	if err := f.client.VerifyCheckpoint(cert); err != nil {
+		*incs = append(*incs, &Inconsistency{"checkpoint", cert.Height(), err})
+		return nil
+	}
+	f.checkpoint = cert.Height()
+	return nil
+}
+
+func (f *Follower) syncStates(incs *[]*Inconsistency) error {
+	for _, key := range f.keys {
+		if _, ok := f.proofs[string(key)]; ok {
+			continue
+		}
+		proof, err := f.remote.GetStateProof(key)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(proof.Key, key) {
+			*incs = append(*incs, &Inconsistency{"state " + hex.EncodeToString(key), proof.Height, ErrInvalidStateProof})
+			continue
+		}
+		f.proofs[string(key)] = proof
+	}
+	for key, proof := range f.proofs {
+		err := f.client.VerifyState(proof)
+		if errors.Is(err, ErrUntrustedRoot) && f.client.CertifiedHeight() <= proof.Height+stateRootWindow {
+			continue // wait for the block certifying the root
+		}
+		if err != nil {
+			*incs = append(*incs, &Inconsistency{"state " + hex.EncodeToString(proof.Key), proof.Height, err})
+		}
+		delete(f.proofs, key)
+	}
+	return nil
+}
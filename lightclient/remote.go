@@ -0,0 +1,83 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package lightclient
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/storage"
+)
+
+// Remote fetches the data to verify from the api of an untrusted full node
+type Remote struct {
+	endpoint string
+	client   *http.Client
+}
+
+func NewRemote(endpoint string) *Remote {
+	return &Remote{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GetCommittedHeight returns the height of the last block committed by the node
+func (r *Remote) GetCommittedHeight() (uint64, error) {
+	status := new(struct{ BExec uint64 })
+	if err := r.get("/consensus", status); err != nil {
+		return 0, err
+	}
+	return status.BExec, nil
+}
+
+func (r *Remote) GetBlockByHeight(height uint64) (*core.Block, error) {
+	blk := core.NewBlock()
+	if err := r.get(fmt.Sprintf("/blocks/height/%d", height), blk); err != nil {
+		return nil, err
+	}
+	return blk, nil
+}
+
+func (r *Remote) GetEpochs() ([]*core.Epoch, error) {
+	var epochs []*core.Epoch
+	if err := r.get("/epochs", &epochs); err != nil {
+		return nil, err
+	}
+	return epochs, nil
+}
+
+func (r *Remote) GetLastCheckpoint() (*core.CheckpointCert, error) {
+	cert := core.NewCheckpointCert()
+	if err := r.get("/checkpoints/latest", cert); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+func (r *Remote) GetStateProof(key []byte) (*storage.StateProof, error) {
+	proof := new(storage.StateProof)
+	if err := r.get(fmt.Sprintf("/state/%s/proof", hex.EncodeToString(key)), proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+func (r *Remote) get(path string, v interface{}) error {
+	resp, err := r.client.Get(r.endpoint + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s status code %d %s", path, resp.StatusCode, string(msg))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package merkle
+
+import (
+	"bytes"
+	"crypto"
+	"math/big"
+)
+
+// Proof is the inclusion proof of a leaf,
+// Siblings holds the other child nodes of each group from the leaf level up to the root
+type Proof struct {
+	LeafCount *big.Int
+	Leaf      *Node
+	Siblings  [][]*Node
+}
+
+// Proof returns the inclusion proof of the leaf at index with the current root-node
+func (tree *Tree) Proof(index *big.Int) *Proof {
+	leafCount := tree.store.GetLeafCount()
+	if leafCount.Cmp(index) != 1 {
+		return nil
+	}
+	p := NewPosition(0, index)
+	data := tree.store.GetNode(p)
+	if data == nil {
+		return nil
+	}
+	proof := &Proof{
+		LeafCount: leafCount,
+		Leaf:      &Node{p, data},
+		Siblings:  make([][]*Node, 0),
+	}
+	height := tree.calc.Height(leafCount)
+	rowSize := leafCount
+	for level := uint8(0); level < height-1; level++ {
+		pPos := NewPosition(level+1, tree.calc.GroupOfNode(p.Index()))
+		g := NewGroup(tree.config.Hash, tree.calc, tree.store, pPos).Load(rowSize)
+		siblings := make([]*Node, 0, len(g.nodes))
+		for _, n := range g.nodes {
+			if n != nil && n.Position.Index().Cmp(p.Index()) != 0 {
+				siblings = append(siblings, n)
+			}
+		}
+		proof.Siblings = append(proof.Siblings, siblings)
+		p = pPos
+		rowSize = tree.calc.GroupCount(rowSize)
+	}
+	return proof
+}
+
+// VerifyProof recomputes the root-node from the leaf and the siblings of the proof
+func VerifyProof(h crypto.Hash, bfactor uint8, root []byte, proof *Proof) bool {
+	if proof == nil || proof.Leaf == nil || proof.LeafCount == nil {
+		return false
+	}
+	if proof.Leaf.Position.Level() != 0 || proof.LeafCount.Cmp(proof.Leaf.Position.Index()) != 1 {
+		return false
+	}
+	if bfactor < 2 {
+		bfactor = 2
+	}
+	tc := NewTreeCalc(bfactor)
+	if len(proof.Siblings) != int(tc.Height(proof.LeafCount))-1 {
+		return false
+	}
+	node := proof.Leaf
+	rowSize := proof.LeafCount
+	for _, siblings := range proof.Siblings {
+		group := tc.GroupOfNode(node.Position.Index())
+		pPos := NewPosition(node.Position.Level()+1, group)
+		g := NewGroup(h, tc, nil, pPos).SetNode(node)
+		for _, n := range siblings {
+			if n.Position.Level() != node.Position.Level() ||
+				rowSize.Cmp(n.Position.Index()) != 1 ||
+				tc.GroupOfNode(n.Position.Index()).Cmp(group) != 0 ||
+				g.nodes[tc.NodeIndexInGroup(n.Position.Index())] != nil {
+				return false
+			}
+			g.SetNode(n)
+		}
+		node = g.MakeParent()
+		rowSize = tc.GroupCount(rowSize)
+	}
+	return bytes.Equal(node.Data, root)
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package merkle
+
+import (
+	"crypto"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTree_Proof(t *testing.T) {
+	asrt := assert.New(t)
+
+	store := NewMapStore()
+	tree := NewTree(store, Config{Hash: crypto.SHA1, BranchFactor: 3})
+	asrt.Nil(tree.Proof(big.NewInt(0)))
+
+	leaves := make([]*Node, 7)
+	for i := range leaves {
+		leaves[i] = &Node{NewPosition(0, big.NewInt(int64(i))), []byte{uint8(i)}}
+	}
+	store.CommitUpdate(tree.Update(leaves, big.NewInt(7)))
+	root := tree.Root().Data
+
+	for i := range leaves {
+		proof := tree.Proof(big.NewInt(int64(i)))
+		if asrt.NotNil(proof) {
+			asrt.Equal(leaves[i].Data, proof.Leaf.Data)
+			asrt.Len(proof.Siblings, 2)
+			asrt.True(VerifyProof(crypto.SHA1, 3, root, proof))
+		}
+	}
+	asrt.Nil(tree.Proof(big.NewInt(7)))
+
+	proof := tree.Proof(big.NewInt(4))
+	asrt.False(VerifyProof(crypto.SHA1, 2, root, proof), "wrong branch factor")
+	asrt.False(VerifyProof(crypto.SHA1, 3, []byte{1}, proof), "wrong root")
+
+	proof.Leaf = &Node{proof.Leaf.Position, []byte{9}}
+	asrt.False(VerifyProof(crypto.SHA1, 3, root, proof), "wrong leaf data")
+
+	proof = tree.Proof(big.NewInt(4))
+	proof.Siblings[0] = proof.Siblings[0][1:]
+	asrt.False(VerifyProof(crypto.SHA1, 3, root, proof), "missing sibling")
+
+	proof = tree.Proof(big.NewInt(4))
+	proof.Siblings[0][0] = &Node{NewPosition(0, big.NewInt(4)), proof.Siblings[0][0].Data}
+	asrt.False(VerifyProof(crypto.SHA1, 3, root, proof), "sibling replaces leaf")
+
+	proof = tree.Proof(big.NewInt(6))
+	proof.Siblings[0] = []*Node{{NewPosition(0, big.NewInt(7)), []byte{7}}}
+	asrt.False(VerifyProof(crypto.SHA1, 3, root, proof), "sibling out of row")
+
+	// single leaf tree, the leaf is the root
+	store = NewMapStore()
+	tree = NewTree(store, Config{Hash: crypto.SHA1, BranchFactor: 3})
+	store.CommitUpdate(tree.Update(leaves[:1], big.NewInt(1)))
+	proof = tree.Proof(big.NewInt(0))
+	asrt.Empty(proof.Siblings)
+	asrt.True(VerifyProof(crypto.SHA1, 3, leaves[0].Data, proof))
+}
@@ -39,6 +39,11 @@ func serveNodeAPI(node *Node) {
 	r.GET("/transactions/:hash/commit", api.getTxCommit)
 	r.GET("/blocks/:hash", api.getBlock)
 	r.GET("/blocks/height/:height", api.getBlockByHeight)
+	r.GET("/evidence", api.getEvidenceList)
+	r.GET("/checkpoints/latest", api.getLastCheckpoint)
+	r.GET("/checkpoints/height/:height", api.getCheckpoint)
+	r.GET("/epochs", api.getEpochs)
+	r.GET("/state/:key/proof", api.getStateProof)
 	r.POST("/querystate", api.queryState)
 	r.POST("/bincc", api.uploadBinChainCode)
 	r.Static("/bincc", node.config.ExecutionConfig.BinccDir)
@@ -65,6 +70,10 @@ func (api *nodeAPI) submitTx(c *gin.Context) {
 		c.String(http.StatusBadRequest, "cannot parse tx")
 		return
 	}
+	if err := api.checkTxSize(tx); err != nil {
+		c.String(http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
 	if err := api.node.txpool.SubmitTx(tx); err != nil {
 		logger.I().Warnf("submit tx failed %+v", err)
 		c.String(http.StatusInternalServerError, err.Error())
@@ -79,6 +88,12 @@ func (api *nodeAPI) batchSubmitTxs(c *gin.Context) {
 		c.String(http.StatusBadRequest, "cannot parse txs")
 		return
 	}
+	for _, tx := range *txs {
+		if err := api.checkTxSize(tx); err != nil {
+			c.String(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+	}
 	if err := api.node.txpool.StoreTxs(txs); err != nil {
 		logger.I().Warnf("store txs failed %+v", err)
 		c.String(http.StatusInternalServerError, err.Error())
@@ -87,6 +102,14 @@ func (api *nodeAPI) batchSubmitTxs(c *gin.Context) {
 	c.String(http.StatusOK, "transactions accepted")
 }
 
+func (api *nodeAPI) checkTxSize(tx *core.Transaction) error {
+	limit := api.node.config.ConsensusConfig.TxSizeLimit
+	if size := tx.Size(); size > limit {
+		return fmt.Errorf("tx size %d exceeds limit %d", size, limit)
+	}
+	return nil
+}
+
 func (api *nodeAPI) queryState(c *gin.Context) {
 	query := new(execution.QueryData)
 	if err := c.ShouldBind(query); err != nil {
@@ -159,6 +182,62 @@ func (api *nodeAPI) getBlockByHeight(c *gin.Context) {
 	c.JSON(http.StatusOK, blk)
 }
 
+func (api *nodeAPI) getEvidenceList(c *gin.Context) {
+	evs, err := api.node.storage.GetEvidenceList()
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, evs)
+}
+
+func (api *nodeAPI) getLastCheckpoint(c *gin.Context) {
+	cert, err := api.node.storage.GetLastCheckpointCert()
+	if err != nil {
+		c.String(http.StatusNotFound, "no checkpoint")
+		return
+	}
+	c.JSON(http.StatusOK, cert)
+}
+
+func (api *nodeAPI) getCheckpoint(c *gin.Context) {
+	hstr := c.Param("height")
+	height, err := strconv.ParseUint(hstr, 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "cannot parse height")
+		return
+	}
+	cert, err := api.node.storage.GetCheckpointCert(height)
+	if err != nil {
+		c.String(http.StatusNotFound, "no checkpoint at height")
+		return
+	}
+	c.JSON(http.StatusOK, cert)
+}
+
+func (api *nodeAPI) getEpochs(c *gin.Context) {
+	epochs, ok := api.node.vldStore.(*core.EpochStore)
+	if !ok {
+		c.String(http.StatusNotFound, "no epochs")
+		return
+	}
+	c.JSON(http.StatusOK, epochs.Epochs())
+}
+
+func (api *nodeAPI) getStateProof(c *gin.Context) {
+	key, err := hex.DecodeString(c.Param("key"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "cannot parse key")
+		return
+	}
+	proof, err := api.node.storage.GetStateProof(key)
+	if err != nil {
+		c.String(http.StatusNotFound, "no state proof for key")
+		return
+	}
+	c.JSON(http.StatusOK, proof)
+}
+
 func (api *nodeAPI) uploadBinChainCode(c *gin.Context) {
 	fh, err := c.FormFile("file")
 	if err != nil {
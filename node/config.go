@@ -5,8 +5,12 @@
 package node
 
 import (
+	"time"
+
 	"github.com/wooyang2018/ppov-blockchain/consensus"
+	"github.com/wooyang2018/ppov-blockchain/core"
 	"github.com/wooyang2018/ppov-blockchain/execution"
+	"github.com/wooyang2018/ppov-blockchain/remotesigner"
 	"github.com/wooyang2018/ppov-blockchain/storage"
 )
 
@@ -20,16 +24,31 @@ type Config struct {
 	APIPort     int
 	BroadcastTx bool
 
+	SigCacheSize int // verified signatures cached by the shared verifier
+
+	RemoteSigner  string                // address of the signer daemon, the nodekey is then only the p2p identity
+	SignerTimeout time.Duration         // timeout of a remote sign request
+	SignerTLS     remotesigner.TLSFiles // client certificate of a tcp signer address
+
+	KeyConfig       KeyConfig
 	StorageConfig   storage.Config
 	ExecutionConfig execution.Config
 	ConsensusConfig consensus.Config
 }
 
+// KeyConfig tells how to read the nodekey
+type KeyConfig struct {
+	PassphraseFile string // 加密nodekey的口令文件 (缺省时读取环境变量或终端输入)
+	PlainNodekey   bool   // 是否允许明文nodekey
+}
+
 var DefaultConfig = Config{
 	PointPort:       15150,
 	TopicPort:       16150,
 	APIPort:         9040,
 	BroadcastTx:     false,
+	SigCacheSize:    core.DefaultSigCacheSize,
+	SignerTimeout:   3 * time.Second,
 	StorageConfig:   storage.DefaultConfig,
 	ExecutionConfig: execution.DefaultConfig,
 	ConsensusConfig: consensus.DefaultConfig,
@@ -14,33 +14,81 @@ import (
 	"github.com/multiformats/go-multiaddr"
 
 	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/keystore"
 	"github.com/wooyang2018/ppov-blockchain/p2p"
+	"github.com/wooyang2018/ppov-blockchain/storage"
 )
 
 type Peer struct {
-	PubKey    []byte
-	PointAddr string
-	TopicAddr string
-	Name      string
+	PubKey       []byte
+	PointAddr    string
+	TopicAddr    string
+	Name         string
+	TransportKey []byte `json:",omitempty"` // p2p身份公钥 (使用远程签名时为nodekey公钥, 缺省同PubKey)
 }
 
 type Genesis struct {
-	Workers []string // 记账节点列表
-	Voters  []string // 投票节点列表
+	Workers []string          // 记账节点列表
+	Voters  []string          // 投票节点列表
+	Variant string            // hotstuff提交规则 (two-phase/three-phase)
+	Powers  map[string]uint64 `json:",omitempty"` // 验证节点投票权重 (缺省为1)
+	Block   *core.Block       `json:",omitempty"` // 离线签署的创世区块 (缺省时由验证节点在线创建)
+	QC      *core.QuorumCert  `json:",omitempty"` // 创世区块的法定人数证书
+
+	SignVersion      core.SignVersion `json:",omitempty"` // 签名方案版本 (缺省为0即签名原始哈希, 1为绑定链ID和消息类型)
+	SignDomainHeight uint64           `json:",omitempty"` // 版本0的链从该高度起切换为版本1 (缺省为0即不切换)
+	StateLeafHeight  uint64           `json:",omitempty"` // 版本0的链从该高度起状态叶子哈希绑定键 (缺省为0即不切换)
+}
+
+// SignScheme returns the sign scheme of the chain created with the genesis file
+func (g *Genesis) SignScheme(chainID int64) (*core.SignScheme, error) {
+	return core.NewSignSchemeOf(g.SignVersion, chainID, g.SignDomainHeight)
+}
+
+// KeyedLeafHeight returns the height from which the state merkle leaves hash their keys,
+// a chain created with SignDomain hashes them from genesis
+func (g *Genesis) KeyedLeafHeight() uint64 {
+	if g.SignVersion != core.SignLegacy {
+		return 0
+	}
+	if g.StateLeafHeight == 0 {
+		return storage.NeverKeyedLeaf
+	}
+	return g.StateLeafHeight
 }
 
 const (
 	NodekeyFile = "nodekey"
 	GenesisFile = "genesis.json"
 	PeersFile   = "peers.json"
+
+	GenesisBlockFile = "genesis_block.json"
+	GenesisVoteFile  = "genesis_vote.json"
 )
 
-func readNodeKey(datadir string) (*core.PrivateKey, error) {
+// ErrPlainNodekey is returned for a plaintext nodekey unless it's explicitly allowed
+var ErrPlainNodekey = errors.New("nodekey is not encrypted, import it with `chain key import` or allow it with --plainNodekey")
+
+func readNodeKey(datadir string, config KeyConfig) (*core.PrivateKey, error) {
 	b, err := os.ReadFile(path.Join(datadir, NodekeyFile))
 	if err != nil {
 		return nil, fmt.Errorf("cannot read %s, %w", NodekeyFile, err)
 	}
-	return core.NewPrivateKey(b)
+	if !keystore.IsKeyFile(b) {
+		if !config.PlainNodekey {
+			return nil, ErrPlainNodekey
+		}
+		return core.NewPrivateKey(b)
+	}
+	kf, err := keystore.ParseKeyFile(b)
+	if err != nil {
+		return nil, err
+	}
+	pass, err := keystore.ReadPassphrase(config.PassphraseFile, "Nodekey passphrase: ", false)
+	if err != nil {
+		return nil, err
+	}
+	return keystore.Decrypt(kf, pass)
 }
 
 func readGenesis(datadir string) (*Genesis, error) {
@@ -58,6 +106,35 @@ func readGenesis(datadir string) (*Genesis, error) {
 	return genesis, nil
 }
 
+func writeGenesis(datadir string, genesis *Genesis) error {
+	return writeJSONFile(path.Join(datadir, GenesisFile), genesis)
+}
+
+func readJSONFile(name string, v interface{}) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("cannot read %s, %w", name, err)
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("cannot parse %s, %w", name, err)
+	}
+	return nil
+}
+
+func writeJSONFile(name string, v interface{}) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	return e.Encode(v)
+}
+
 func readPeers(datadir string) ([]*p2p.Peer, error) {
 	f, err := os.Open(path.Join(datadir, PeersFile))
 	if err != nil {
@@ -87,6 +164,13 @@ func readPeers(datadir string) ([]*p2p.Peer, error) {
 		}
 		peers[i] = p2p.NewPeer(pubKey, pointAddr, topicAddr)
 		peers[i].SetName(r.Name)
+		if r.TransportKey != nil {
+			transportKey, err := core.NewPublicKey(r.TransportKey)
+			if err != nil {
+				return nil, fmt.Errorf("invalid transport key %w", err)
+			}
+			peers[i].SetTransportKey(transportKey)
+		}
 	}
 	return peers, nil
 }
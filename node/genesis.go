@@ -0,0 +1,114 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package node
+
+import (
+	"errors"
+	"path"
+	"time"
+
+	"github.com/wooyang2018/ppov-blockchain/consensus"
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/hotstuff"
+)
+
+// 离线创世流程: InitGenesis 生成未签名的创世区块, 各验证节点使用 SignGenesis 离线投票,
+// AssembleGenesis 汇总投票并将创世区块和 QC 写入 genesis.json
+
+// InitGenesis writes the unsigned genesis block of the validators in genesis file
+func InitGenesis(datadir string, chainID int64) (*core.Block, error) {
+	genesis, variant, vs, err := loadGenesisSetup(datadir, chainID)
+	if err != nil {
+		return nil, err
+	}
+	if genesis.Block != nil {
+		return nil, errors.New("genesis block already signed")
+	}
+	b0 := consensus.NewGenesisBlock(chainID, variant, vs, time.Now().UnixNano())
+	if err := writeJSONFile(path.Join(datadir, GenesisBlockFile), b0); err != nil {
+		return nil, err
+	}
+	return b0, nil
+}
+
+// SignGenesis votes for the genesis block with the node key and writes the vote file
+func SignGenesis(datadir string, chainID int64, blockFile string, keyConfig KeyConfig) (*core.Vote, error) {
+	_, variant, vs, err := loadGenesisSetup(datadir, chainID)
+	if err != nil {
+		return nil, err
+	}
+	privKey, err := readNodeKey(datadir, keyConfig)
+	if err != nil {
+		return nil, err
+	}
+	if !vs.IsWorker(privKey.PublicKey()) && !vs.IsVoter(privKey.PublicKey()) {
+		return nil, errors.New("node is not a validator")
+	}
+	b0 := core.NewBlock()
+	if err := readJSONFile(blockFile, b0); err != nil {
+		return nil, err
+	}
+	if err := consensus.CheckGenesisBlock(b0, chainID, variant, vs); err != nil {
+		return nil, err
+	}
+	vote := b0.Vote(core.NewChainSigner(privKey, vs.SignScheme()))
+	if err := writeJSONFile(path.Join(datadir, GenesisVoteFile), vote); err != nil {
+		return nil, err
+	}
+	return vote, nil
+}
+
+// AssembleGenesis builds the qc from the vote files and adds the signed genesis block to genesis file
+func AssembleGenesis(datadir string, chainID int64, blockFile string, voteFiles []string) (*core.Block, error) {
+	genesis, variant, vs, err := loadGenesisSetup(datadir, chainID)
+	if err != nil {
+		return nil, err
+	}
+	b0 := core.NewBlock()
+	if err := readJSONFile(blockFile, b0); err != nil {
+		return nil, err
+	}
+	if err := consensus.CheckGenesisBlock(b0, chainID, variant, vs); err != nil {
+		return nil, err
+	}
+	votes := make([]*core.Vote, len(voteFiles))
+	for i, name := range voteFiles {
+		votes[i] = core.NewVote()
+		if err := readJSONFile(name, votes[i]); err != nil {
+			return nil, err
+		}
+	}
+	q0, err := consensus.CertifyGenesisBlock(b0, votes, vs)
+	if err != nil {
+		return nil, err
+	}
+	if err := consensus.VerifyGenesis(b0, q0, chainID, variant, vs); err != nil {
+		return nil, err
+	}
+	genesis.Block = b0
+	genesis.QC = q0
+	return b0, writeGenesis(datadir, genesis)
+}
+
+// loadGenesisSetup also sets the sign scheme of the chain on the validator store to validate votes
+func loadGenesisSetup(datadir string, chainID int64) (*Genesis, hotstuff.Variant, *core.EpochStore, error) {
+	genesis, err := readGenesis(datadir)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	variant, err := hotstuff.ParseVariant(genesis.Variant)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	scheme, err := genesis.SignScheme(chainID)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if len(genesis.Workers) == 0 {
+		return nil, 0, nil, errors.New("no workers in genesis file")
+	}
+	vs := core.NewEpochStore(genesis.Workers, genesis.Voters, genesis.Powers)
+	vs.SetSignScheme(scheme)
+	return genesis, variant, vs, nil
+}
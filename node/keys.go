@@ -0,0 +1,84 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package node
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/keystore"
+)
+
+// nodekey 管理: GenerateNodeKey 和 ImportNodeKey 写入加密的 nodekey,
+// ExportNodeKey 导出明文私钥, ChangePassphrase 更换口令
+
+// GenerateNodeKey writes a new encrypted nodekey, an existing nodekey is never overwritten
+func GenerateNodeKey(datadir string, pass []byte, params keystore.ScryptParams) (*core.PrivateKey, error) {
+	name := path.Join(datadir, NodekeyFile)
+	if _, err := os.Stat(name); err == nil {
+		return nil, fmt.Errorf("%s already exists", name)
+	}
+	priv := core.GenerateKey(nil)
+	if err := keystore.WriteKeyFile(name, priv, pass, params); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// ImportNodeKey encrypts the raw private key file as the nodekey,
+// the plaintext nodekey in datadir is imported in place if file is empty
+func ImportNodeKey(datadir, file string, pass []byte, params keystore.ScryptParams) (*core.PrivateKey, error) {
+	name := path.Join(datadir, NodekeyFile)
+	if file == "" {
+		file = name
+	} else if _, err := os.Stat(name); err == nil {
+		return nil, fmt.Errorf("%s already exists", name)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if keystore.IsKeyFile(b) {
+		return nil, fmt.Errorf("%s is already encrypted", file)
+	}
+	priv, err := core.NewPrivateKey(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := keystore.WriteKeyFile(name, priv, pass, params); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// ExportNodeKey writes the raw bytes of the nodekey to file
+func ExportNodeKey(datadir, file string, config KeyConfig) (*core.PrivateKey, error) {
+	priv, err := readNodeKey(datadir, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(file, priv.Bytes(), 0600); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// ChangePassphrase re-encrypts the nodekey with the new passphrase
+func ChangePassphrase(datadir string, config KeyConfig, newPass []byte,
+	params keystore.ScryptParams,
+) (*core.PrivateKey, error) {
+	config.PlainNodekey = false
+	priv, err := readNodeKey(datadir, config)
+	if err != nil {
+		return nil, err
+	}
+	name := path.Join(datadir, NodekeyFile)
+	tmp := name + ".tmp"
+	if err := keystore.WriteKeyFile(tmp, priv, newPass, params); err != nil {
+		return nil, err
+	}
+	return priv, os.Rename(tmp, name)
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package node
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/wooyang2018/ppov-blockchain/consensus"
+	"github.com/wooyang2018/ppov-blockchain/lightclient"
+	"github.com/wooyang2018/ppov-blockchain/logger"
+)
+
+// RunLightClient follows the remote node and logs the inconsistencies until the process is killed
+func RunLightClient(config Config, endpoint string, keys [][]byte, interval time.Duration) {
+	node := new(Node)
+	node.config = config
+	node.setupLogger()
+
+	remote := lightclient.NewRemote(endpoint)
+	client, err := NewLightClient(config.DataDir, config.ConsensusConfig.ChainID, remote,
+		lightclient.Config{MerkleBranchFactor: config.StorageConfig.MerkleBranchFactor})
+	if err != nil {
+		logger.I().Fatalw("setup light client failed", "error", err)
+	}
+	follower := lightclient.NewFollower(client, remote, keys, interval)
+	follower.Start()
+	logger.I().Infow("light client started", "endpoint", endpoint, "keys", len(keys))
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	<-c
+	follower.Stop()
+	logger.I().Info("light client stopped")
+}
+
+// NewLightClient creates a light client trusting the validators and the chain id of genesis file,
+// the genesis block created online is fetched from the remote node and checked against them
+func NewLightClient(datadir string, chainID int64, remote *lightclient.Remote,
+	config lightclient.Config,
+) (*lightclient.Client, error) {
+	genesis, variant, vs, err := loadGenesisSetup(datadir, chainID)
+	if err != nil {
+		return nil, err
+	}
+	b0, err := remote.GetBlockByHeight(0)
+	if err != nil {
+		return nil, err
+	}
+	if genesis.Block != nil && !bytes.Equal(genesis.Block.Hash(), b0.Hash()) {
+		return nil, errors.New("remote genesis block differs from genesis file")
+	}
+	if err := consensus.CheckGenesisBlock(b0, chainID, variant, vs); err != nil {
+		return nil, err
+	}
+	if err := b0.Validate(vs); err != nil {
+		return nil, err
+	}
+	config.KeyedLeafHeight = genesis.KeyedLeafHeight()
+	return lightclient.New(b0, vs, config), nil
+}
@@ -5,6 +5,7 @@
 package node
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net"
@@ -20,8 +21,10 @@ import (
 	"github.com/wooyang2018/ppov-blockchain/consensus"
 	"github.com/wooyang2018/ppov-blockchain/core"
 	"github.com/wooyang2018/ppov-blockchain/execution"
+	"github.com/wooyang2018/ppov-blockchain/hotstuff"
 	"github.com/wooyang2018/ppov-blockchain/logger"
 	"github.com/wooyang2018/ppov-blockchain/p2p"
+	"github.com/wooyang2018/ppov-blockchain/remotesigner"
 	"github.com/wooyang2018/ppov-blockchain/storage"
 	"github.com/wooyang2018/ppov-blockchain/txpool"
 )
@@ -30,8 +33,10 @@ type Node struct {
 	config Config
 
 	privKey *core.PrivateKey
+	signer  core.Signer // the nodekey, or the remote signer of the validator key
 	peers   []*p2p.Peer
 	genesis *Genesis
+	scheme  *core.SignScheme
 
 	vldStore  core.ValidatorStore
 	storage   *storage.Storage
@@ -49,6 +54,7 @@ func Run(config Config) {
 	node.setupLogger()
 	node.readFiles()
 	node.limitCPUs()
+	node.setupSigVerifier()
 	node.setupComponents()
 	logger.I().Infow("node setup done")
 	node.consensus.Start()
@@ -62,13 +68,18 @@ func Run(config Config) {
 }
 
 func (node *Node) limitCPUs() {
-	if consensus.PreserveTxFlag && !consensus.GenerateTxFlag {
+	config := node.config.ConsensusConfig
+	if config.PreserveTxFlag && !config.GenerateTxFlag {
 		runtime.GOMAXPROCS(MaxProcsNum)
 		logger.I().Debugf("setup node to use %d out of %d CPUs",
 			runtime.GOMAXPROCS(0), runtime.NumCPU())
 	}
 }
 
+func (node *Node) setupSigVerifier() {
+	core.SetSigVerifier(core.NewSigVerifier(runtime.GOMAXPROCS(0), node.config.SigCacheSize))
+}
+
 func (node *Node) setupLogger() {
 	var inst *zap.Logger
 	var err error
@@ -90,7 +101,7 @@ func (node *Node) setupBinccDir() {
 
 func (node *Node) readFiles() {
 	var err error
-	node.privKey, err = readNodeKey(node.config.DataDir)
+	node.privKey, err = readNodeKey(node.config.DataDir, node.config.KeyConfig)
 	if err != nil {
 		logger.I().Fatalw("read key failed", "error", err)
 	}
@@ -100,12 +111,43 @@ func (node *Node) readFiles() {
 	if err != nil {
 		logger.I().Fatalw("read genesis failed", "error", err)
 	}
+	node.config.ConsensusConfig.Variant, err = hotstuff.ParseVariant(node.genesis.Variant)
+	if err != nil {
+		logger.I().Fatalw("read genesis failed", "error", err)
+	}
+	node.config.ConsensusConfig.GenesisBlock = node.genesis.Block
+	node.config.ConsensusConfig.GenesisQC = node.genesis.QC
+	node.scheme, err = node.genesis.SignScheme(node.config.ConsensusConfig.ChainID)
+	if err != nil {
+		logger.I().Fatalw("read genesis failed", "error", err)
+	}
+	node.signer = core.NewChainSigner(node.privKey, node.scheme)
+	node.config.StorageConfig.KeyedLeafHeight = node.genesis.KeyedLeafHeight()
+	logger.I().Infow("read genesis", "variant", node.config.ConsensusConfig.Variant,
+		"signVersion", node.genesis.SignVersion, "signDomainHeight", node.scheme.DomainHeight(),
+		"keyedLeafHeight", node.config.StorageConfig.KeyedLeafHeight, "signed", node.genesis.Block != nil)
 
 	node.peers, err = readPeers(node.config.DataDir)
 	if err != nil {
 		logger.I().Fatalw("read peers failed", "error", err)
 	}
 	logger.I().Infow("read peers", "count", len(node.peers))
+
+	if node.config.RemoteSigner != "" {
+		// the signer daemon only signs with SignDomain of the same chain id
+		var tlsConf *tls.Config
+		if !node.config.SignerTLS.Empty() {
+			if tlsConf, err = node.config.SignerTLS.Config(); err != nil {
+				logger.I().Fatalw("load signer tls files failed", "error", err)
+			}
+		}
+		client, err := remotesigner.Dial(node.config.RemoteSigner, node.config.SignerTimeout, tlsConf)
+		if err != nil {
+			logger.I().Fatalw("connect remote signer failed", "error", err)
+		}
+		node.signer = core.NewChainSigner(client, node.scheme)
+		logger.I().Infow("connected remote signer", "pubkey", node.signer.PublicKey())
+	}
 }
 
 func (node *Node) setupComponents() {
@@ -116,14 +158,18 @@ func (node *Node) setupComponents() {
 		"topic port", node.config.TopicPort, "broadcastTx", node.config.BroadcastTx)
 	node.msgSvc = p2p.NewMsgService(node.host)
 	node.execution = execution.New(node.storage, node.config.ExecutionConfig)
-	node.txpool = txpool.New(node.storage, node.execution, node.msgSvc, node.config.BroadcastTx)
+	node.txpool = txpool.New(node.storage, node.execution, node.msgSvc, node.scheme,
+		node.config.ConsensusConfig.TxSizeLimit, node.config.BroadcastTx)
 	node.setupConsensus()
 	node.setReqHandlers()
 	serveNodeAPI(node)
 }
 
+// setupValidatorStore starts from the genesis validators, scheduled epochs are restored by consensus
 func (node *Node) setupValidatorStore() {
-	node.vldStore = core.NewValidatorStore(node.genesis.Workers, node.genesis.Voters)
+	vs := core.NewEpochStore(node.genesis.Workers, node.genesis.Voters, node.genesis.Powers)
+	vs.SetSignScheme(node.scheme)
+	node.vldStore = vs
 }
 
 func (node *Node) setupStorage() {
@@ -140,12 +186,13 @@ func (node *Node) setupHost() {
 	pointAddr, _ := multiaddr.NewMultiaddr(fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", node.config.PointPort))
 	topicAddr, _ := multiaddr.NewMultiaddr(fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", node.config.TopicPort))
 	host, err := p2p.NewHost(node.privKey, pointAddr, topicAddr)
-	host.SetPeers(node.peers)
 	if err != nil {
 		logger.I().Fatalw("cannot create p2p host", "error", err)
 	}
+	host.SetValidatorKey(node.signer.PublicKey())
+	host.SetPeers(node.peers)
 	for _, p := range node.peers {
-		if p.PublicKey().Equal(node.privKey.PublicKey()) {
+		if p.PublicKey().Equal(node.signer.PublicKey()) {
 			host.SetName(p.Name())
 		} else {
 			host.AddPeer(p)
@@ -167,7 +214,7 @@ func checkPort(port int) {
 
 func (node *Node) setupConsensus() {
 	node.consensus = consensus.New(&consensus.Resources{
-		Signer:    node.privKey,
+		Signer:    node.signer,
 		VldStore:  node.vldStore,
 		Storage:   node.storage,
 		MsgSvc:    node.msgSvc,
@@ -187,6 +234,9 @@ func (node *Node) setReqHandlers() {
 	node.msgSvc.SetReqHandler(&p2p.TxListReqHandler{
 		GetTxList: node.GetTxList,
 	})
+	node.msgSvc.SetReqHandler(&p2p.BatchReqHandler{
+		GetBatch: node.storage.GetBatch,
+	})
 }
 
 func (node *Node) GetBlock(hash []byte) (*core.Block, error) {
@@ -7,6 +7,8 @@ package p2p
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p"
@@ -28,9 +30,12 @@ const protocolID = "/point2point"
 
 type Host struct {
 	privKey   *core.PrivateKey
+	pubKey    *core.PublicKey // validator key, the same as the key of privKey unless kept by a remote signer
 	name      string
 	peerStore *PeerStore
-	peers     []*Peer
+	peers     []*Peer // ordered by worker index
+	allPeers  []*Peer // all known peers, including the ones out of the validator set
+	mtxPeers  sync.RWMutex
 
 	pointAddr  multiaddr.Multiaddr
 	pointHost  host.Host
@@ -44,6 +49,7 @@ type Host struct {
 func NewHost(privKey *core.PrivateKey, pointAddr, topicAddr multiaddr.Multiaddr) (*Host, error) {
 	host := new(Host)
 	host.privKey = privKey
+	host.pubKey = privKey.PublicKey()
 	host.pointAddr = pointAddr
 	host.topicAddr = topicAddr
 	host.peerStore = NewPeerStore()
@@ -60,7 +66,10 @@ func NewHost(privKey *core.PrivateKey, pointAddr, topicAddr multiaddr.Multiaddr)
 }
 
 func (host *Host) newLibHost() (host.Host, host.Host, error) {
-	priv, err := crypto.UnmarshalEd25519PrivateKey(host.privKey.Bytes())
+	priv, err := getLibPrivateKey(host.privKey)
+	if err != nil {
+		return nil, nil, err
+	}
 	pointHost, err := libp2p.New(
 		libp2p.Identity(priv),
 		libp2p.ListenAddrs(host.pointAddr),
@@ -87,7 +96,7 @@ func (host *Host) handleStream(s network.Stream) {
 	if err != nil {
 		return
 	}
-	if peer := host.peerStore.Load(pubKey); peer != nil {
+	if peer := host.peerStore.LoadByTransport(pubKey); peer != nil {
 		if err := peer.setConnecting(); err == nil {
 			peer.onConnected(s)
 			return
@@ -124,7 +133,51 @@ func (host *Host) Close() {
 }
 
 func (host *Host) SetPeers(peers []*Peer) {
+	host.mtxPeers.Lock()
+	defer host.mtxPeers.Unlock()
 	host.peers = peers
+	host.allPeers = peers
+}
+
+// SetValidators allows connections only from the given validators on epoch change,
+// workers must be ordered by worker index for leader lookup
+func (host *Host) SetValidators(workers, validators []*core.PublicKey) {
+	host.mtxPeers.Lock()
+	defer host.mtxPeers.Unlock()
+
+	known := make(map[string]*Peer, len(host.allPeers))
+	for _, p := range host.allPeers {
+		known[p.PublicKey().String()] = p
+	}
+	host.peers = make([]*Peer, len(workers))
+	for i, w := range workers {
+		host.peers[i] = known[w.String()]
+		if host.peers[i] == nil {
+			logger.I().Warnw("unknown worker peer", "pubkey", w)
+		}
+	}
+	allowed := make(map[string]struct{}, len(validators))
+	for _, v := range validators {
+		allowed[v.String()] = struct{}{}
+	}
+	for _, p := range host.allPeers {
+		if p.PublicKey().Equal(host.pubKey) {
+			continue
+		}
+		_, ok := allowed[p.PublicKey().String()]
+		stored := host.peerStore.Load(p.PublicKey()) != nil
+		if ok && !stored {
+			host.AddPeer(p)
+		} else if !ok && stored {
+			host.peerStore.Delete(p.PublicKey())
+			p.disconnect()
+		}
+	}
+}
+
+// SetValidatorKey sets the validator key of the host when the private key is only its p2p identity
+func (host *Host) SetValidatorKey(pubKey *core.PublicKey) {
+	host.pubKey = pubKey
 }
 
 func (host *Host) SetName(name string) {
@@ -132,14 +185,23 @@ func (host *Host) SetName(name string) {
 }
 
 func (host *Host) SetLeader(idx int) {
+	host.mtxPeers.RLock()
+	defer host.mtxPeers.RUnlock()
+	if idx < 0 || idx >= len(host.peers) || host.peers[idx] == nil {
+		logger.I().Errorw("leader peer not found", "index", idx)
+		return
+	}
 	host.consLeader = host.peers[idx]
-	if !host.consLeader.pubKey.Equal(host.privKey.PublicKey()) {
+	if !host.consLeader.pubKey.Equal(host.pubKey) {
 		host.ConnectLeader()
 	}
 }
 
 func (host *Host) ConnectLeader() {
 	leader := host.consLeader
+	if leader == nil {
+		return
+	}
 	// prevent simultaneous connections from both hosts
 	if err := leader.setConnecting(); err != nil {
 		logger.I().Error(err)
@@ -161,7 +223,7 @@ func (host *Host) SubscribeMsg() *emitter.Subscription {
 
 func (host *Host) newStream(peer *Peer) (network.Stream, error) {
 	logger.I().Debugw("newing stream to peer", "pubkey", peer.PublicKey())
-	id, err := getIDFromPublicKey(peer.PublicKey())
+	id, err := getIDFromPublicKey(peer.TransportKey())
 	if err != nil {
 		return nil, err
 	}
@@ -178,15 +240,31 @@ func (host *Host) PeerStore() *PeerStore {
 	return host.peerStore
 }
 
+func getLibPrivateKey(priv *core.PrivateKey) (crypto.PrivKey, error) {
+	switch priv.PublicKey().Type() {
+	case core.KeyEd25519:
+		return crypto.UnmarshalEd25519PrivateKey(priv.Bytes())
+	case core.KeySecp256k1:
+		return crypto.UnmarshalSecp256k1PrivateKey(priv.Bytes())
+	}
+	return nil, fmt.Errorf("unsupported key type %s", priv.PublicKey().Type())
+}
+
 func getRemotePublicKey(s network.Stream) (*core.PublicKey, error) {
-	if _, ok := s.Conn().RemotePublicKey().(*crypto.Ed25519PublicKey); !ok {
+	var keyType core.KeyType
+	switch s.Conn().RemotePublicKey().(type) {
+	case *crypto.Ed25519PublicKey:
+		keyType = core.KeyEd25519
+	case *crypto.Secp256k1PublicKey:
+		keyType = core.KeySecp256k1
+	default:
 		return nil, errors.New("invalid pubKey type")
 	}
 	b, err := s.Conn().RemotePublicKey().Raw()
 	if err != nil {
 		return nil, err
 	}
-	return core.NewPublicKey(b)
+	return core.NewTypedPublicKey(keyType, b)
 }
 
 func getIDFromPublicKey(pubKey *core.PublicKey) (peer.ID, error) {
@@ -194,7 +272,16 @@ func getIDFromPublicKey(pubKey *core.PublicKey) (peer.ID, error) {
 	if pubKey == nil {
 		return id, errors.New("nil peer pubkey")
 	}
-	key, err := crypto.UnmarshalEd25519PublicKey(pubKey.Bytes())
+	var key crypto.PubKey
+	var err error
+	switch pubKey.Type() {
+	case core.KeyEd25519:
+		key, err = crypto.UnmarshalEd25519PublicKey(pubKey.Bytes())
+	case core.KeySecp256k1:
+		key, err = crypto.UnmarshalSecp256k1PublicKey(pubKey.Bytes())
+	default:
+		err = fmt.Errorf("unsupported key type %s", pubKey.Type())
+	}
 	if err != nil {
 		return id, err
 	}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/assert"
 
@@ -131,3 +132,46 @@ func TestTopicHost(t *testing.T) {
 	host1.Close()
 	host2.Close()
 }
+
+func TestHost_SetValidators(t *testing.T) {
+	asrt := assert.New(t)
+
+	pointAddr, _ := multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/15153")
+	topicAddr, _ := multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/16163")
+	priv := core.GenerateKey(nil)
+	host, err := NewHost(priv, pointAddr, topicAddr)
+	asrt.NoError(err)
+	defer host.Close()
+
+	self := NewPeer(priv.PublicKey(), pointAddr, topicAddr)
+	peer1 := NewPeer(core.GenerateKey(nil).PublicKey(), pointAddr, topicAddr)
+	peer2 := NewPeer(core.GenerateKey(nil).PublicKey(), pointAddr, topicAddr)
+	host.SetPeers([]*Peer{self, peer1, peer2})
+	host.AddPeer(peer1)
+
+	// peer1 leaves, peer2 joins as the first worker
+	host.SetValidators(
+		[]*core.PublicKey{peer2.PublicKey(), self.PublicKey()},
+		[]*core.PublicKey{peer2.PublicKey(), self.PublicKey()},
+	)
+	asrt.Nil(host.PeerStore().Load(peer1.PublicKey()))
+	asrt.NotNil(host.PeerStore().Load(peer2.PublicKey()))
+	asrt.Nil(host.PeerStore().Load(self.PublicKey()))
+	asrt.Equal(peer2, host.peers[0])
+	asrt.Equal(self, host.peers[1])
+}
+
+func TestGetIDFromPublicKey(t *testing.T) {
+	asrt := assert.New(t)
+	for _, keyType := range []core.KeyType{core.KeyEd25519, core.KeySecp256k1} {
+		priv, err := core.GenerateTypedKey(keyType, nil)
+		asrt.NoError(err)
+		libPriv, err := getLibPrivateKey(priv)
+		asrt.NoError(err)
+		expected, err := peer.IDFromPrivateKey(libPriv)
+		asrt.NoError(err)
+		id, err := getIDFromPublicKey(priv.PublicKey())
+		asrt.NoError(err)
+		asrt.Equal(expected, id, keyType.String())
+	}
+}
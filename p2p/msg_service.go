@@ -33,6 +33,10 @@ const (
 	MsgTypeTxList
 	MsgTypeRequest
 	MsgTypeResponse
+	MsgTypeTimeout
+	MsgTypeTimeoutCert
+	MsgTypeEvidence
+	MsgTypeCheckpointVote
 )
 
 type msgReceiver func(peer *Peer, data []byte)
@@ -49,6 +53,10 @@ type MsgService struct {
 	voteEmitter      *emitter.Emitter
 	newViewEmitter   *emitter.Emitter
 	txListEmitter    *emitter.Emitter
+	timeoutEmitter   *emitter.Emitter
+	tcEmitter        *emitter.Emitter
+	evidenceEmitter  *emitter.Emitter
+	cpVoteEmitter    *emitter.Emitter
 
 	reqHandlers  map[pb.Request_Type]ReqHandler
 	reqClientSeq uint32
@@ -93,6 +101,22 @@ func (svc *MsgService) SubscribeTxList(buffer int) *emitter.Subscription {
 	return svc.txListEmitter.Subscribe(buffer)
 }
 
+func (svc *MsgService) SubscribeTimeout(buffer int) *emitter.Subscription {
+	return svc.timeoutEmitter.Subscribe(buffer)
+}
+
+func (svc *MsgService) SubscribeTimeoutCert(buffer int) *emitter.Subscription {
+	return svc.tcEmitter.Subscribe(buffer)
+}
+
+func (svc *MsgService) SubscribeEvidence(buffer int) *emitter.Subscription {
+	return svc.evidenceEmitter.Subscribe(buffer)
+}
+
+func (svc *MsgService) SubscribeCheckpointVote(buffer int) *emitter.Subscription {
+	return svc.cpVoteEmitter.Subscribe(buffer)
+}
+
 func (svc *MsgService) BroadcastProposal(blk *core.Block) error {
 	data, err := blk.Marshal()
 	if err != nil {
@@ -109,14 +133,46 @@ func (svc *MsgService) BroadcastBatch(batch *core.Batch) error {
 	return svc.broadcastData(MsgTypeBatch, data)
 }
 
-func (svc *MsgService) BroadcastNewView(qc *core.QuorumCert) error {
-	data, err := qc.Marshal()
+func (svc *MsgService) BroadcastNewView(nv *core.NewView) error {
+	data, err := nv.Marshal()
 	if err != nil {
 		return err
 	}
 	return svc.broadcastData(MsgTypeNewView, data)
 }
 
+func (svc *MsgService) BroadcastTimeout(to *core.Timeout) error {
+	data, err := to.Marshal()
+	if err != nil {
+		return err
+	}
+	return svc.broadcastData(MsgTypeTimeout, data)
+}
+
+func (svc *MsgService) BroadcastTimeoutCert(tc *core.TimeoutCert) error {
+	data, err := tc.Marshal()
+	if err != nil {
+		return err
+	}
+	return svc.broadcastData(MsgTypeTimeoutCert, data)
+}
+
+func (svc *MsgService) BroadcastEvidence(ev *core.Evidence) error {
+	data, err := ev.Marshal()
+	if err != nil {
+		return err
+	}
+	return svc.broadcastData(MsgTypeEvidence, data)
+}
+
+func (svc *MsgService) BroadcastCheckpointVote(vote *core.CheckpointVote) error {
+	data, err := vote.Marshal()
+	if err != nil {
+		return err
+	}
+	return svc.broadcastData(MsgTypeCheckpointVote, data)
+}
+
 func (svc *MsgService) SendBatch(pubKey *core.PublicKey, batch *core.Batch) error {
 	data, err := batch.Marshal()
 	if err != nil {
@@ -141,8 +197,8 @@ func (svc *MsgService) SendBatchVote(pubKey *core.PublicKey, vote *core.BatchVot
 	return svc.sendData(pubKey, MsgTypeBatchVote, data)
 }
 
-func (svc *MsgService) SendNewView(pubKey *core.PublicKey, qc *core.QuorumCert) error {
-	data, err := qc.Marshal()
+func (svc *MsgService) SendNewView(pubKey *core.PublicKey, nv *core.NewView) error {
+	data, err := nv.Marshal()
 	if err != nil {
 		return err
 	}
@@ -185,6 +241,18 @@ func (svc *MsgService) RequestBlockByHeight(
 	return blk, nil
 }
 
+func (svc *MsgService) RequestBatch(pubKey *core.PublicKey, hash []byte) (*core.Batch, error) {
+	respData, err := svc.requestData(pubKey, pb.Request_Batch, hash)
+	if err != nil {
+		return nil, err
+	}
+	batch := core.NewBatch()
+	if err := batch.Unmarshal(respData); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
 func (svc *MsgService) RequestTxList(pubKey *core.PublicKey, hashes [][]byte) (*core.TxList, error) {
 	hl := new(pb.HashList)
 	hl.List = hashes
@@ -215,6 +283,10 @@ func (svc *MsgService) setEmitters() {
 	svc.voteEmitter = emitter.New()
 	svc.newViewEmitter = emitter.New()
 	svc.txListEmitter = emitter.New()
+	svc.timeoutEmitter = emitter.New()
+	svc.tcEmitter = emitter.New()
+	svc.evidenceEmitter = emitter.New()
+	svc.cpVoteEmitter = emitter.New()
 }
 
 func (svc *MsgService) setMsgReceivers() {
@@ -234,6 +306,10 @@ func (svc *MsgService) setTopicReceivers() {
 	svc.topicReceivers[MsgTypeProposal] = svc.onReceiveProposal2
 	svc.topicReceivers[MsgTypeTxList] = svc.onReceiveTxList2
 	svc.topicReceivers[MsgTypeNewView] = svc.onReceiveNewView2
+	svc.topicReceivers[MsgTypeTimeout] = svc.onReceiveTimeout2
+	svc.topicReceivers[MsgTypeTimeoutCert] = svc.onReceiveTimeoutCert2
+	svc.topicReceivers[MsgTypeEvidence] = svc.onReceiveEvidence2
+	svc.topicReceivers[MsgTypeCheckpointVote] = svc.onReceiveCheckpointVote2
 }
 
 func (svc *MsgService) listenPeer(peer *Peer) {
@@ -318,21 +394,57 @@ func (svc *MsgService) onReceiveVote(peer *Peer, data []byte) {
 }
 
 func (svc *MsgService) onReceiveNewView(peer *Peer, data []byte) {
-	qc := core.NewQuorumCert()
-	if err := qc.Unmarshal(data); err != nil {
+	nv := core.NewNewView()
+	if err := nv.Unmarshal(data); err != nil {
 		logger.I().Errorw("receive new view failed", "error", err)
 		return
 	}
-	svc.newViewEmitter.Emit(qc)
+	svc.newViewEmitter.Emit(nv)
 }
 
 func (svc *MsgService) onReceiveNewView2(data []byte) {
-	qc := core.NewQuorumCert()
-	if err := qc.Unmarshal(data); err != nil {
+	nv := core.NewNewView()
+	if err := nv.Unmarshal(data); err != nil {
 		logger.I().Errorw("receive topic new view failed", "error", err)
 		return
 	}
-	svc.newViewEmitter.Emit(qc)
+	svc.newViewEmitter.Emit(nv)
+}
+
+func (svc *MsgService) onReceiveTimeout2(data []byte) {
+	to := core.NewTimeout()
+	if err := to.Unmarshal(data); err != nil {
+		logger.I().Errorw("receive topic timeout failed", "error", err)
+		return
+	}
+	svc.timeoutEmitter.Emit(to)
+}
+
+func (svc *MsgService) onReceiveTimeoutCert2(data []byte) {
+	tc := core.NewTimeoutCert()
+	if err := tc.Unmarshal(data); err != nil {
+		logger.I().Errorw("receive topic timeout cert failed", "error", err)
+		return
+	}
+	svc.tcEmitter.Emit(tc)
+}
+
+func (svc *MsgService) onReceiveEvidence2(data []byte) {
+	ev := core.NewEvidence()
+	if err := ev.Unmarshal(data); err != nil {
+		logger.I().Errorw("receive topic evidence failed", "error", err)
+		return
+	}
+	svc.evidenceEmitter.Emit(ev)
+}
+
+func (svc *MsgService) onReceiveCheckpointVote2(data []byte) {
+	vote := core.NewCheckpointVote()
+	if err := vote.Unmarshal(data); err != nil {
+		logger.I().Errorw("receive topic checkpoint vote failed", "error", err)
+		return
+	}
+	svc.cpVoteEmitter.Emit(vote)
 }
 
 func (svc *MsgService) onReceiveTxList(peer *Peer, data []byte) {
@@ -94,20 +94,22 @@ func TestSendNewView(t *testing.T) {
 	svc2 := NewMsgService(host2)
 	sub := svc1.SubscribeNewView(5)
 
-	var recvQC *core.QuorumCert
+	var recvNV *core.NewView
 	go func() {
 		for e := range sub.Events() {
-			recvQC = e.(*core.QuorumCert)
+			recvNV = e.(*core.NewView)
 		}
 	}()
 
-	_, qc, _ := newTestProposal(core.GenerateKey(nil))
-	err := svc2.SendNewView(peer1.PublicKey(), qc)
+	priv := core.GenerateKey(nil)
+	_, qc, _ := newTestProposal(priv)
+	err := svc2.SendNewView(peer1.PublicKey(), core.NewNewView().Sign(2, qc, priv))
 	asrt.NoError(err)
 
 	time.Sleep(10 * time.Millisecond)
-	if asrt.NotNil(recvQC) {
-		asrt.Equal(qc.BlockHash(), recvQC.BlockHash())
+	if asrt.NotNil(recvNV) {
+		asrt.EqualValues(2, recvNV.View())
+		asrt.Equal(qc.BlockHash(), recvNV.QCHigh().BlockHash())
 	}
 
 	host1.Close()
@@ -206,3 +208,35 @@ func TestRequestTxList(t *testing.T) {
 	host1.Close()
 	host2.Close()
 }
+
+func TestRequestBatch(t *testing.T) {
+	asrt := assert.New(t)
+
+	tx := core.NewTransaction().SetNonce(1).Sign(core.GenerateKey(nil))
+	batch := core.NewBatch().SetTransactions([]*core.Transaction{tx}).Sign(core.GenerateKey(nil))
+	batchReqHandler := &BatchReqHandler{
+		GetBatch: func(hash []byte) (*core.Batch, error) {
+			if bytes.Equal(batch.Header().Hash(), hash) {
+				return batch, nil
+			}
+			return nil, errors.New("batch not found")
+		},
+	}
+
+	host1, host2, peer1, _ := setupTwoHost(t)
+	svc1 := NewMsgService(host1)
+	svc2 := NewMsgService(host2)
+	svc1.SetReqHandler(batchReqHandler)
+
+	recvBatch, err := svc2.RequestBatch(peer1.PublicKey(), batch.Header().Hash())
+	if asrt.NoError(err) && asrt.NotNil(recvBatch) {
+		asrt.Equal(batch.Header().Hash(), recvBatch.Header().Hash())
+		asrt.Equal(tx.Hash(), (*recvBatch.TxList())[0].Hash())
+	}
+
+	_, err = svc2.RequestBatch(peer1.PublicKey(), []byte{1})
+	asrt.Error(err)
+
+	host1.Close()
+	host2.Close()
+}
@@ -38,10 +38,11 @@ const (
 
 // Peer type
 type Peer struct {
-	pubKey    *core.PublicKey
-	name      string
-	pointAddr ma.Multiaddr
-	topicAddr ma.Multiaddr
+	pubKey       *core.PublicKey
+	transportKey *core.PublicKey // p2p identity if the validator key is kept by a remote signer
+	name         string
+	pointAddr    ma.Multiaddr
+	topicAddr    ma.Multiaddr
 
 	status  PeerStatus
 	rwc     io.ReadWriteCloser
@@ -75,6 +76,19 @@ func (p *Peer) PublicKey() *core.PublicKey {
 	return p.pubKey
 }
 
+// SetTransportKey sets the p2p identity of the peer, which defaults to its public key
+func (p *Peer) SetTransportKey(key *core.PublicKey) {
+	p.transportKey = key
+}
+
+// TransportKey returns the p2p identity of the peer
+func (p *Peer) TransportKey() *core.PublicKey {
+	if p.transportKey != nil {
+		return p.transportKey
+	}
+	return p.pubKey
+}
+
 // PointAddr return network address of peer
 func (p *Peer) PointAddr() ma.Multiaddr {
 	return p.pointAddr
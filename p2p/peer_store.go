@@ -15,6 +15,7 @@ import (
 
 type PeerStore struct {
 	pub2peer map[string]*Peer
+	tp2peer  map[string]*Peer // by transport key
 	id2name  map[peer.ID]string
 	mtx      sync.RWMutex
 }
@@ -22,6 +23,7 @@ type PeerStore struct {
 func NewPeerStore() *PeerStore {
 	return &PeerStore{
 		pub2peer: make(map[string]*Peer),
+		tp2peer:  make(map[string]*Peer),
 		id2name:  make(map[peer.ID]string),
 	}
 }
@@ -32,11 +34,19 @@ func (s *PeerStore) Load(pubKey *core.PublicKey) *Peer {
 	return s.pub2peer[pubKey.String()]
 }
 
+// LoadByTransport returns the peer of the p2p identity
+func (s *PeerStore) LoadByTransport(key *core.PublicKey) *Peer {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.tp2peer[key.String()]
+}
+
 func (s *PeerStore) Store(p *Peer) *Peer {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 	s.pub2peer[p.PublicKey().String()] = p
-	id, err := getIDFromPublicKey(p.PublicKey())
+	s.tp2peer[p.TransportKey().String()] = p
+	id, err := getIDFromPublicKey(p.TransportKey())
 	if err != nil {
 		panic(nil)
 	}
@@ -50,7 +60,8 @@ func (s *PeerStore) Delete(pubKey *core.PublicKey) *Peer {
 
 	p := s.pub2peer[pubKey.String()]
 	delete(s.pub2peer, pubKey.String())
-	id, err := getIDFromPublicKey(p.PublicKey())
+	delete(s.tp2peer, p.TransportKey().String())
+	id, err := getIDFromPublicKey(p.TransportKey())
 	if err != nil {
 		panic(nil)
 	}
@@ -75,7 +86,8 @@ func (s *PeerStore) LoadOrStore(p *Peer) (actual *Peer, loaded bool) {
 		return actual, loaded
 	}
 	s.pub2peer[p.PublicKey().String()] = p
-	id, err := getIDFromPublicKey(p.PublicKey())
+	s.tp2peer[p.TransportKey().String()] = p
+	id, err := getIDFromPublicKey(p.TransportKey())
 	if err != nil {
 		panic(nil)
 	}
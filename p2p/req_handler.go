@@ -75,3 +75,21 @@ func (hdlr *BlockByHeightReqHandler) HandleReq(data []byte) ([]byte, error) {
 	}
 	return block.Marshal()
 }
+
+type BatchReqHandler struct {
+	GetBatch func(hash []byte) (*core.Batch, error)
+}
+
+var _ ReqHandler = (*BatchReqHandler)(nil)
+
+func (hdlr *BatchReqHandler) Type() pb.Request_Type {
+	return pb.Request_Batch
+}
+
+func (hdlr *BatchReqHandler) HandleReq(data []byte) ([]byte, error) {
+	batch, err := hdlr.GetBatch(data)
+	if err != nil {
+		return nil, err
+	}
+	return batch.Marshal()
+}
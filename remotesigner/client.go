@@ -0,0 +1,164 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package remotesigner
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/logger"
+)
+
+// ErrRawMessage is returned for a message signed without its request
+var ErrRawMessage = errors.New("remote signer does not sign raw messages")
+
+// Client signs with the key kept by a signer daemon
+type Client struct {
+	network string
+	address string
+	timeout time.Duration
+	tlsConf *tls.Config // mutual tls over tcp
+	pubKey  *core.PublicKey
+
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+	mtx  sync.Mutex
+}
+
+var _ core.RequestSigner = (*Client)(nil)
+
+// Dial connects to the signer daemon and fetches its public key,
+// a tcp address requires the tls config with the client certificate
+func Dial(addr string, timeout time.Duration, tlsConf *tls.Config) (*Client, error) {
+	network, address, err := ParseAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	if network == "tcp" {
+		if tlsConf == nil {
+			return nil, ErrPlainTCP
+		}
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		tlsConf = tlsConf.Clone()
+		tlsConf.ServerName = host
+	}
+	c := &Client{
+		network: network,
+		address: address,
+		timeout: timeout,
+		tlsConf: tlsConf,
+	}
+	res, err := c.call(&Request{Method: MethodPubKey})
+	if err != nil {
+		return nil, err
+	}
+	c.pubKey, err = core.NewPublicKey(res.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// PublicKey returns the public key of the signer
+func (c *Client) PublicKey() *core.PublicKey {
+	return c.pubKey
+}
+
+// Sign refuses raw messages whose kind is unknown to the signer, the signature is empty
+func (c *Client) Sign(msg []byte) *core.Signature {
+	logger.I().Errorw("remote sign failed", "error", ErrRawMessage)
+	sig, _ := core.NewSignature(c.pubKey.Bytes(), nil)
+	return sig
+}
+
+// SignRequest asks the signer daemon to sign the request
+func (c *Client) SignRequest(req *core.SignRequest) (*core.Signature, error) {
+	res, err := c.call(&Request{
+		Method:   MethodSign,
+		Domain:   uint8(req.Domain),
+		Hash:     req.Hash,
+		Payload:  req.Payload,
+		Version:  uint8(req.Version),
+		Proposal: req.Proposal,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return core.NewSignature(c.pubKey.Bytes(), res.Signature)
+}
+
+// Close closes the connection to the signer daemon
+func (c *Client) Close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// call sends the request and retries once on a new connection,
+// signing the same request twice is allowed by the signer
+func (c *Client) call(req *Request) (*Response, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	res, err := c.roundTrip(req)
+	if err != nil && c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+		res, err = c.roundTrip(req)
+	}
+	if err != nil {
+		if c.conn != nil {
+			c.conn.Close()
+			c.conn = nil
+		}
+		return nil, err
+	}
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+	return res, nil
+}
+
+func (c *Client) roundTrip(req *Request) (*Response, error) {
+	if c.conn == nil {
+		conn, err := c.dial()
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+		c.enc = json.NewEncoder(conn)
+		c.dec = json.NewDecoder(conn)
+	}
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err := c.enc.Encode(req); err != nil {
+		return nil, err
+	}
+	res := new(Response)
+	if err := c.dec.Decode(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	if c.network == "tcp" {
+		return tls.DialWithDialer(dialer, c.network, c.address, c.tlsConf)
+	}
+	return dialer.Dial(c.network, c.address)
+}
@@ -0,0 +1,46 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package remotesigner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 远程签名协议: 每个连接上依次发送 json 编码的 Request 并读取对应的 Response
+
+// request methods
+const (
+	MethodPubKey = "pubkey"
+	MethodSign   = "sign"
+)
+
+// Request asks the public key or a signature of the signer
+type Request struct {
+	Method   string `json:"method"`
+	Domain   uint8  `json:"domain,omitempty"`
+	Hash     []byte `json:"hash,omitempty"`
+	Payload  []byte `json:"payload,omitempty"`
+	Version  uint8  `json:"version,omitempty"`
+	Proposal bool   `json:"proposal,omitempty"`
+}
+
+// Response carries the result or the error of a request
+type Response struct {
+	PubKey    []byte `json:"pubKey,omitempty"`
+	Signature []byte `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ParseAddr splits the signer address as unix:///path/to/socket or tcp://host:port.
+// The protocol has no authentication of its own, a Unix socket is protected by its file permission
+// and a tcp connection by mutual tls.
+func ParseAddr(addr string) (network, address string, err error) {
+	for _, network := range []string{"unix", "tcp"} {
+		if prefix := network + "://"; strings.HasPrefix(addr, prefix) && len(addr) > len(prefix) {
+			return network, strings.TrimPrefix(addr, prefix), nil
+		}
+	}
+	return "", "", fmt.Errorf("invalid signer address %s", addr)
+}
@@ -0,0 +1,244 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package remotesigner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/logger"
+)
+
+// errors
+var (
+	ErrDoubleSign    = errors.New("conflicting message at signed height")
+	ErrStaleHeight   = errors.New("height is lower than the signed height")
+	ErrLegacyScheme  = errors.New("signer requires domain separated signing")
+	ErrUnknownDomain = errors.New("unknown sign domain")
+)
+
+// Watermark is the highest height signed in a guarded domain and the hash signed at it
+type Watermark struct {
+	Height uint64 `json:"height"`
+	Hash   []byte `json:"hash"`
+}
+
+// SignState is persisted before any signature of a guarded domain is returned
+type SignState struct {
+	Proposal   Watermark `json:"proposal"`
+	Vote       Watermark `json:"vote"`
+	Checkpoint Watermark `json:"checkpoint"`
+}
+
+// Server is the signer daemon, it refuses to sign two blocks or checkpoints at the same height
+// and anything below the signed height, whatever the node asks. Proposals and votes have their
+// own watermarks, a proposal is also the vote of its proposer, so they never differ at a height.
+// Only blocks, votes and checkpoints are guarded, batches, batch votes, transactions, timeouts
+// and new views are signed by hash without any check.
+type Server struct {
+	priv      *core.PrivateKey
+	scheme    *core.SignScheme
+	stateFile string
+	state     SignState
+	mtxState  sync.Mutex
+
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	mtxConns sync.Mutex
+}
+
+// NewServer creates the signer daemon of the chain with the state file of the signed heights
+func NewServer(priv *core.PrivateKey, scheme *core.SignScheme, stateFile string) (*Server, error) {
+	if scheme == nil {
+		return nil, ErrLegacyScheme
+	}
+	s := &Server{
+		priv:      priv,
+		scheme:    scheme,
+		stateFile: stateFile,
+		conns:     make(map[net.Conn]struct{}),
+	}
+	b, err := os.ReadFile(stateFile)
+	if err == nil {
+		if err := json.Unmarshal(b, &s.state); err != nil {
+			return nil, fmt.Errorf("cannot parse %s, %w", stateFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return s, nil
+}
+
+// State returns the signed heights
+func (s *Server) State() SignState {
+	s.mtxState.Lock()
+	defer s.mtxState.Unlock()
+	return s.state
+}
+
+// Serve accepts connections until the listener is closed
+func (s *Server) Serve(ln net.Listener) error {
+	s.listener = ln
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		s.mtxConns.Lock()
+		s.conns[conn] = struct{}{}
+		s.mtxConns.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+// Close stops accepting connections and closes the open ones
+func (s *Server) Close() error {
+	s.mtxConns.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mtxConns.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		s.mtxConns.Lock()
+		delete(s.conns, conn)
+		s.mtxConns.Unlock()
+		conn.Close()
+	}()
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		req := new(Request)
+		if err := dec.Decode(req); err != nil {
+			if err != io.EOF {
+				logger.I().Warnw("read sign request failed", "error", err)
+			}
+			return
+		}
+		if err := enc.Encode(s.handle(req)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(req *Request) *Response {
+	switch req.Method {
+	case MethodPubKey:
+		return &Response{PubKey: s.priv.PublicKey().Bytes()}
+
+	case MethodSign:
+		sig, err := s.sign(&core.SignRequest{
+			Domain:   core.SignDomainTag(req.Domain),
+			Hash:     req.Hash,
+			Payload:  req.Payload,
+			Version:  core.SignVersion(req.Version),
+			Scheme:   s.scheme,
+			Proposal: req.Proposal,
+		})
+		if err != nil {
+			logger.I().Warnw("refused sign request", "domain", req.Domain, "error", err)
+			return &Response{Error: err.Error()}
+		}
+		return &Response{Signature: sig}
+
+	default:
+		return &Response{Error: fmt.Sprintf("unknown method %s", req.Method)}
+	}
+}
+
+func (s *Server) sign(req *core.SignRequest) ([]byte, error) {
+	if req.Version != core.SignDomain {
+		return nil, ErrLegacyScheme
+	}
+	if req.Domain < core.DomainBlock || req.Domain > core.DomainCheckpoint {
+		return nil, ErrUnknownDomain
+	}
+	if req.Guarded() {
+		height, err := req.PayloadHeight()
+		if err != nil {
+			return nil, err
+		}
+		if req.Proposal {
+			if err := req.CheckProposer(s.priv.PublicKey()); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.raiseWatermark(req, height); err != nil {
+			return nil, err
+		}
+	}
+	return s.priv.Sign(req.Message()).Value(), nil
+}
+
+// raiseWatermark records the height and hash on disk, signing the same hash again is allowed
+func (s *Server) raiseWatermark(req *core.SignRequest, height uint64) error {
+	s.mtxState.Lock()
+	defer s.mtxState.Unlock()
+
+	state := s.state
+	wm, other := &state.Vote, &state.Proposal
+	if req.Domain == core.DomainCheckpoint {
+		wm, other = &state.Checkpoint, nil
+	} else if req.Proposal {
+		wm, other = &state.Proposal, &state.Vote
+	}
+	if other != nil && other.Hash != nil && height == other.Height && !bytes.Equal(req.Hash, other.Hash) {
+		return fmt.Errorf("%w, height %d", ErrDoubleSign, height)
+	}
+	if wm.Hash != nil {
+		if height < wm.Height {
+			return fmt.Errorf("%w, height %d, signed %d", ErrStaleHeight, height, wm.Height)
+		}
+		if height == wm.Height {
+			if !bytes.Equal(req.Hash, wm.Hash) {
+				return fmt.Errorf("%w, height %d", ErrDoubleSign, height)
+			}
+			return nil
+		}
+	}
+	wm.Height = height
+	wm.Hash = req.Hash
+	if err := writeState(s.stateFile, &state); err != nil {
+		return err
+	}
+	s.state = state
+	return nil
+}
+
+func writeState(name string, state *SignState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
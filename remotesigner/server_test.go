@@ -0,0 +1,163 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package remotesigner
+
+import (
+	"net"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+var testScheme = core.NewSignScheme(1, 0)
+
+func newValidatorStore(priv *core.PrivateKey) core.ValidatorStore {
+	keys := []string{priv.PublicKey().String()}
+	vs := core.NewEpochStore(keys, keys, nil)
+	vs.SetSignScheme(testScheme)
+	return vs
+}
+
+func startServer(t *testing.T, priv *core.PrivateKey, stateFile string) (*Server, *Client) {
+	srv, err := NewServer(priv, testScheme, stateFile)
+	assert.NoError(t, err)
+	sock := path.Join(t.TempDir(), "signer.sock")
+	ln, err := net.Listen("unix", sock)
+	assert.NoError(t, err)
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	client, err := Dial("unix://"+sock, time.Second, nil)
+	assert.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return srv, client
+}
+
+func newBlock(height uint64, timestamp int64) *core.Block {
+	return core.NewBlock().
+		SetHeight(height).
+		SetParentHash(make([]byte, 32)).
+		SetQuorumCert(core.NewQuorumCert()).
+		SetTimestamp(timestamp)
+}
+
+// proposalSigner asks to sign every block as its proposer
+type proposalSigner struct {
+	*Client
+}
+
+func (s proposalSigner) SignRequest(req *core.SignRequest) (*core.Signature, error) {
+	req.Proposal = true
+	return s.Client.SignRequest(req)
+}
+
+func TestServer_SignBlock(t *testing.T) {
+	asrt := assert.New(t)
+
+	priv := core.GenerateKey(nil)
+	proposer := core.GenerateKey(nil)
+	stateFile := path.Join(t.TempDir(), "state.json")
+	srv, client := startServer(t, priv, stateFile)
+	asrt.True(priv.PublicKey().Equal(client.PublicKey()))
+	vs := newValidatorStore(priv)
+	signer := core.NewChainSigner(client, testScheme)
+
+	b5 := newBlock(5, 1)
+	_, err := b5.TrySign(signer)
+	asrt.NoError(err)
+	asrt.NoError(b5.ProposerVote().Validate(vs))
+	vote, err := b5.TryVote(signer)
+	asrt.NoError(err, "the same block can be signed again")
+	asrt.NoError(vote.Validate(vs))
+
+	_, err = newBlock(5, 2).Sign(proposer).TryVote(signer)
+	asrt.ErrorContains(err, ErrDoubleSign.Error())
+	_, err = newBlock(4, 2).TrySign(signer)
+	asrt.ErrorContains(err, ErrStaleHeight.Error())
+	asrt.Error(newBlock(4, 2).Sign(signer).ProposerVote().Validate(vs), "refused block has no signature")
+
+	// the node cannot bypass the payload check
+	_, err = client.SignRequest(&core.SignRequest{
+		Domain: core.DomainBlock, Hash: newBlock(5, 2).Sign(priv).Hash(), Version: core.SignDomain,
+	})
+	asrt.Error(err)
+
+	_, err = newBlock(6, 2).Sign(proposer).TryVote(signer)
+	asrt.NoError(err)
+	asrt.EqualValues(6, srv.State().Vote.Height)
+	asrt.EqualValues(5, srv.State().Proposal.Height)
+	_, err = newBlock(6, 3).TrySign(signer)
+	asrt.ErrorContains(err, ErrDoubleSign.Error(), "proposal is also a vote")
+
+	// a proposal must be proposed by the signer
+	_, err = newBlock(7, 1).Sign(proposer).TryVote(core.NewChainSigner(proposalSigner{client}, testScheme))
+	asrt.ErrorContains(err, core.ErrNotProposer.Error())
+
+	// watermarks are kept after restart
+	srv.Close()
+	_, client = startServer(t, priv, stateFile)
+	_, err = newBlock(6, 3).Sign(proposer).TryVote(core.NewChainSigner(client, testScheme))
+	asrt.ErrorContains(err, ErrDoubleSign.Error())
+}
+
+func TestServer_SignCheckpoint(t *testing.T) {
+	asrt := assert.New(t)
+
+	priv := core.GenerateKey(nil)
+	_, client := startServer(t, priv, path.Join(t.TempDir(), "state.json"))
+	vs := newValidatorStore(priv)
+	signer := core.NewChainSigner(client, testScheme)
+
+	hash := make([]byte, 32)
+	vote, err := core.NewCheckpointVote().TrySign(100, hash, []byte{1}, signer)
+	asrt.NoError(err)
+	asrt.NoError(vote.Validate(vs))
+
+	_, err = core.NewCheckpointVote().TrySign(100, hash, []byte{2}, signer)
+	asrt.ErrorContains(err, ErrDoubleSign.Error())
+
+	// blocks have their own watermark
+	_, err = newBlock(1, 1).TrySign(signer)
+	asrt.NoError(err)
+}
+
+func TestServer_SignUnguarded(t *testing.T) {
+	asrt := assert.New(t)
+
+	_, err := NewServer(core.GenerateKey(nil), nil, path.Join(t.TempDir(), "state.json"))
+	asrt.ErrorIs(err, ErrLegacyScheme)
+
+	_, client := startServer(t, core.GenerateKey(nil), path.Join(t.TempDir(), "state.json"))
+	tx := core.NewTransaction().SetNonce(1).Sign(core.NewChainSigner(client, testScheme))
+	asrt.NoError(tx.Validate(testScheme))
+	asrt.Error(core.NewTransaction().SetNonce(1).Sign(client).Validate(nil), "legacy signing is refused")
+
+	asrt.Empty(client.Sign([]byte("raw message")).Value())
+
+	_, err = client.SignRequest(&core.SignRequest{Domain: 100, Hash: []byte{1}, Version: core.SignDomain})
+	asrt.ErrorContains(err, ErrUnknownDomain.Error())
+}
+
+func TestParseAddr(t *testing.T) {
+	asrt := assert.New(t)
+
+	network, address, err := ParseAddr("unix:///tmp/signer.sock")
+	asrt.NoError(err)
+	asrt.Equal("unix", network)
+	asrt.Equal("/tmp/signer.sock", address)
+
+	network, address, err = ParseAddr("tcp://127.0.0.1:7070")
+	asrt.NoError(err)
+	asrt.Equal("tcp", network)
+	asrt.Equal("127.0.0.1:7070", address)
+
+	_, _, err = ParseAddr("unix://")
+	asrt.Error(err)
+	_, _, err = ParseAddr("127.0.0.1:7070")
+	asrt.Error(err)
+}
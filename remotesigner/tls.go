@@ -0,0 +1,53 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package remotesigner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrPlainTCP is returned for a tcp address without mutual tls
+var ErrPlainTCP = errors.New("tcp signer address requires mutual tls")
+
+// TLSFiles are the pem files of the mutual tls between the node and the signer daemon over tcp
+type TLSFiles struct {
+	Cert string // certificate of this side
+	Key  string // private key of the certificate
+	CA   string // ca certificate which issued the certificate of the other side
+}
+
+// Empty reports whether no file is set
+func (f TLSFiles) Empty() bool {
+	return f.Cert == "" && f.Key == "" && f.CA == ""
+}
+
+// Config loads the files, both sides present their certificates and verify the peer with the ca
+func (f TLSFiles) Config() (*tls.Config, error) {
+	if f.Cert == "" || f.Key == "" || f.CA == "" {
+		return nil, fmt.Errorf("%w, cert, key and ca files are all required", ErrPlainTCP)
+	}
+	cert, err := tls.LoadX509KeyPair(f.Cert, f.Key)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(f.CA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no ca certificate in %s", f.CA)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}, nil
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package remotesigner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func writePEM(t *testing.T, name, typ string, b []byte) string {
+	file := path.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0600))
+	return file
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "signer ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, file: writePEM(t, "ca.pem", "CERTIFICATE", der)}
+}
+
+// issue returns the tls files of a certificate signed by the ca, the other side trusts the peer ca
+func (ca *testCA) issue(t *testing.T, name string, peer *testCA) TLSFiles {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return TLSFiles{
+		Cert: writePEM(t, name+".pem", "CERTIFICATE", der),
+		Key:  writePEM(t, name+".key", "EC PRIVATE KEY", keyDer),
+		CA:   peer.file,
+	}
+}
+
+func TestServer_TLS(t *testing.T) {
+	asrt := assert.New(t)
+
+	ca, other := newTestCA(t), newTestCA(t)
+	serverConf, err := ca.issue(t, "signer", ca).Config()
+	asrt.NoError(err)
+
+	priv := core.GenerateKey(nil)
+	srv, err := NewServer(priv, testScheme, path.Join(t.TempDir(), "state.json"))
+	asrt.NoError(err)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverConf)
+	asrt.NoError(err)
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	addr := "tcp://" + ln.Addr().String()
+
+	_, err = Dial(addr, time.Second, nil)
+	asrt.ErrorIs(err, ErrPlainTCP)
+	_, err = TLSFiles{CA: ca.file}.Config()
+	asrt.ErrorIs(err, ErrPlainTCP)
+
+	clientConf, err := ca.issue(t, "node", ca).Config()
+	asrt.NoError(err)
+	client, err := Dial(addr, time.Second, clientConf)
+	if asrt.NoError(err) {
+		asrt.True(priv.PublicKey().Equal(client.PublicKey()))
+		client.Close()
+	}
+
+	// a client certificate from another ca is refused
+	strangerConf, err := other.issue(t, "stranger", ca).Config()
+	asrt.NoError(err)
+	_, err = Dial(addr, time.Second, strangerConf)
+	asrt.Error(err)
+
+	// the node does not trust a signer of another ca
+	wrongCAConf, err := ca.issue(t, "node", other).Config()
+	asrt.NoError(err)
+	_, err = Dial(addr, time.Second, wrongCAConf)
+	asrt.Error(err)
+}
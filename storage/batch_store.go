@@ -0,0 +1,47 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+type batchStore struct {
+	db *levelDB
+}
+
+// getBatch 通过Batch头部Hash获取完整Batch
+func (bs *batchStore) getBatch(hash []byte) (*core.Batch, error) {
+	b, err := bs.db.Get(concatBytes([]byte{colBatchByHash}, hash))
+	if err != nil {
+		return nil, err
+	}
+	batch := core.NewBatch()
+	if err := batch.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (bs *batchStore) hasBatch(hash []byte) bool {
+	return bs.db.HasKey(concatBytes([]byte{colBatchByHash}, hash))
+}
+
+func (bs *batchStore) setBatch(batch *core.Batch) updateFunc {
+	return func(setter setter) error {
+		val, err := batch.Marshal()
+		if err != nil {
+			return err
+		}
+		return setter.Set(concatBytes([]byte{colBatchByHash}, batch.Header().Hash()), val)
+	}
+}
+
+func (bs *batchStore) deleteBatches(hashes [][]byte) error {
+	keys := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		keys[i] = concatBytes([]byte{colBatchByHash}, hash)
+	}
+	return bs.db.deleteKeys(keys)
+}
@@ -0,0 +1,47 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func TestBatchStore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := os.MkdirTemp("", "db")
+	rawDB, _ := NewLevelDB(dir)
+	db := &levelDB{rawDB}
+	bs := &batchStore{db}
+
+	priv := core.GenerateKey(nil)
+	txs := []*core.Transaction{
+		core.NewTransaction().SetNonce(1).Sign(priv),
+		core.NewTransaction().SetNonce(2).Sign(priv),
+	}
+	batch := core.NewBatch().SetTransactions(txs).SetTimestamp(1).Sign(priv)
+
+	assert.False(bs.hasBatch(batch.Header().Hash()))
+	_, err := bs.getBatch(batch.Header().Hash())
+	assert.Error(err)
+
+	assert.NoError(updateLevelDB(db, []updateFunc{bs.setBatch(batch)}))
+
+	assert.True(bs.hasBatch(batch.Header().Hash()))
+	ret, err := bs.getBatch(batch.Header().Hash())
+	assert.NoError(err)
+	assert.Equal(batch.Header().Hash(), ret.Header().Hash())
+	assert.Equal(priv.PublicKey(), ret.Header().Proposer())
+	if assert.Len(*ret.TxList(), 2) {
+		assert.Equal(txs[1].Hash(), (*ret.TxList())[1].Hash())
+	}
+
+	assert.NoError(bs.deleteBatches([][]byte{batch.Header().Hash()}))
+	assert.False(bs.hasBatch(batch.Header().Hash()))
+}
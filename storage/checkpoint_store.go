@@ -0,0 +1,56 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"encoding/binary"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+type checkpointStore struct {
+	db *levelDB
+}
+
+// getCheckpointCert 通过区块高度获取检查点证书
+func (cs *checkpointStore) getCheckpointCert(height uint64) (*core.CheckpointCert, error) {
+	b, err := cs.db.Get(concatBytes([]byte{colCheckpointByHeight}, uint64BEBytes(height)))
+	if err != nil {
+		return nil, err
+	}
+	cert := core.NewCheckpointCert()
+	if err := cert.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// getLastCheckpointCert 获取最高的检查点证书
+func (cs *checkpointStore) getLastCheckpointCert() (*core.CheckpointCert, error) {
+	b, err := cs.db.Get([]byte{colCheckpointHeight})
+	if err != nil {
+		return nil, err
+	}
+	return cs.getCheckpointCert(binary.BigEndian.Uint64(b))
+}
+
+func (cs *checkpointStore) hasCheckpointCert(height uint64) bool {
+	return cs.db.HasKey(concatBytes([]byte{colCheckpointByHeight}, uint64BEBytes(height)))
+}
+
+func (cs *checkpointStore) setCheckpointCert(cert *core.CheckpointCert) []updateFunc {
+	ret := []updateFunc{func(setter setter) error {
+		val, err := cert.Marshal()
+		if err != nil {
+			return err
+		}
+		return setter.Set(concatBytes([]byte{colCheckpointByHeight}, uint64BEBytes(cert.Height())), val)
+	}}
+	if b, err := cs.db.Get([]byte{colCheckpointHeight}); err == nil && binary.BigEndian.Uint64(b) > cert.Height() {
+		return ret // certs may be collected out of order
+	}
+	return append(ret, func(setter setter) error {
+		return setter.Set([]byte{colCheckpointHeight}, uint64BEBytes(cert.Height()))
+	})
+}
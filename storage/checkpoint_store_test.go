@@ -0,0 +1,46 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func TestCheckpointStore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := os.MkdirTemp("", "db")
+	rawDB, _ := NewLevelDB(dir)
+	db := &levelDB{rawDB}
+	cs := &checkpointStore{db}
+
+	priv := core.GenerateKey(nil)
+	newCert := func(height uint64) *core.CheckpointCert {
+		vote := core.NewCheckpointVote().Sign(height, make([]byte, 32), []byte("root"), priv)
+		cert, _ := core.NewCheckpointCert().Build([]*core.CheckpointVote{vote})
+		return cert
+	}
+
+	assert.False(cs.hasCheckpointCert(10))
+	_, err := cs.getLastCheckpointCert()
+	assert.Error(err)
+
+	assert.NoError(updateLevelDB(db, cs.setCheckpointCert(newCert(20))))
+	assert.NoError(updateLevelDB(db, cs.setCheckpointCert(newCert(10))))
+
+	assert.True(cs.hasCheckpointCert(10))
+	cert, err := cs.getCheckpointCert(10)
+	assert.NoError(err)
+	assert.EqualValues(10, cert.Height())
+	assert.Equal([]byte("root"), cert.MerkleRoot())
+
+	cert, err = cs.getLastCheckpointCert()
+	assert.NoError(err)
+	assert.EqualValues(20, cert.Height(), "last checkpoint not overwritten by lower height")
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+// MaxEvidenceCount bounds the stored evidence, one evidence is kept for each offender and type
+const MaxEvidenceCount = 1000
+
+var ErrEvidenceLimit = errors.New("evidence limit reached")
+
+type evidenceStore struct {
+	db  *levelDB
+	mtx sync.Mutex // serializes the limit check and the write
+}
+
+// getEvidence 通过Hash获取作恶证据
+func (es *evidenceStore) getEvidence(hash []byte) (*core.Evidence, error) {
+	b, err := es.db.Get(concatBytes([]byte{colEvidenceByHash}, hash))
+	if err != nil {
+		return nil, err
+	}
+	ev := core.NewEvidence()
+	if err := ev.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// getEvidenceList 获取所有作恶证据
+func (es *evidenceStore) getEvidenceList() ([]*core.Evidence, error) {
+	ret := make([]*core.Evidence, 0)
+	err := es.db.iterate([]byte{colEvidenceByHash}, func(value []byte) error {
+		ev := core.NewEvidence()
+		if err := ev.Unmarshal(value); err != nil {
+			return err
+		}
+		ret = append(ret, ev)
+		return nil
+	})
+	return ret, err
+}
+
+func (es *evidenceStore) hasEvidence(hash []byte) bool {
+	return es.db.HasKey(concatBytes([]byte{colEvidenceByHash}, hash))
+}
+
+// hasOffenderEvidence 检查是否已有同类型同作恶者的证据
+func (es *evidenceStore) hasOffenderEvidence(ev *core.Evidence) bool {
+	return es.db.HasKey(offenderKey(ev))
+}
+
+func (es *evidenceStore) count() (int, error) {
+	count := 0
+	err := es.db.iterate([]byte{colEvidenceByOffender}, func(value []byte) error {
+		count++
+		return nil
+	})
+	return count, err
+}
+
+func (es *evidenceStore) setEvidence(ev *core.Evidence) updateFunc {
+	return func(setter setter) error {
+		val, err := ev.Marshal()
+		if err != nil {
+			return err
+		}
+		hash := ev.Hash()
+		if err := setter.Set(concatBytes([]byte{colEvidenceByHash}, hash), val); err != nil {
+			return err
+		}
+		return setter.Set(offenderKey(ev), hash)
+	}
+}
+
+func offenderKey(ev *core.Evidence) []byte {
+	return concatBytes([]byte{colEvidenceByOffender, byte(ev.Type())}, ev.Offender().Bytes())
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func TestEvidenceStore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := os.MkdirTemp("", "db")
+	rawDB, _ := NewLevelDB(dir)
+	db := &levelDB{rawDB}
+	es := &evidenceStore{db: db}
+
+	priv := core.GenerateKey(nil)
+	qc := core.NewQuorumCert().Build(
+		[]*core.Vote{core.NewBlock().SetHeight(9).Vote(priv)})
+	b1 := core.NewBlock().SetQuorumCert(qc).SetHeight(10).SetTimestamp(1).Sign(priv)
+	b2 := core.NewBlock().SetQuorumCert(qc).SetHeight(10).SetTimestamp(2).Sign(priv)
+	ev, err := core.NewEvidence().BuildDoubleProposal(b1, b2)
+	assert.NoError(err)
+
+	assert.False(es.hasEvidence(ev.Hash()))
+	_, err = es.getEvidence(ev.Hash())
+	assert.Error(err)
+	list, err := es.getEvidenceList()
+	assert.NoError(err)
+	assert.Empty(list)
+
+	assert.NoError(updateLevelDB(db, []updateFunc{es.setEvidence(ev)}))
+
+	assert.True(es.hasEvidence(ev.Hash()))
+	ret, err := es.getEvidence(ev.Hash())
+	assert.NoError(err)
+	assert.Equal(ev.Hash(), ret.Hash())
+	assert.Equal(priv.PublicKey(), ret.Offender())
+
+	list, err = es.getEvidenceList()
+	assert.NoError(err)
+	assert.Len(list, 1)
+}
+
+func TestStorage_SetEvidence(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := os.MkdirTemp("", "db")
+	defer os.RemoveAll(dir)
+	rawDB, _ := NewLevelDB(dir)
+	strg := New(rawDB, DefaultConfig)
+
+	priv := core.GenerateKey(nil)
+	qc := core.NewQuorumCert().Build(
+		[]*core.Vote{core.NewBlock().SetHeight(9).Vote(priv)})
+	b1 := core.NewBlock().SetQuorumCert(qc).SetHeight(10).SetTimestamp(1).Sign(priv)
+	b2 := core.NewBlock().SetQuorumCert(qc).SetHeight(10).SetTimestamp(2).Sign(priv)
+	b3 := core.NewBlock().SetQuorumCert(qc).SetHeight(10).SetTimestamp(3).Sign(priv)
+	ev1, _ := core.NewEvidence().BuildDoubleProposal(b1, b2)
+	ev2, _ := core.NewEvidence().BuildDoubleProposal(b1, b3)
+
+	assert.False(strg.HasEvidence(ev1))
+	assert.NoError(strg.SetEvidence(ev1))
+	assert.True(strg.HasEvidence(ev2), "same offender and type")
+	assert.NoError(strg.SetEvidence(ev2))
+	list, err := strg.GetEvidenceList()
+	assert.NoError(err)
+	assert.Len(list, 1)
+
+	for i := 1; i < MaxEvidenceCount; i++ {
+		assert.NoError(updateLevelDB(strg.db, []updateFunc{func(setter setter) error {
+			return setter.Set([]byte{colEvidenceByOffender, byte(i), byte(i >> 8)}, nil)
+		}}))
+	}
+	other := core.GenerateKey(nil)
+	ev3, _ := core.NewEvidence().BuildDoubleProposal(
+		core.NewBlock().SetQuorumCert(qc).SetHeight(10).SetTimestamp(1).Sign(other),
+		core.NewBlock().SetQuorumCert(qc).SetHeight(10).SetTimestamp(2).Sign(other))
+	assert.ErrorIs(strg.SetEvidence(ev3), ErrEvidenceLimit)
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"encoding/json"
+)
+
+type governanceStore struct {
+	getter getter
+}
+
+// getGovernanceState 获取已调度的纪元和待定的验证节点集合提案
+func (gs *governanceStore) getGovernanceState() (*GovernanceState, error) {
+	b, err := gs.getter.Get([]byte{colGovernance})
+	if err != nil {
+		return nil, err
+	}
+	state := new(GovernanceState)
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (gs *governanceStore) setGovernanceState(state *GovernanceState) updateFunc {
+	return func(setter setter) error {
+		val, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		return setter.Set([]byte{colGovernance}, val)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func TestGovernanceStore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := os.MkdirTemp("", "db")
+	rawDB, _ := NewLevelDB(dir)
+	db := &levelDB{rawDB}
+	gs := &governanceStore{db}
+
+	_, err := gs.getGovernanceState()
+	assert.Error(err)
+
+	state := &GovernanceState{
+		Epochs: []*core.Epoch{
+			{Start: 20, Workers: []string{"w1"}, Voters: []string{"v1", "v2"}},
+		},
+		Approvals: map[string][]string{"proposal": {"v1"}},
+	}
+	assert.NoError(updateLevelDB(db, []updateFunc{gs.setGovernanceState(state)}))
+
+	res, err := gs.getGovernanceState()
+	assert.NoError(err)
+	assert.Equal(state, res)
+}
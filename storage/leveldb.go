@@ -7,6 +7,8 @@ import (
 	"bytes"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // data collection prefixes for different data collections
@@ -24,6 +26,14 @@ const (
 	colMerkleTreeHeight                      // tree height
 	colMerkleLeafCount                       // tree leaf count
 	colMerkleNodeByPosition                  // tree node value by position
+	colSafetyState                           // hotstuff voting state by field
+	colEvidenceByHash                        // equivocation evidence by hash
+	colGovernance                            // scheduled epochs and validator set approvals
+	colWALEntryBySeq                         // consensus write-ahead log entry by sequence
+	colBatchByHash                           // batch body by batch header hash
+	colCheckpointByHeight                    // checkpoint cert by block height
+	colCheckpointHeight                      // height of the last checkpoint cert
+	colEvidenceByOffender                    // evidence hash by type and offender
 )
 
 type setter interface {
@@ -58,6 +68,34 @@ func (lg *levelDB) Set(key, value []byte) error {
 	return lg.db.Put(key, value, nil)
 }
 
+// iterate calls fn with the value of each key having the prefix
+func (lg *levelDB) iterate(prefix []byte, fn func(value []byte) error) error {
+	return lg.iterateKV(prefix, func(key, value []byte) error {
+		return fn(value)
+	})
+}
+
+// iterateKV calls fn with each key having the prefix and its value in key order
+func (lg *levelDB) iterateKV(prefix []byte, fn func(key, value []byte) error) error {
+	iter := lg.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		if err := fn(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+// deleteKeys removes the keys in one batch
+func (lg *levelDB) deleteKeys(keys [][]byte) error {
+	batch := new(leveldb.Batch)
+	for _, key := range keys {
+		batch.Delete(key)
+	}
+	return lg.db.Write(batch, nil)
+}
+
 func updateLevelDB(db *levelDB, fns []updateFunc) error {
 	for _, fn := range fns {
 		if err := fn(db); err != nil {
@@ -67,6 +105,26 @@ func updateLevelDB(db *levelDB, fns []updateFunc) error {
 	return nil
 }
 
+type batchSetter struct {
+	batch *leveldb.Batch
+}
+
+func (bs *batchSetter) Set(key, value []byte) error {
+	bs.batch.Put(key, value)
+	return nil
+}
+
+// updateLevelDBSync writes all updates in one batch and fsyncs it before return
+func updateLevelDBSync(db *levelDB, fns []updateFunc) error {
+	bs := &batchSetter{new(leveldb.Batch)}
+	for _, fn := range fns {
+		if err := fn(bs); err != nil {
+			return err
+		}
+	}
+	return db.db.Write(bs.batch, &opt.WriteOptions{Sync: true})
+}
+
 func concatBytes(srcs ...[]byte) []byte {
 	buf := bytes.NewBuffer(nil)
 	size := 0
@@ -0,0 +1,109 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+// fields of the safety state
+const (
+	safetyBVote byte = iota + 1
+	safetyBLock
+	safetyQCHigh
+	safetyQCHighBlock
+	safetyView
+)
+
+type safetyStore struct {
+	getter getter
+}
+
+// getSafetyState 获取重启前保存的投票状态
+func (ss *safetyStore) getSafetyState() (*SafetyState, error) {
+	bVote, err := ss.getBlock(safetyBVote)
+	if err != nil {
+		return nil, err
+	}
+	bLock, err := ss.getBlock(safetyBLock)
+	if err != nil {
+		return nil, err
+	}
+	qcHighBlock, err := ss.getBlock(safetyQCHighBlock)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ss.getter.Get([]byte{colSafetyState, safetyQCHigh})
+	if err != nil {
+		return nil, err
+	}
+	qcHigh := core.NewQuorumCert()
+	if err := qcHigh.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return &SafetyState{
+		BVote:       bVote,
+		BLock:       bLock,
+		QCHigh:      qcHigh,
+		QCHighBlock: qcHighBlock,
+	}, nil
+}
+
+func (ss *safetyStore) getBlock(field byte) (*core.Block, error) {
+	b, err := ss.getter.Get([]byte{colSafetyState, field})
+	if err != nil {
+		return nil, err
+	}
+	blk := core.NewBlock()
+	if err := blk.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return blk, nil
+}
+
+func (ss *safetyStore) setSafetyState(state *SafetyState) []updateFunc {
+	return []updateFunc{
+		ss.setBlock(safetyBVote, state.BVote),
+		ss.setBlock(safetyBLock, state.BLock),
+		ss.setBlock(safetyQCHighBlock, state.QCHighBlock),
+		func(setter setter) error {
+			val, err := state.QCHigh.Marshal()
+			if err != nil {
+				return err
+			}
+			return setter.Set([]byte{colSafetyState, safetyQCHigh}, val)
+		},
+	}
+}
+
+func (ss *safetyStore) setBlock(field byte, blk *core.Block) updateFunc {
+	return func(setter setter) error {
+		val, err := blk.Marshal()
+		if err != nil {
+			return err
+		}
+		return setter.Set([]byte{colSafetyState, field}, val)
+	}
+}
+
+// getView 获取重启前的视图编号
+func (ss *safetyStore) getView() (uint64, error) {
+	b, err := ss.getter.Get([]byte{colSafetyState, safetyView})
+	if err != nil {
+		return 0, err
+	}
+	if len(b) != 8 {
+		return 0, errors.New("invalid view data")
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (ss *safetyStore) setView(view uint64) updateFunc {
+	return func(setter setter) error {
+		return setter.Set([]byte{colSafetyState, safetyView}, uint64BEBytes(view))
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wooyang2018/ppov-blockchain/core"
+)
+
+func TestSafetyStore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := os.MkdirTemp("", "db")
+	rawDB, _ := NewLevelDB(dir)
+	db := &levelDB{rawDB}
+	ss := &safetyStore{db}
+
+	_, err := ss.getSafetyState()
+	assert.Error(err)
+
+	priv := core.GenerateKey(nil)
+	qc := core.NewQuorumCert().Build(
+		[]*core.Vote{core.NewBlock().SetHeight(7).Vote(priv)})
+	bLock := core.NewBlock().SetHeight(8).SetQuorumCert(qc).Sign(priv)
+	qcLock := core.NewQuorumCert().Build([]*core.Vote{bLock.Vote(priv)})
+	qcHighBlock := core.NewBlock().SetHeight(9).SetParentHash(bLock.Hash()).
+		SetQuorumCert(qcLock).Sign(priv)
+	qcHigh := core.NewQuorumCert().Build([]*core.Vote{qcHighBlock.Vote(priv)})
+	bVote := core.NewBlock().SetHeight(10).SetParentHash(qcHighBlock.Hash()).
+		SetQuorumCert(qcHigh).Sign(priv)
+
+	state := &SafetyState{
+		BVote:       bVote,
+		BLock:       bLock,
+		QCHigh:      qcHigh,
+		QCHighBlock: qcHighBlock,
+	}
+	assert.NoError(updateLevelDBSync(db, ss.setSafetyState(state)))
+
+	ret, err := ss.getSafetyState()
+	assert.NoError(err)
+	assert.Equal(bVote.Hash(), ret.BVote.Hash())
+	assert.Equal(bLock.Hash(), ret.BLock.Hash())
+	assert.Equal(qcHighBlock.Hash(), ret.QCHighBlock.Hash())
+	assert.Equal(qcHigh.BlockHash(), ret.QCHigh.BlockHash())
+	assert.EqualValues(10, ret.BVote.Height())
+
+	_, err = ss.getView()
+	assert.Error(err)
+	assert.NoError(updateLevelDBSync(db, []updateFunc{ss.setView(12)}))
+	view, err := ss.getView()
+	assert.NoError(err)
+	assert.EqualValues(12, view)
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"crypto"
+	"math/big"
+
+	"github.com/wooyang2018/ppov-blockchain/merkle"
+)
+
+// StateProof is the merkle inclusion proof of a state value against MerkleRoot,
+// Height is the committed block height when the proof was made
+type StateProof struct {
+	Key        []byte        `json:"key"`
+	Value      []byte        `json:"value"`
+	Height     uint64        `json:"height"`
+	MerkleRoot []byte        `json:"merkleRoot"`
+	LeafIndex  []byte        `json:"leafIndex"`
+	LeafCount  []byte        `json:"leafCount"`
+	Siblings   [][]ProofNode `json:"siblings"` // sibling nodes of each level from the leaf
+}
+
+// ProofNode is a sibling node of a StateProof, its level is the index of the siblings in the proof
+type ProofNode struct {
+	Index []byte `json:"index"`
+	Data  []byte `json:"data"`
+}
+
+// Verify checks the key and value of the proof against the trusted merkle root,
+// keyed is whether the leaves of the tree of the root hash their keys
+func (sp *StateProof) Verify(h crypto.Hash, bfactor uint8, keyed bool, root []byte) bool {
+	if len(sp.LeafCount) == 0 || len(sp.Siblings) > 255 {
+		return false
+	}
+	proof := &merkle.Proof{
+		LeafCount: big.NewInt(0).SetBytes(sp.LeafCount),
+		Leaf: &merkle.Node{
+			Position: merkle.NewPosition(0, big.NewInt(0).SetBytes(sp.LeafIndex)),
+			Data:     sumStateLeaf(h, sp.Key, sp.Value, keyed),
+		},
+		Siblings: make([][]*merkle.Node, len(sp.Siblings)),
+	}
+	for level, nodes := range sp.Siblings {
+		proof.Siblings[level] = make([]*merkle.Node, len(nodes))
+		for i, n := range nodes {
+			proof.Siblings[level][i] = &merkle.Node{
+				Position: merkle.NewPosition(uint8(level), big.NewInt(0).SetBytes(n.Index)),
+				Data:     n.Data,
+			}
+		}
+	}
+	return merkle.VerifyProof(h, bfactor, root, proof)
+}
+
+func newStateProof(key, value []byte, height uint64, root []byte, proof *merkle.Proof) *StateProof {
+	sp := &StateProof{
+		Key:        key,
+		Value:      value,
+		Height:     height,
+		MerkleRoot: root,
+		LeafIndex:  proof.Leaf.Position.Index().Bytes(),
+		LeafCount:  proof.LeafCount.Bytes(),
+		Siblings:   make([][]ProofNode, len(proof.Siblings)),
+	}
+	for level, nodes := range proof.Siblings {
+		sp.Siblings[level] = make([]ProofNode, len(nodes))
+		for i, n := range nodes {
+			sp.Siblings[level][i] = ProofNode{Index: n.Position.Index().Bytes(), Data: n.Data}
+		}
+	}
+	return sp
+}
@@ -7,6 +7,7 @@ package storage
 import (
 	"bytes"
 	"crypto"
+	"encoding/binary"
 	"math/big"
 	"sort"
 	"sync"
@@ -65,14 +66,14 @@ func setLeafIndex(sc *core.StateChange, idx *big.Int) {
 	sc.SetTreeIndex(idxB)
 }
 
-func (ss *stateStore) computeUpdatedTreeNodes(scList []*core.StateChange) []*merkle.Node {
+func (ss *stateStore) computeUpdatedTreeNodes(scList []*core.StateChange, keyed bool) []*merkle.Node {
 	nodes := make([]*merkle.Node, len(scList))
 	jobs := make(chan int, ss.concurrentLimit)
 	defer close(jobs)
 
 	wg := new(sync.WaitGroup)
 	for i := 0; i < ss.concurrentLimit; i++ {
-		go ss.worker(nodes, scList, jobs, wg)
+		go ss.worker(nodes, scList, keyed, jobs, wg)
 	}
 	for i := range scList {
 		wg.Add(1)
@@ -83,21 +84,31 @@ func (ss *stateStore) computeUpdatedTreeNodes(scList []*core.StateChange) []*mer
 }
 
 func (ss *stateStore) worker(
-	nodes []*merkle.Node, scList []*core.StateChange,
+	nodes []*merkle.Node, scList []*core.StateChange, keyed bool,
 	jobs <-chan int, wg *sync.WaitGroup,
 ) {
 	for i := range jobs {
 		sc := scList[i]
 		nodes[i] = &merkle.Node{
 			Position: merkle.NewPosition(0, big.NewInt(0).SetBytes(sc.TreeIndex())),
-			Data:     ss.sumStateValue(sc.Value()),
+			Data:     ss.sumStateLeaf(sc.Key(), sc.Value(), keyed),
 		}
 		wg.Done()
 	}
 }
 
-func (ss *stateStore) sumStateValue(value []byte) []byte {
-	h := ss.hashFunc.New()
+func (ss *stateStore) sumStateLeaf(key, value []byte, keyed bool) []byte {
+	return sumStateLeaf(ss.hashFunc, key, value, keyed)
+}
+
+// sumStateLeaf binds the value to its key, so a proof cannot be shown for another key,
+// the leaves of a legacy chain before the activation height only hash the value
+func sumStateLeaf(hashFunc crypto.Hash, key, value []byte, keyed bool) []byte {
+	h := hashFunc.New()
+	if keyed {
+		binary.Write(h, binary.BigEndian, uint32(len(key)))
+		h.Write(key)
+	}
 	h.Write(value)
 	return h.Sum(nil)
 }
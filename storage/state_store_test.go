@@ -84,7 +84,7 @@ func TestStateStore_computeUpdatedTreeNodes(t *testing.T) {
 		hashFunc:        hashFunc,
 		concurrentLimit: 20,
 	}
-	nodes := ss.computeUpdatedTreeNodes(scList)
+	nodes := ss.computeUpdatedTreeNodes(scList, true)
 
 	p0 := merkle.NewPosition(0, big.NewInt(9))
 	p1 := merkle.NewPosition(0, big.NewInt(12))
@@ -92,11 +92,18 @@ func TestStateStore_computeUpdatedTreeNodes(t *testing.T) {
 	assert.Equal(p0.Bytes(), nodes[0].Position.Bytes())
 	assert.Equal(p1.Bytes(), nodes[1].Position.Bytes())
 
-	d0 := ss.sumStateValue([]byte{10})
-	d1 := ss.sumStateValue([]byte{20})
+	d0 := ss.sumStateLeaf([]byte{1}, []byte{10}, true)
+	d1 := ss.sumStateLeaf([]byte{2}, []byte{20}, true)
 
 	assert.Equal(d0, nodes[0].Data)
 	assert.Equal(d1, nodes[1].Data)
+
+	// legacy leaves only hash the value
+	nodes = ss.computeUpdatedTreeNodes(scList, false)
+	h := hashFunc.New()
+	h.Write([]byte{10})
+	assert.Equal(h.Sum(nil), nodes[0].Data)
+	assert.NotEqual(d0, nodes[0].Data)
 }
 
 func TestStateStore_setNewTreeIndexes(t *testing.T) {
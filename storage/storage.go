@@ -6,6 +6,9 @@ package storage
 
 import (
 	"crypto"
+	"errors"
+	"fmt"
+	"math"
 	"math/big"
 	"sync"
 	"time"
@@ -27,11 +30,41 @@ type CommitData struct {
 	merkleUpdate *merkle.UpdateResult
 }
 
+// SafetyState is the hotstuff voting state which must survive restarts
+type SafetyState struct {
+	BVote       *core.Block
+	BLock       *core.Block
+	QCHigh      *core.QuorumCert
+	QCHighBlock *core.Block // block referenced by QCHigh
+}
+
+// GovernanceState is the scheduled epochs and the approvals of the pending validator set proposals
+type GovernanceState struct {
+	Epochs      []*core.Epoch       `json:"epochs"`                // scheduled epochs after genesis
+	Approvals   map[string][]string `json:"approvals"`             // approving validators by proposal hash
+	ApprovalTxs map[string][][]byte `json:"approvalTxs,omitempty"` // approving txs by proposal hash
+}
+
+// WALEntry is a consensus message recorded before it is processed
+type WALEntry struct {
+	Seq    uint64
+	Type   byte
+	Height uint64 // height of the referenced block, or the committed height when recorded
+	Data   []byte
+}
+
 type Config struct {
 	MerkleBranchFactor uint8
 	ConcurrentLimit    int
+	KeyedLeafHeight    uint64 // first committed height whose state leaves hash their keys
 }
 
+// NeverKeyedLeaf is the activation height of a chain which keeps hashing only the state values
+const NeverKeyedLeaf uint64 = math.MaxUint64
+
+// ErrStateVerification is returned when a state value does not match the merkle tree
+var ErrStateVerification = errors.New("merkle verification failed")
+
 var DefaultConfig = Config{
 	MerkleBranchFactor: 8,
 	ConcurrentLimit:    20,
@@ -42,9 +75,18 @@ type Storage struct {
 	chainStore  *chainStore
 	stateStore  *stateStore
 	merkleStore *merkleStore
+	safetyStore *safetyStore
+	evidStore   *evidenceStore
+	govStore    *governanceStore
+	walStore    *walStore
+	batchStore  *batchStore
+	cpStore     *checkpointStore
 	merkleTree  *merkle.Tree
 
-	// for writeStateTree and VerifyState
+	keyedHeight uint64
+	keyedLeaf   bool // whether the committed tree has keyed leaves
+
+	// for writeStateTree, VerifyState and GetStateProof
 	mtxWriteState sync.RWMutex
 }
 
@@ -54,11 +96,19 @@ func New(db *leveldb.DB, config Config) *Storage {
 	strg.chainStore = &chainStore{strg.db}
 	strg.stateStore = &stateStore{strg.db, crypto.SHA3_256, config.ConcurrentLimit}
 	strg.merkleStore = &merkleStore{strg.db}
+	strg.safetyStore = &safetyStore{strg.db}
+	strg.evidStore = &evidenceStore{db: strg.db}
+	strg.govStore = &governanceStore{strg.db}
+	strg.walStore = &walStore{strg.db}
+	strg.batchStore = &batchStore{strg.db}
+	strg.cpStore = &checkpointStore{strg.db}
 	strg.merkleTree = merkle.NewTree(strg.merkleStore, merkle.Config{
 		Hash:            crypto.SHA3_256,
 		BranchFactor:    config.MerkleBranchFactor,
 		ConcurrentLimit: config.ConcurrentLimit,
 	})
+	strg.keyedHeight = config.KeyedLeafHeight
+	strg.keyedLeaf = strg.GetBlockHeight() >= strg.keyedHeight
 	return strg
 }
 
@@ -87,6 +137,111 @@ func (strg *Storage) GetBlockByHeight(height uint64) (*core.Block, error) {
 	return strg.chainStore.getBlockByHeight(height)
 }
 
+func (strg *Storage) GetSafetyState() (*SafetyState, error) {
+	return strg.safetyStore.getSafetyState()
+}
+
+// SetSafetyState returns after the state is flushed to disk
+func (strg *Storage) SetSafetyState(ss *SafetyState) error {
+	return updateLevelDBSync(strg.db, strg.safetyStore.setSafetyState(ss))
+}
+
+func (strg *Storage) GetSafetyView() (uint64, error) {
+	return strg.safetyStore.getView()
+}
+
+// SetSafetyView returns after the view is flushed to disk
+func (strg *Storage) SetSafetyView(view uint64) error {
+	return updateLevelDBSync(strg.db, []updateFunc{strg.safetyStore.setView(view)})
+}
+
+func (strg *Storage) GetEvidence(hash []byte) (*core.Evidence, error) {
+	return strg.evidStore.getEvidence(hash)
+}
+
+func (strg *Storage) GetEvidenceList() ([]*core.Evidence, error) {
+	return strg.evidStore.getEvidenceList()
+}
+
+// HasEvidence checks whether the evidence or another one of the same type and offender is stored
+func (strg *Storage) HasEvidence(ev *core.Evidence) bool {
+	return strg.evidStore.hasEvidence(ev.Hash()) || strg.evidStore.hasOffenderEvidence(ev)
+}
+
+// SetEvidence keeps the first evidence of each type and offender, up to MaxEvidenceCount
+func (strg *Storage) SetEvidence(ev *core.Evidence) error {
+	strg.evidStore.mtx.Lock()
+	defer strg.evidStore.mtx.Unlock()
+
+	if strg.evidStore.hasOffenderEvidence(ev) {
+		return nil
+	}
+	count, err := strg.evidStore.count()
+	if err != nil {
+		return err
+	}
+	if count >= MaxEvidenceCount {
+		return ErrEvidenceLimit
+	}
+	return updateLevelDB(strg.db, []updateFunc{strg.evidStore.setEvidence(ev)})
+}
+
+func (strg *Storage) GetGovernanceState() (*GovernanceState, error) {
+	return strg.govStore.getGovernanceState()
+}
+
+// SetGovernanceState returns after the state is flushed to disk
+func (strg *Storage) SetGovernanceState(gs *GovernanceState) error {
+	return updateLevelDBSync(strg.db, []updateFunc{strg.govStore.setGovernanceState(gs)})
+}
+
+func (strg *Storage) GetWALEntries() ([]*WALEntry, error) {
+	return strg.walStore.getWALEntries()
+}
+
+// AppendWAL flushes the entry to disk, a proposal is recorded before it is voted
+func (strg *Storage) AppendWAL(entry *WALEntry) error {
+	return updateLevelDBSync(strg.db, []updateFunc{strg.walStore.setWALEntry(entry)})
+}
+
+func (strg *Storage) DeleteWAL(seqs []uint64) error {
+	return strg.walStore.deleteWALEntries(seqs)
+}
+
+func (strg *Storage) GetBatch(hash []byte) (*core.Batch, error) {
+	return strg.batchStore.getBatch(hash)
+}
+
+func (strg *Storage) HasBatch(hash []byte) bool {
+	return strg.batchStore.hasBatch(hash)
+}
+
+// StoreBatch keeps the batch body to serve the validators missing it
+func (strg *Storage) StoreBatch(batch *core.Batch) error {
+	return updateLevelDB(strg.db, []updateFunc{strg.batchStore.setBatch(batch)})
+}
+
+// DeleteBatches removes the batch bodies no longer served after their block is committed
+func (strg *Storage) DeleteBatches(hashes [][]byte) error {
+	return strg.batchStore.deleteBatches(hashes)
+}
+
+func (strg *Storage) GetCheckpointCert(height uint64) (*core.CheckpointCert, error) {
+	return strg.cpStore.getCheckpointCert(height)
+}
+
+func (strg *Storage) GetLastCheckpointCert() (*core.CheckpointCert, error) {
+	return strg.cpStore.getLastCheckpointCert()
+}
+
+func (strg *Storage) HasCheckpointCert(height uint64) bool {
+	return strg.cpStore.hasCheckpointCert(height)
+}
+
+func (strg *Storage) SetCheckpointCert(cert *core.CheckpointCert) error {
+	return updateLevelDB(strg.db, strg.cpStore.setCheckpointCert(cert))
+}
+
 func (strg *Storage) GetBlockCommit(hash []byte) (*core.BlockCommit, error) {
 	return strg.chainStore.getBlockCommit(hash)
 }
@@ -107,27 +262,49 @@ func (strg *Storage) GetState(key []byte) []byte {
 	return strg.stateStore.getStateNotFoundNil(key)
 }
 
-func (strg *Storage) VerifyState(key []byte) []byte {
+// VerifyState returns the state value after verifying it with the merkle root, nil if not found
+func (strg *Storage) VerifyState(key []byte) ([]byte, error) {
 	strg.mtxWriteState.RLock()
 	defer strg.mtxWriteState.RUnlock()
 
 	value, err := strg.stateStore.getState(key)
 	if err != nil {
 		// state not found
-		return nil
+		return nil, nil
 	}
 	merkleIdx, err := strg.stateStore.getMerkleIndex(key)
 	if err != nil {
-		panic("failed to get state merkle index")
+		return nil, fmt.Errorf("failed to get state merkle index, %w", err)
 	}
 	node := &merkle.Node{
-		Data:     strg.stateStore.sumStateValue(value),
+		Data:     strg.stateStore.sumStateLeaf(key, value, strg.keyedLeaf),
 		Position: merkle.NewPosition(0, big.NewInt(0).SetBytes(merkleIdx)),
 	}
 	if !strg.merkleTree.Verify([]*merkle.Node{node}) {
-		panic("merkle verification failed")
+		return nil, ErrStateVerification
+	}
+	return value, nil
+}
+
+// GetStateProof returns the value of the key with the merkle inclusion proof of the current state root
+func (strg *Storage) GetStateProof(key []byte) (*StateProof, error) {
+	strg.mtxWriteState.RLock()
+	defer strg.mtxWriteState.RUnlock()
+
+	value, err := strg.stateStore.getState(key)
+	if err != nil {
+		return nil, err
+	}
+	merkleIdx, err := strg.stateStore.getMerkleIndex(key)
+	if err != nil {
+		return nil, err
 	}
-	return value
+	proof := strg.merkleTree.Proof(big.NewInt(0).SetBytes(merkleIdx))
+	if proof == nil {
+		return nil, errors.New("merkle proof not found")
+	}
+	root := strg.merkleTree.Root()
+	return newStateProof(key, value, strg.GetBlockHeight(), root.Data, proof), nil
 }
 
 func (strg *Storage) GetMerkleRoot() []byte {
@@ -139,9 +316,11 @@ func (strg *Storage) GetMerkleRoot() []byte {
 }
 
 func (strg *Storage) commit(data *CommitData) error {
-	if len(data.BlockCommit.StateChanges()) > 0 {
+	if len(data.BlockCommit.StateChanges()) > 0 || strg.isKeyedActivation(data.Block.Height()) {
 		start := time.Now()
-		strg.computeMerkleUpdate(data)
+		if err := strg.computeMerkleUpdate(data); err != nil {
+			return err
+		}
 		elapsed := time.Since(start)
 		data.BlockCommit.SetElapsedMerkle(elapsed.Seconds())
 		logger.I().Debugw("compute merkle update",
@@ -170,17 +349,60 @@ func (strg *Storage) writeCommitData(data *CommitData) error {
 	return strg.setCommittedBlockHeight(data.Block.Height())
 }
 
-func (strg *Storage) computeMerkleUpdate(data *CommitData) {
-	strg.stateStore.loadPrevValues(data.BlockCommit.StateChanges())
-	strg.stateStore.loadPrevTreeIndexes(data.BlockCommit.StateChanges())
+func (strg *Storage) computeMerkleUpdate(data *CommitData) error {
+	scList := data.BlockCommit.StateChanges()
+	strg.stateStore.loadPrevValues(scList)
+	strg.stateStore.loadPrevTreeIndexes(scList)
 	prevLeafCount := strg.merkleStore.getLeafCount()
-	leafCount := strg.stateStore.setNewTreeIndexes(data.BlockCommit.StateChanges(), prevLeafCount)
-	nodes := strg.stateStore.computeUpdatedTreeNodes(data.BlockCommit.StateChanges())
+	leafCount := strg.stateStore.setNewTreeIndexes(scList, prevLeafCount)
+	nodes := strg.stateStore.computeUpdatedTreeNodes(scList, data.Block.Height() >= strg.keyedHeight)
+	if strg.isKeyedActivation(data.Block.Height()) {
+		keyedNodes, err := strg.computeKeyedLeaves(scList)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, keyedNodes...)
+		logger.I().Infow("switch to keyed state leaves",
+			"height", data.Block.Height(), "leaf nodes", len(keyedNodes))
+	}
 	data.merkleUpdate = strg.merkleTree.Update(nodes, leafCount)
 
 	data.BlockCommit.
 		SetLeafCount(data.merkleUpdate.LeafCount.Bytes()).
 		SetMerkleRoot(data.merkleUpdate.Root.Data)
+	return nil
+}
+
+// isKeyedActivation reports whether the legacy leaves of the tree are rehashed with their keys
+// on the commit of the block, every node switches at the same height of the chain
+func (strg *Storage) isKeyedActivation(height uint64) bool {
+	return height > 0 && height == strg.keyedHeight && strg.merkleStore.getLeafCount().Sign() > 0
+}
+
+// computeKeyedLeaves recomputes the keyed leaves of the stored states not in scList
+func (strg *Storage) computeKeyedLeaves(scList []*core.StateChange) ([]*merkle.Node, error) {
+	changed := make(map[string]struct{}, len(scList))
+	for _, sc := range scList {
+		changed[string(sc.Key())] = struct{}{}
+	}
+	nodes := make([]*merkle.Node, 0)
+	prefix := []byte{colMerkleIndexByStateKey}
+	err := strg.db.iterateKV(prefix, func(k, idx []byte) error {
+		key := k[len(prefix):]
+		if _, ok := changed[string(key)]; ok {
+			return nil
+		}
+		value, err := strg.stateStore.getState(key)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, &merkle.Node{
+			Position: merkle.NewPosition(0, big.NewInt(0).SetBytes(idx)),
+			Data:     strg.stateStore.sumStateLeaf(key, value, true),
+		})
+		return nil
+	})
+	return nodes, err
 }
 
 func (strg *Storage) writeChainData(data *CommitData) error {
@@ -199,7 +421,7 @@ func (strg *Storage) writeBlockCommit(data *CommitData) error {
 
 // commit state values and merkle tree in one transaction
 func (strg *Storage) writeStateMerkleTree(data *CommitData) error {
-	if len(data.BlockCommit.StateChanges()) == 0 {
+	if data.merkleUpdate == nil {
 		return nil
 	}
 	strg.mtxWriteState.Lock()
@@ -207,7 +429,11 @@ func (strg *Storage) writeStateMerkleTree(data *CommitData) error {
 
 	updFns := strg.stateStore.commitStateChanges(data.BlockCommit.StateChanges())
 	updFns = append(updFns, strg.merkleStore.commitUpdate(data.merkleUpdate)...)
-	return updateLevelDB(strg.db, updFns)
+	if err := updateLevelDB(strg.db, updFns); err != nil {
+		return err
+	}
+	strg.keyedLeaf = data.Block.Height() >= strg.keyedHeight
+	return nil
 }
 
 func (strg *Storage) setCommittedBlockHeight(height uint64) error {
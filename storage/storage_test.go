@@ -72,8 +72,8 @@ func TestStorage_Commit(t *testing.T) {
 	assert.Equal(big.NewInt(2).Bytes(), bcm.LeafCount())
 
 	h := hashFunc.New()
-	h.Write(strg.stateStore.sumStateValue([]byte{10}))
-	h.Write(strg.stateStore.sumStateValue([]byte{20}))
+	h.Write(strg.stateStore.sumStateLeaf([]byte{1}, []byte{10}, true))
+	h.Write(strg.stateStore.sumStateLeaf([]byte{2}, []byte{20}, true))
 	mroot := h.Sum(nil)
 	h.Reset()
 	assert.Equal(mroot, bcm.MerkleRoot())
@@ -142,35 +142,107 @@ func TestStorage_Commit(t *testing.T) {
 	assert.Equal(big.NewInt(2).Bytes(), bcm.StateChanges()[2].TreeIndex())
 	assert.Equal(big.NewInt(4).Bytes(), bcm.LeafCount())
 
-	h.Write(strg.stateStore.sumStateValue([]byte{20}))
-	h.Write(strg.stateStore.sumStateValue([]byte{20}))
-	h.Write(strg.stateStore.sumStateValue([]byte{30}))
-	h.Write(strg.stateStore.sumStateValue([]byte{50}))
+	h.Write(strg.stateStore.sumStateLeaf([]byte{1}, []byte{20}, true))
+	h.Write(strg.stateStore.sumStateLeaf([]byte{2}, []byte{20}, true))
+	h.Write(strg.stateStore.sumStateLeaf([]byte{3}, []byte{30}, true))
+	h.Write(strg.stateStore.sumStateLeaf([]byte{5}, []byte{50}, true))
 	mroot = h.Sum(nil)
 	h.Reset()
 	assert.Equal(mroot, bcm.MerkleRoot())
 	assert.Equal(bcm.MerkleRoot(), strg.GetMerkleRoot())
 
 	assert.Equal([]byte{50}, strg.GetState([]byte{5}))
-	var value []byte
-	assert.NotPanics(func() {
-		value = strg.VerifyState([]byte{5})
-	})
+	value, err := strg.VerifyState([]byte{5})
+	assert.NoError(err)
 	assert.Equal([]byte{50}, value)
 
-	assert.NotPanics(func() {
-		// non existing state value
-		value = strg.VerifyState([]byte{10})
-	})
+	// non existing state value
+	value, err = strg.VerifyState([]byte{10})
+	assert.NoError(err)
 	assert.Nil(value)
 
+	sp, err := strg.GetStateProof([]byte{3})
+	assert.NoError(err)
+	assert.Equal([]byte{30}, sp.Value)
+	assert.EqualValues(1, sp.Height)
+	assert.Equal(mroot, sp.MerkleRoot)
+	assert.True(sp.Verify(hashFunc, DefaultConfig.MerkleBranchFactor, true, mroot))
+	assert.False(sp.Verify(hashFunc, DefaultConfig.MerkleBranchFactor, false, mroot))
+	sp.Value = []byte{31}
+	assert.False(sp.Verify(hashFunc, DefaultConfig.MerkleBranchFactor, true, mroot))
+
+	_, err = strg.GetStateProof([]byte{10})
+	assert.Error(err)
+
 	// tampering state value
 	updFn := strg.stateStore.setState([]byte{5}, []byte{100})
 	updateLevelDB(strg.db, []updateFunc{updFn})
 
-	// should panic
-	assert.Panics(func() {
-		value = strg.VerifyState([]byte{5})
-	})
+	value, err = strg.VerifyState([]byte{5})
+	assert.ErrorIs(err, ErrStateVerification)
 	assert.Nil(value)
 }
+
+func TestStorage_KeyedLeafActivation(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := os.MkdirTemp("", "db")
+	rawDB, _ := NewLevelDB(dir)
+	config := DefaultConfig
+	config.KeyedLeafHeight = 2
+	strg := New(rawDB, config)
+
+	priv := core.GenerateKey(nil)
+	commit := func(height uint64, scList ...*core.StateChange) {
+		blk := core.NewBlock().SetHeight(height).Sign(priv)
+		err := strg.Commit(&CommitData{
+			Block:       blk,
+			QC:          core.NewQuorumCert(),
+			BlockCommit: core.NewBlockCommit().SetHash(blk.Hash()).SetStateChanges(scList),
+		})
+		assert.NoError(err)
+	}
+	sumValue := func(value []byte) []byte {
+		h := hashFunc.New()
+		h.Write(value)
+		return h.Sum(nil)
+	}
+
+	commit(0,
+		core.NewStateChange().SetKey([]byte{1}).SetValue([]byte{10}),
+		core.NewStateChange().SetKey([]byte{2}).SetValue([]byte{20}),
+	)
+	// legacy leaves keep the root of existing chains
+	h := hashFunc.New()
+	h.Write(sumValue([]byte{10}))
+	h.Write(sumValue([]byte{20}))
+	assert.Equal(h.Sum(nil), strg.GetMerkleRoot())
+	value, err := strg.VerifyState([]byte{1})
+	assert.NoError(err)
+	assert.Equal([]byte{10}, value)
+
+	commit(1)
+	sp, err := strg.GetStateProof([]byte{2})
+	assert.NoError(err)
+	assert.True(sp.Verify(hashFunc, config.MerkleBranchFactor, false, strg.GetMerkleRoot()))
+
+	// all leaves are rehashed with their keys at the activation height
+	commit(2, core.NewStateChange().SetKey([]byte{2}).SetValue([]byte{21}))
+	h.Reset()
+	h.Write(strg.stateStore.sumStateLeaf([]byte{1}, []byte{10}, true))
+	h.Write(strg.stateStore.sumStateLeaf([]byte{2}, []byte{21}, true))
+	assert.Equal(h.Sum(nil), strg.GetMerkleRoot())
+	value, err = strg.VerifyState([]byte{1})
+	assert.NoError(err)
+	assert.Equal([]byte{10}, value)
+
+	sp, err = strg.GetStateProof([]byte{1})
+	assert.NoError(err)
+	assert.True(sp.Verify(hashFunc, config.MerkleBranchFactor, true, strg.GetMerkleRoot()))
+
+	// the format is restored from the committed height on open
+	reopened := New(rawDB, config)
+	value, err = reopened.VerifyState([]byte{2})
+	assert.NoError(err)
+	assert.Equal([]byte{21}, value)
+}
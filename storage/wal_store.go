@@ -0,0 +1,55 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+type walStore struct {
+	db *levelDB
+}
+
+func walKey(seq uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = colWALEntryBySeq
+	binary.BigEndian.PutUint64(key[1:], seq)
+	return key
+}
+
+// getWALEntries 按序号顺序获取预写日志
+func (ws *walStore) getWALEntries() ([]*WALEntry, error) {
+	ret := make([]*WALEntry, 0)
+	err := ws.db.iterateKV([]byte{colWALEntryBySeq}, func(key, value []byte) error {
+		if len(key) != 9 || len(value) < 9 {
+			return errors.New("invalid wal entry")
+		}
+		ret = append(ret, &WALEntry{
+			Seq:    binary.BigEndian.Uint64(key[1:]),
+			Type:   value[0],
+			Height: binary.BigEndian.Uint64(value[1:9]),
+			Data:   append([]byte(nil), value[9:]...),
+		})
+		return nil
+	})
+	return ret, err
+}
+
+func (ws *walStore) setWALEntry(entry *WALEntry) updateFunc {
+	return func(setter setter) error {
+		val := make([]byte, 9, 9+len(entry.Data))
+		val[0] = entry.Type
+		binary.BigEndian.PutUint64(val[1:], entry.Height)
+		return setter.Set(walKey(entry.Seq), append(val, entry.Data...))
+	}
+}
+
+func (ws *walStore) deleteWALEntries(seqs []uint64) error {
+	keys := make([][]byte, len(seqs))
+	for i, seq := range seqs {
+		keys[i] = walKey(seq)
+	}
+	return ws.db.deleteKeys(keys)
+}
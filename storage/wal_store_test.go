@@ -0,0 +1,41 @@
+// Copyright (C) 2023 Wooyang2018
+// Licensed under the GNU General Public License v3.0
+
+package storage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWALStore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := os.MkdirTemp("", "db")
+	rawDB, _ := NewLevelDB(dir)
+	db := &levelDB{rawDB}
+	ws := &walStore{db}
+
+	entries, err := ws.getWALEntries()
+	assert.NoError(err)
+	assert.Empty(entries)
+
+	e1 := &WALEntry{Seq: 1, Type: 1, Height: 10, Data: []byte("proposal")}
+	e2 := &WALEntry{Seq: 2, Type: 3, Height: 9, Data: []byte("batch")}
+	e3 := &WALEntry{Seq: 256, Type: 2, Height: 10}
+	assert.NoError(updateLevelDB(db, []updateFunc{
+		ws.setWALEntry(e3), ws.setWALEntry(e1), ws.setWALEntry(e2),
+	}))
+
+	entries, err = ws.getWALEntries()
+	assert.NoError(err)
+	assert.Equal([]*WALEntry{e1, e2, e3}, entries,
+		"entries are in sequence order")
+
+	assert.NoError(ws.deleteWALEntries([]uint64{1, 256}))
+	entries, err = ws.getWALEntries()
+	assert.NoError(err)
+	assert.Equal([]*WALEntry{e2}, entries)
+}
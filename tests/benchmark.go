@@ -295,7 +295,7 @@ func (bm *Benchmark) onTick() error {
 			}
 		}
 	}()
-	if consensus.ExecuteTxFlag {
+	if bm.cluster.NodeConfig().ConsensusConfig.ExecuteTxFlag {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
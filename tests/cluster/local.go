@@ -16,6 +16,7 @@ import (
 
 	"github.com/multiformats/go-multiaddr"
 
+	"github.com/wooyang2018/ppov-blockchain/core"
 	"github.com/wooyang2018/ppov-blockchain/node"
 )
 
@@ -25,6 +26,7 @@ type LocalFactoryParams struct {
 	NodeCount   int
 	WorkerCount int
 	VoterCount  int
+	SignVersion core.SignVersion
 
 	SetupDocker bool
 	NodeConfig  node.Config
@@ -66,7 +68,8 @@ func (ftry *LocalFactory) setup() (err error) {
 	}
 	keys := MakeRandomKeys(ftry.params.NodeCount)
 	peers := MakePeers(keys, pointAddrs, topicAddrs)
-	return SetupTemplateDir(ftry.templateDir, keys, peers, ftry.params.WorkerCount, ftry.params.VoterCount)
+	return SetupTemplateDir(ftry.templateDir, keys, peers, ftry.params.WorkerCount, ftry.params.VoterCount,
+		ftry.params.NodeConfig.ConsensusConfig.Variant, ftry.params.SignVersion)
 }
 
 func (ftry *LocalFactory) makeDockerAddrs() ([]multiaddr.Multiaddr, []multiaddr.Multiaddr, error) {
@@ -16,6 +16,7 @@ import (
 
 	"github.com/multiformats/go-multiaddr"
 
+	"github.com/wooyang2018/ppov-blockchain/core"
 	"github.com/wooyang2018/ppov-blockchain/node"
 )
 
@@ -25,6 +26,7 @@ type RemoteFactoryParams struct {
 	NodeCount   int
 	WorkerCount int
 	VoterCount  int
+	SignVersion core.SignVersion
 
 	NodeConfig node.Config
 
@@ -110,7 +112,8 @@ func (ftry *RemoteFactory) setup() error {
 	}
 	keys := MakeRandomKeys(ftry.params.NodeCount)
 	peers := MakePeers(keys, pointAddrs, topicAddrs)
-	if err := SetupTemplateDir(ftry.templateDir, keys, peers, ftry.params.WorkerCount, ftry.params.VoterCount); err != nil {
+	if err := SetupTemplateDir(ftry.templateDir, keys, peers, ftry.params.WorkerCount, ftry.params.VoterCount,
+		ftry.params.NodeConfig.ConsensusConfig.Variant, ftry.params.SignVersion); err != nil {
 		return err
 	}
 	if err := ftry.setupRemoteServers(); err != nil {
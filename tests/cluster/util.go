@@ -5,6 +5,7 @@
 package cluster
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -20,6 +21,8 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/hotstuff"
+	"github.com/wooyang2018/ppov-blockchain/keystore"
 	"github.com/wooyang2018/ppov-blockchain/node"
 )
 
@@ -48,7 +51,7 @@ func NewDockerCompose(cls *Cluster) DockerCompose {
 			Ports:   []string{fmt.Sprintf("%d:%d", curNode.NodeConfig().APIPort+i, curNode.NodeConfig().APIPort)},
 			Command: curNode.PrintCmd(),
 		}
-		for _, v := range []string{node.GenesisFile, node.NodekeyFile, node.PeersFile} {
+		for _, v := range []string{node.GenesisFile, node.NodekeyFile, PassphraseFile, node.PeersFile} {
 			filepath := path.Join(curNode.NodeConfig().DataDir, v)
 			service.Volumes = append(service.Volumes, fmt.Sprintf("./%d/%s:%s", i, v, filepath))
 		}
@@ -69,14 +72,16 @@ func WriteYamlFile(clusterDir string, data DockerCompose) error {
 	return encoder.Encode(data)
 }
 
+// PassphraseFile is stored beside the nodekey of the test nodes
+const PassphraseFile = "passphrase"
+
+// WriteNodeKey writes the nodekey encrypted with a random passphrase
 func WriteNodeKey(datadir string, key *core.PrivateKey) error {
-	f, err := os.Create(path.Join(datadir, node.NodekeyFile))
-	if err != nil {
+	pass := []byte(base64.StdEncoding.EncodeToString(core.GenerateKey(nil).PublicKey().Bytes()))
+	if err := os.WriteFile(path.Join(datadir, PassphraseFile), pass, 0600); err != nil {
 		return err
 	}
-	defer f.Close()
-	_, err = f.Write(key.Bytes())
-	return err
+	return keystore.WriteKeyFile(path.Join(datadir, node.NodekeyFile), key, pass, keystore.LightScrypt)
 }
 
 func WriteGenesisFile(datadir string, genesis *node.Genesis) error {
@@ -125,7 +130,8 @@ func MakePeers(keys []*core.PrivateKey, pointAddrs, topicAddrs []multiaddr.Multi
 	return vlds
 }
 
-func SetupTemplateDir(dir string, keys []*core.PrivateKey, vlds []node.Peer, WorkerCount, VoterCount int) error {
+func SetupTemplateDir(dir string, keys []*core.PrivateKey, vlds []node.Peer,
+	WorkerCount, VoterCount int, variant hotstuff.Variant, signVersion core.SignVersion) error {
 	if err := os.RemoveAll(dir); err != nil {
 		return err
 	}
@@ -135,6 +141,9 @@ func SetupTemplateDir(dir string, keys []*core.PrivateKey, vlds []node.Peer, Wor
 	genesis := &node.Genesis{
 		Workers: make([]string, 0),
 		Voters:  make([]string, 0),
+		Variant: variant.String(),
+		// clients in the test process sign transactions with the same scheme
+		SignVersion: signVersion,
 	}
 
 	workers := PickUniqueRandoms(len(keys), WorkerCount, true)
@@ -188,6 +197,7 @@ func RunCommand(cmd *exec.Cmd) error {
 func AddPPoVFlags(cmd *exec.Cmd, config *node.Config) {
 	cmd.Args = append(cmd.Args, "-d", config.DataDir)
 	cmd.Args = append(cmd.Args, "-p", strconv.Itoa(config.APIPort))
+	cmd.Args = append(cmd.Args, "--passphraseFile", path.Join(config.DataDir, PassphraseFile))
 	cmd.Args = append(cmd.Args, "--pointPort", strconv.Itoa(config.PointPort))
 	cmd.Args = append(cmd.Args, "--topicPort", strconv.Itoa(config.TopicPort))
 	cmd.Args = append(cmd.Args, "--chainID",
@@ -198,6 +208,7 @@ func AddPPoVFlags(cmd *exec.Cmd, config *node.Config) {
 	if config.BroadcastTx {
 		cmd.Args = append(cmd.Args, "--broadcastTx")
 	}
+	cmd.Args = append(cmd.Args, "--sigCacheSize", strconv.Itoa(config.SigCacheSize))
 
 	cmd.Args = append(cmd.Args, "--storage-merkleBranchFactor",
 		strconv.Itoa(int(config.StorageConfig.MerkleBranchFactor)))
@@ -218,12 +229,24 @@ func AddPPoVFlags(cmd *exec.Cmd, config *node.Config) {
 	cmd.Args = append(cmd.Args, "--consensus-voteBatchLimit",
 		strconv.Itoa(config.ConsensusConfig.VoteBatchLimit))
 
+	cmd.Args = append(cmd.Args, "--consensus-txSizeLimit",
+		strconv.Itoa(config.ConsensusConfig.TxSizeLimit))
+
+	cmd.Args = append(cmd.Args, "--consensus-batchSizeLimit",
+		strconv.Itoa(config.ConsensusConfig.BatchSizeLimit))
+
+	cmd.Args = append(cmd.Args, "--consensus-blockSizeLimit",
+		strconv.Itoa(config.ConsensusConfig.BlockSizeLimit))
+
 	cmd.Args = append(cmd.Args, "--consensus-txWaitTime",
 		config.ConsensusConfig.TxWaitTime.String())
 
 	cmd.Args = append(cmd.Args, "--consensus-batchWaitTime",
 		config.ConsensusConfig.BatchWaitTime.String())
 
+	cmd.Args = append(cmd.Args, "--consensus-partitionTimeout",
+		config.ConsensusConfig.PartitionTimeout.String())
+
 	cmd.Args = append(cmd.Args, "--consensus-proposeTimeout",
 		config.ConsensusConfig.ProposeTimeout.String())
 
@@ -236,8 +259,47 @@ func AddPPoVFlags(cmd *exec.Cmd, config *node.Config) {
 	cmd.Args = append(cmd.Args, "--consensus-leaderTimeout",
 		config.ConsensusConfig.LeaderTimeout.String())
 
+	cmd.Args = append(cmd.Args, "--consensus-newViewTimeout",
+		config.ConsensusConfig.NewViewTimeout.String())
+
 	cmd.Args = append(cmd.Args, "--consensus-benchmarkPath",
 		config.ConsensusConfig.BenchmarkPath)
+
+	cmd.Args = append(cmd.Args, "--consensus-executeTx="+
+		strconv.FormatBool(config.ConsensusConfig.ExecuteTxFlag))
+
+	cmd.Args = append(cmd.Args, "--consensus-preserveTx="+
+		strconv.FormatBool(config.ConsensusConfig.PreserveTxFlag))
+
+	cmd.Args = append(cmd.Args, "--consensus-generateTx="+
+		strconv.FormatBool(config.ConsensusConfig.GenerateTxFlag))
+
+	cmd.Args = append(cmd.Args, "--consensus-voteBatch="+
+		strconv.FormatBool(config.ConsensusConfig.VoteBatchFlag))
+
+	cmd.Args = append(cmd.Args, "--consensus-voteForward="+
+		strconv.FormatBool(config.ConsensusConfig.VoteForwardFlag))
+
+	cmd.Args = append(cmd.Args, "--consensus-partitionTx="+
+		strconv.FormatBool(config.ConsensusConfig.PartitionTxFlag))
+
+	cmd.Args = append(cmd.Args, "--consensus-compactQC="+
+		strconv.FormatBool(config.ConsensusConfig.CompactQCFlag))
+
+	cmd.Args = append(cmd.Args, "--consensus-leaderElection",
+		config.ConsensusConfig.LeaderElection)
+
+	cmd.Args = append(cmd.Args, "--consensus-reputationWindow",
+		strconv.Itoa(config.ConsensusConfig.ReputationWindow))
+
+	cmd.Args = append(cmd.Args, "--consensus-epochDelay",
+		strconv.Itoa(config.ConsensusConfig.EpochDelay))
+
+	cmd.Args = append(cmd.Args, "--consensus-syncParallel",
+		strconv.Itoa(config.ConsensusConfig.SyncParallel))
+
+	cmd.Args = append(cmd.Args, "--consensus-checkpointInterval",
+		strconv.Itoa(config.ConsensusConfig.CheckpointInterval))
 }
 
 func PickUniqueRandoms(total, count int, isSort bool) []int {
@@ -12,14 +12,16 @@ import (
 	"github.com/wooyang2018/ppov-blockchain/tests/testutil"
 )
 
-type CorrectExecution struct{}
+type CorrectExecution struct {
+	SignScheme *core.SignScheme // sign scheme of the chain to sign transactions
+}
 
 func (expm *CorrectExecution) Name() string {
 	return "correct_execution"
 }
 
 func (expm *CorrectExecution) Run(cls *cluster.Cluster) error {
-	jc := testutil.NewPPoVCoinClient(nil, 0, 0, "")
+	jc := testutil.NewPPoVCoinClient(nil, 0, 0, "", expm.SignScheme)
 
 	if err := jc.SetupOnCluster(cls); err != nil {
 		return fmt.Errorf("setup ppovcoin failed. %w", err)
@@ -15,6 +15,8 @@ import (
 	"time"
 
 	"github.com/wooyang2018/ppov-blockchain/consensus"
+	"github.com/wooyang2018/ppov-blockchain/core"
+	"github.com/wooyang2018/ppov-blockchain/hotstuff"
 	"github.com/wooyang2018/ppov-blockchain/node"
 	"github.com/wooyang2018/ppov-blockchain/tests/cluster"
 	"github.com/wooyang2018/ppov-blockchain/tests/experiments"
@@ -38,6 +40,21 @@ var (
 	CheckRotation  = false
 	BroadcastTx    = false
 
+	// consensus modes passed to every node
+	ExecuteTxFlag  = false // set to false when benchmark test
+	PreserveTxFlag = true  // set to true when benchmark test
+	GenerateTxFlag = true
+	VoteBatchFlag  = false // set to false to prevent voting on batch
+
+	// hotstuff commit rule recorded in genesis file
+	HotstuffVariant = hotstuff.TwoPhase
+
+	// signing scheme recorded in genesis file, also used by the load clients
+	SignVersion = core.SignDomain
+
+	// leader election policy of the rotator
+	LeaderElection = consensus.ElectionRoundRobin
+
 	// run tests in remote linux cluster
 	RemoteLinuxCluster    = false // if false it'll use local cluster (running multiple nodes on single local machine)
 	RemoteSetupRequired   = true
@@ -56,10 +73,26 @@ var (
 	OnlyRunCluster   = false
 )
 
+// sign scheme of the load clients, the same as the one in genesis file
+var signScheme *core.SignScheme
+
+func makeSignScheme() *core.SignScheme {
+	scheme, err := core.NewSignSchemeOf(SignVersion, getNodeConfig().ConsensusConfig.ChainID, 0)
+	check(err)
+	return scheme
+}
+
 func getNodeConfig() node.Config {
 	config := node.DefaultConfig
 	config.Debug = true
 	config.BroadcastTx = BroadcastTx
+	config.ConsensusConfig.ExecuteTxFlag = ExecuteTxFlag
+	config.ConsensusConfig.PreserveTxFlag = PreserveTxFlag
+	config.ConsensusConfig.GenerateTxFlag = GenerateTxFlag
+	config.ConsensusConfig.VoteBatchFlag = VoteBatchFlag
+	config.ConsensusConfig.PartitionTxFlag = BroadcastTx // workers receive the same txs
+	config.ConsensusConfig.Variant = HotstuffVariant
+	config.ConsensusConfig.LeaderElection = LeaderElection
 	if !CheckRotation {
 		config.ConsensusConfig.ViewWidth = 24 * time.Hour
 		config.ConsensusConfig.LeaderTimeout = 24 * time.Hour
@@ -78,13 +111,14 @@ func setupExperiments() []Experiment {
 		})
 	}
 	expms = append(expms, &experiments.MajorityKeepRunning{})
-	expms = append(expms, &experiments.CorrectExecution{})
+	expms = append(expms, &experiments.CorrectExecution{SignScheme: signScheme})
 	expms = append(expms, &experiments.RestartCluster{})
 	return expms
 }
 
 func main() {
 	printAndCheckVars()
+	signScheme = makeSignScheme()
 	os.Mkdir(WorkDir, 0755)
 	buildPPoV()
 	setupTransport()
@@ -195,10 +229,13 @@ func printAndCheckVars() {
 	fmt.Println("RemoteLinuxCluster =", RemoteLinuxCluster)
 	fmt.Println("RunBenchmark =", RunBenchmark)
 	fmt.Println("BenchLoads =", BenchLoads)
-	fmt.Println("consensus.ExecuteTxFlag =", consensus.ExecuteTxFlag)
-	fmt.Println("consensus.PreserveTxFlag =", consensus.PreserveTxFlag)
-	fmt.Println("consensus.GenerateTxFlag =", consensus.GenerateTxFlag)
-	fmt.Println("consensus.VoteBatchFlag =", consensus.VoteBatchFlag)
+	fmt.Println("ExecuteTxFlag =", ExecuteTxFlag)
+	fmt.Println("PreserveTxFlag =", PreserveTxFlag)
+	fmt.Println("GenerateTxFlag =", GenerateTxFlag)
+	fmt.Println("VoteBatchFlag =", VoteBatchFlag)
+	fmt.Println("HotstuffVariant =", HotstuffVariant)
+	fmt.Println("SignVersion =", SignVersion)
+	fmt.Println("LeaderElection =", LeaderElection)
 	fmt.Println()
 	pass := true
 	if !RunBenchmark && len(LoadSubmitNodes) != 0 {
@@ -207,8 +244,8 @@ func printAndCheckVars() {
 	if RunBenchmark && !LoadBatchSubmit {
 		fmt.Println("RunBenchmark =?=> LoadBatchSubmit")
 	}
-	if !consensus.ExecuteTxFlag && !EmptyChainCode {
-		fmt.Println("!consensus.ExecuteTxFlag ===> EmptyChainCode")
+	if !ExecuteTxFlag && !EmptyChainCode {
+		fmt.Println("!ExecuteTxFlag ===> EmptyChainCode")
 		pass = false
 	}
 	if !RunBenchmark && !CheckRotation {
@@ -234,18 +271,18 @@ func printAndCheckVars() {
 		fmt.Println("OnlyRunCluster ===> !RunBenchmark")
 		pass = false
 	}
-	if !consensus.ExecuteTxFlag && !RunBenchmark {
-		fmt.Println("!consensus.ExecuteTxFlag ===> RunBenchmark")
+	if !ExecuteTxFlag && !RunBenchmark {
+		fmt.Println("!ExecuteTxFlag ===> RunBenchmark")
 	}
-	if consensus.PreserveTxFlag && !RunBenchmark {
-		fmt.Println("consensus.PreserveTxFlag ===> RunBenchmark")
+	if PreserveTxFlag && !RunBenchmark {
+		fmt.Println("PreserveTxFlag ===> RunBenchmark")
 	}
-	if consensus.ExecuteTxFlag && consensus.PreserveTxFlag {
-		fmt.Println("consensus.ExecuteTxFlag ===> !consensus.PreserveTxFlag")
+	if ExecuteTxFlag && PreserveTxFlag {
+		fmt.Println("ExecuteTxFlag ===> !PreserveTxFlag")
 		pass = false
 	}
-	if consensus.ExecuteTxFlag && consensus.GenerateTxFlag {
-		fmt.Println("consensus.ExecuteTxFlag ===> !consensus.GenerateTxFlag")
+	if ExecuteTxFlag && GenerateTxFlag {
+		fmt.Println("ExecuteTxFlag ===> !GenerateTxFlag")
 		pass = false
 	}
 	if pass {
@@ -270,7 +307,7 @@ func buildPPoV() {
 func makeLoadClient() testutil.LoadClient {
 	fmt.Println("Preparing load client")
 	if EmptyChainCode {
-		return testutil.NewEmptyClient(LoadSubmitNodes)
+		return testutil.NewEmptyClient(LoadSubmitNodes, signScheme)
 	}
 	var binccPath string
 	if PPoVCoinBinCC {
@@ -279,7 +316,7 @@ func makeLoadClient() testutil.LoadClient {
 	}
 	mintAccounts := 100
 	destAccounts := 10000 // increase dest accounts for benchmark
-	return testutil.NewPPoVCoinClient(LoadSubmitNodes, mintAccounts, destAccounts, binccPath)
+	return testutil.NewPPoVCoinClient(LoadSubmitNodes, mintAccounts, destAccounts, binccPath, signScheme)
 }
 
 func buildPPoVCoinBinCC() {
@@ -302,6 +339,7 @@ func makeLocalClusterFactory() *cluster.LocalFactory {
 		NodeCount:   NodeCount,
 		WorkerCount: WorkerCount,
 		VoterCount:  VoterCount,
+		SignVersion: SignVersion,
 		SetupDocker: OnlySetupDocker,
 		NodeConfig:  getNodeConfig(),
 	})
@@ -316,6 +354,7 @@ func makeRemoteClusterFactory() *cluster.RemoteFactory {
 		NodeCount:       NodeCount,
 		WorkerCount:     WorkerCount,
 		VoterCount:      VoterCount,
+		SignVersion:     SignVersion,
 		NodeConfig:      getNodeConfig(),
 		KeySSH:          RemoteKeySSH,
 		HostsPath:       RemoteHostsPath,
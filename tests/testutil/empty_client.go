@@ -11,7 +11,6 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/wooyang2018/ppov-blockchain/consensus"
 	"github.com/wooyang2018/ppov-blockchain/core"
 	"github.com/wooyang2018/ppov-blockchain/execution"
 	"github.com/wooyang2018/ppov-blockchain/tests/cluster"
@@ -19,6 +18,7 @@ import (
 
 type EmptyClient struct {
 	signer   *core.PrivateKey
+	scheme   *core.SignScheme
 	cluster  *cluster.Cluster
 	codeAddr []byte
 	nodes    []int
@@ -26,9 +26,10 @@ type EmptyClient struct {
 
 var _ LoadClient = (*EmptyClient)(nil)
 
-func NewEmptyClient(nodes []int) *EmptyClient {
+func NewEmptyClient(nodes []int, scheme *core.SignScheme) *EmptyClient {
 	return &EmptyClient{
 		signer: core.GenerateKey(nil),
+		scheme: scheme,
 		nodes:  nodes,
 	}
 }
@@ -74,7 +75,7 @@ func (client *EmptyClient) BatchSubmitTx(num int) (int, *core.TxList, error) {
 
 func (client *EmptyClient) setupOnCluster(cls *cluster.Cluster) error {
 	client.cluster = cls
-	if consensus.ExecuteTxFlag {
+	if cls.NodeConfig().ConsensusConfig.ExecuteTxFlag {
 		if err := client.deploy(); err != nil {
 			return err
 		}
@@ -98,7 +99,7 @@ func (client *EmptyClient) MakeDeploymentTx(minter *core.PrivateKey) *core.Trans
 	return core.NewTransaction().
 		SetNonce(time.Now().UnixNano()).
 		SetInput(b).
-		Sign(minter)
+		Sign(core.NewChainSigner(minter, client.scheme))
 }
 
 func (client *EmptyClient) nativeDeploymentInput() *execution.DeploymentInput {
@@ -119,5 +120,5 @@ func (client *EmptyClient) MakeTx() *core.Transaction {
 		SetCodeAddr(codeAddr).
 		SetNonce(time.Now().UnixNano()).
 		SetInput([]byte(strconv.Itoa(rand.Intn(math.MaxInt)))).
-		Sign(client.signer)
+		Sign(core.NewChainSigner(client.signer, client.scheme))
 }
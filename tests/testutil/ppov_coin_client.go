@@ -20,6 +20,7 @@ import (
 
 type PPoVCoinClient struct {
 	binccPath       string
+	scheme          *core.SignScheme
 	minter          *core.PrivateKey
 	accounts        []*core.PrivateKey
 	dests           []*core.PrivateKey
@@ -35,9 +36,12 @@ var _ LoadClient = (*PPoVCoinClient)(nil)
 
 // create and setup a LoadService
 // submit chaincode deploy tx and wait for commit
-func NewPPoVCoinClient(nodes []int, mintCount, destCount int, binccPath string) *PPoVCoinClient {
+func NewPPoVCoinClient(nodes []int, mintCount, destCount int, binccPath string,
+	scheme *core.SignScheme,
+) *PPoVCoinClient {
 	client := &PPoVCoinClient{
 		binccPath: binccPath,
+		scheme:    scheme,
 		minter:    core.GenerateKey(nil),
 		accounts:  make([]*core.PrivateKey, mintCount),
 		dests:     make([]*core.PrivateKey, destCount),
@@ -191,7 +195,7 @@ func (client *PPoVCoinClient) MakeDeploymentTx(minter *core.PrivateKey) *core.Tr
 	return core.NewTransaction().
 		SetNonce(time.Now().UnixNano()).
 		SetInput(b).
-		Sign(minter)
+		Sign(core.NewChainSigner(minter, client.scheme))
 }
 
 func (client *PPoVCoinClient) nativeDeploymentInput() *execution.DeploymentInput {
@@ -227,7 +231,7 @@ func (client *PPoVCoinClient) MakeMintTx(dest *core.PublicKey, value int64) *cor
 		SetCodeAddr(client.codeAddr).
 		SetNonce(time.Now().UnixNano()).
 		SetInput(b).
-		Sign(client.minter)
+		Sign(core.NewChainSigner(client.minter, client.scheme))
 }
 
 func (client *PPoVCoinClient) MakeTransferTx(
@@ -243,7 +247,7 @@ func (client *PPoVCoinClient) MakeTransferTx(
 		SetCodeAddr(client.codeAddr).
 		SetNonce(time.Now().UnixNano()).
 		SetInput(b).
-		Sign(sender)
+		Sign(core.NewChainSigner(sender, client.scheme))
 }
 
 func (client *PPoVCoinClient) MakeBalanceQuery(dest *core.PublicKey) *execution.QueryData {
@@ -8,6 +8,8 @@ import (
 	"bytes"
 	"encoding/base64"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/wooyang2018/ppov-blockchain/core"
 	"github.com/wooyang2018/ppov-blockchain/emitter"
@@ -34,6 +36,9 @@ type MsgService interface {
 	RequestTxList(pubKey *core.PublicKey, hashes [][]byte) (*core.TxList, error)
 }
 
+// TxFilter decides whether a queued tx can be taken, waited is the duration since the tx was received
+type TxFilter func(tx *core.Transaction, waited time.Duration) bool
+
 type TxStatus uint8
 
 const (
@@ -44,19 +49,25 @@ const (
 )
 
 type TxPool struct {
-	storage     Storage      //存储服务
-	execution   Execution    //交易执行服务
-	msgSvc      MsgService   //通信服务
-	store       *txStore     //交易缓存
-	broadcaster *broadcaster //交易广播器
-	broadcastTx bool         //是否广播交易
+	storage     Storage          //存储服务
+	execution   Execution        //交易执行服务
+	msgSvc      MsgService       //通信服务
+	store       *txStore         //交易缓存
+	broadcaster *broadcaster     //交易广播器
+	broadcastTx bool             //是否广播交易
+	scheme      *core.SignScheme //链的签名方案
+	txSizeLimit int              //交易编码后的最大字节数
 }
 
-func New(storage Storage, execution Execution, msgSvc MsgService, broadcastTx bool) *TxPool {
+func New(storage Storage, execution Execution, msgSvc MsgService,
+	scheme *core.SignScheme, txSizeLimit int, broadcastTx bool,
+) *TxPool {
 	pool := &TxPool{
 		storage:     storage,
 		execution:   execution,
 		msgSvc:      msgSvc,
+		scheme:      scheme,
+		txSizeLimit: txSizeLimit,
 		store:       newTxStore(),
 		broadcastTx: broadcastTx,
 	}
@@ -92,6 +103,14 @@ func (pool *TxPool) GetTxsFromQueue(max int) []*core.Transaction {
 	return pool.store.getTxsFromQueue(max)
 }
 
+func (pool *TxPool) PopTxsFromQueueBy(max int, filter TxFilter) []*core.Transaction {
+	return pool.store.popTxsFromQueueBy(max, filter)
+}
+
+func (pool *TxPool) GetTxsFromQueueBy(max int, filter TxFilter) []*core.Transaction {
+	return pool.store.getTxsFromQueueBy(max, filter)
+}
+
 func (pool *TxPool) PutTxsToQueue(hashes [][]byte) {
 	pool.store.putTxsToQueue(hashes)
 }
@@ -166,8 +185,13 @@ func (pool *TxPool) addTxList(txList *core.TxList, pending bool) error {
 	return nil
 }
 
+// addNewTx rejects the new txs over the size limit, pending txs are already in a batch
+// which is limited as a whole and must be kept to execute its block
 func (pool *TxPool) addNewTx(tx *core.Transaction, pending bool) error {
-	if err := tx.Validate(); err != nil {
+	if err := pool.checkTxSize(tx, pending); err != nil {
+		return err
+	}
+	if err := tx.Validate(pool.scheme); err != nil {
 		return err
 	}
 	if pool.storage.HasTx(tx.Hash()) {
@@ -180,6 +204,13 @@ func (pool *TxPool) addNewTx(tx *core.Transaction, pending bool) error {
 	return nil
 }
 
+func (pool *TxPool) checkTxSize(tx *core.Transaction, pending bool) error {
+	if size := tx.Size(); !pending && size > pool.txSizeLimit {
+		return fmt.Errorf("tx size %d exceeds limit %d", size, pool.txSizeLimit)
+	}
+	return nil
+}
+
 func (pool *TxPool) syncTxs(peer *core.PublicKey, hashes [][]byte) error {
 	missing := make([][]byte, 0)
 	for _, hash := range hashes {
@@ -213,6 +244,10 @@ func (pool *TxPool) requestTxList(peer *core.PublicKey, hashes [][]byte) (*core.
 func (pool *TxPool) storeTxs(txs *core.TxList, pending bool) error {
 	missing := make([]*core.Transaction, 0)
 	for _, tx := range *txs {
+		// oversize txs must not be broadcast
+		if err := pool.checkTxSize(tx, pending); err != nil {
+			return err
+		}
 		if !pool.storage.HasTx(tx.Hash()) && pool.store.getTx(tx.Hash()) == nil {
 			missing = append(missing, tx)
 			if !pending && pool.broadcastTx {
@@ -75,7 +75,7 @@ func TestTxPool_SubmitTx(t *testing.T) {
 
 	msgSvc.On("SubscribeTxList", mock.Anything).Return(emitter.New().Subscribe(10))
 
-	pool := New(storage, execution, msgSvc, true)
+	pool := New(storage, execution, msgSvc, nil, 1<<20, true)
 	pool.broadcaster.timer.Reset(time.Hour) // to avoid timeout broadcast for testing
 	pool.broadcaster.batchSize = 2          // broadcast after two successful submitTx
 
@@ -102,6 +102,11 @@ func TestTxPool_SubmitTx(t *testing.T) {
 	assert.Error(err, "verify should failed for executed tx")
 	storage.AssertExpectations(t)
 
+	// tx4 is over the size limit
+	tx4 := core.NewTransaction().SetNonce(4).SetInput(make([]byte, 1<<20)).Sign(priv)
+	assert.Error(pool.SubmitTx(tx4))
+	assert.Error(pool.StoreTxs(&core.TxList{tx4}))
+
 	// tx3 is already executed
 	storage.On("HasTx", tx3.Hash()).Return(true)
 	msgSvc.On("BroadcastTxList", &core.TxList{tx1, tx3}).Return(nil)
@@ -131,7 +136,7 @@ func TestTxPool_SubscribeTxList(t *testing.T) {
 	txEmitter := emitter.New()
 	msgSvc.On("SubscribeTxList", mock.Anything).Return(txEmitter.Subscribe(10))
 
-	pool := New(storage, execution, msgSvc, true)
+	pool := New(storage, execution, msgSvc, nil, 1<<20, true)
 	pool.broadcaster.timeout = time.Minute // to avoid timeout broadcast
 	pool.broadcaster.timer.Reset(time.Minute)
 
@@ -167,7 +172,7 @@ func TestTxPool_Sync(t *testing.T) {
 
 	msgSvc.On("SubscribeTxList", mock.Anything).Return(emitter.New().Subscribe(10))
 
-	pool := New(storage, execution, msgSvc, true)
+	pool := New(storage, execution, msgSvc, nil, 1<<20, true)
 	pool.broadcaster.timeout = time.Minute // to avoid timeout broadcast
 	pool.broadcaster.timer.Reset(time.Minute)
 
@@ -224,7 +229,7 @@ func TestTxPool_GetTxsToExecute(t *testing.T) {
 
 	msgSvc.On("SubscribeTxList", mock.Anything).Return(emitter.New().Subscribe(10))
 
-	pool := New(storage, execution, msgSvc, true)
+	pool := New(storage, execution, msgSvc, nil, 1<<20, true)
 	pool.broadcaster.timeout = time.Minute // to avoid timeout broadcast
 	pool.broadcaster.timer.Reset(time.Minute)
 
@@ -6,6 +6,7 @@ package txpool
 
 import (
 	"container/heap"
+	"sort"
 	"sync"
 	"time"
 
@@ -131,6 +132,49 @@ func (store *txStore) getTxsFromQueue(max int) []*core.Transaction {
 	return ret
 }
 
+// popTxsFromQueueBy pops the earliest received txs accepted by the filter, other txs stay in the queue
+func (store *txStore) popTxsFromQueueBy(max int, filter TxFilter) []*core.Transaction {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	items := store.filterQueue(max, filter)
+	ret := make([]*core.Transaction, len(items))
+	for i, item := range items {
+		heap.Remove(store.txq, item.index)
+		ret[i] = item.tx
+	}
+	return ret
+}
+
+func (store *txStore) getTxsFromQueueBy(max int, filter TxFilter) []*core.Transaction {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	items := store.filterQueue(max, filter)
+	ret := make([]*core.Transaction, len(items))
+	for i, item := range items {
+		ret[i] = item.tx
+	}
+	return ret
+}
+
+func (store *txStore) filterQueue(max int, filter TxFilter) []*txItem {
+	now := time.Now().UnixNano()
+	items := make([]*txItem, 0)
+	for _, item := range *store.txq {
+		if filter(item.tx, time.Duration(now-item.receivedTime)) {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].receivedTime < items[j].receivedTime
+	})
+	if len(items) > max {
+		items = items[:max]
+	}
+	return items
+}
+
 func (store *txStore) putTxsToQueue(hashes [][]byte) {
 	store.mtx.Lock()
 	defer store.mtx.Unlock()
@@ -92,6 +92,46 @@ func TestTxStore_popTxsFromQueue(t *testing.T) {
 	assert.Nil(txs)
 }
 
+func TestTxStore_popTxsFromQueueBy(t *testing.T) {
+	assert := assert.New(t)
+
+	priv := core.GenerateKey(nil)
+	txs := make([]*core.Transaction, 4)
+	store := newTxStore()
+	for i := range txs {
+		txs[i] = core.NewTransaction().SetNonce(int64(i)).Sign(priv)
+		store.addNewTx(txs[i], false)
+		time.Sleep(1 * time.Microsecond)
+	}
+	odd := func(tx *core.Transaction, waited time.Duration) bool {
+		return tx.Nonce()%2 == 1 && waited > 0
+	}
+
+	res := store.getTxsFromQueueBy(5, odd)
+	assert.Equal(2, len(res))
+	assert.Equal(txs[1].Hash(), res[0].Hash())
+	assert.Equal(txs[3].Hash(), res[1].Hash())
+	assert.Equal(4, store.getStatus().Queue)
+
+	res = store.popTxsFromQueueBy(1, odd)
+	assert.Equal(1, len(res))
+	assert.Equal(txs[1].Hash(), res[0].Hash())
+	assert.False(store.txItems[string(txs[1].Hash())].inQueue())
+	assert.Equal(3, store.getStatus().Queue)
+	assert.Equal(1, store.getStatus().Pending)
+
+	res = store.popTxsFromQueueBy(5, odd)
+	assert.Equal(1, len(res))
+	assert.Equal(txs[3].Hash(), res[0].Hash())
+	assert.Empty(store.popTxsFromQueueBy(5, odd))
+
+	// other txs stay in the queue in received order
+	res = store.popTxsFromQueue(5)
+	assert.Equal(2, len(res))
+	assert.Equal(txs[0].Hash(), res[0].Hash())
+	assert.Equal(txs[2].Hash(), res[1].Hash())
+}
+
 func TestTxStore_putTxsToQueue(t *testing.T) {
 	assert := assert.New(t)
 